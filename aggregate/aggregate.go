@@ -0,0 +1,160 @@
+// Package aggregate implements a binary tree in which each node also records an aggregate of its
+// subtree, such as a sum, a minimum or a maximum of a field of the payloads. `QueryRange()` then
+// returns the aggregate over a range of nodes in O(log n), without visiting the nodes within the
+// range. The tree is a `btree.Augmented`, so it stays balanced as nodes are added and removed.
+//
+// The nodes that are added are the caller's `*btree.Node`s, ordered by the caller's
+// `btree.LessFunc`. The tree keeps them in a `btree.Augmented` of its own, so their `Left` and
+// `Right` pointers are not used.
+package aggregate
+
+import (
+	"github.com/KarelKubat/btree"
+)
+
+// ValueFunc returns the value of a node that is aggregated, e.g. a field of its payload.
+type ValueFunc func(n *btree.Node) interface{}
+
+// CombineFunc combines two aggregates (or values) into one. It must be associative; it needn't be
+// commutative, since aggregates are always combined in the order of the nodes.
+type CombineFunc func(a, b interface{}) interface{}
+
+// item is the payload of the nodes of the underlying tree: a caller's node, and the aggregate of
+// the subtree.
+type item struct {
+	n   *btree.Node
+	agg interface{}
+}
+
+// Tree is a tree of aggregates. It is not safe for concurrent use.
+type Tree struct {
+	less    btree.LessFunc
+	value   ValueFunc
+	combine CombineFunc
+	a       *btree.Augmented
+}
+
+// New returns an empty `Tree` that orders its nodes using `less`, and aggregates the `value`s of
+// its nodes using `combine`.
+func New(less btree.LessFunc, value ValueFunc, combine CombineFunc) *Tree {
+	t := &Tree{less: less, value: value, combine: combine}
+	t.a = btree.NewAugmented(func(a, b *btree.Node) bool {
+		return less(a.Payload.(*item).n, b.Payload.(*item).n)
+	}, t.fix)
+	return t
+}
+
+func itemOf(n *btree.Node) *item {
+	return n.Payload.(*item)
+}
+
+// join combines the optional aggregates `a` and `b`; `ok` is `false` when both are absent.
+func (t *Tree) join(a interface{}, aok bool, b interface{}, bok bool) (agg interface{}, ok bool) {
+	switch {
+	case aok && bok:
+		return t.combine(a, b), true
+	case aok:
+		return a, true
+	}
+	return b, bok
+}
+
+// aggOf returns the aggregate of the subtree under `n`, or `false` when it is empty.
+func aggOf(n *btree.Node) (agg interface{}, ok bool) {
+	if n == nil {
+		return nil, false
+	}
+	return itemOf(n).agg, true
+}
+
+// fix recomputes the aggregate of the subtree under `n` from those of its sub-nodes.
+func (t *Tree) fix(n *btree.Node) {
+	it := itemOf(n)
+	left, lok := aggOf(n.Left)
+	agg, _ := t.join(left, lok, t.value(it.n), true)
+	right, rok := aggOf(n.Right)
+	it.agg, _ = t.join(agg, true, right, rok)
+}
+
+// key returns a node of the underlying tree to look up the caller's node `n` with.
+func key(n *btree.Node) *btree.Node {
+	return &btree.Node{Payload: &item{n: n}}
+}
+
+// Upsert adds `n`, unless an equal node is present, like `btree.BTree.Upsert()`. The return value
+// `intree` is the node in the tree, and `inserted` is `true` when `n` was added.
+func (t *Tree) Upsert(n *btree.Node) (intree *btree.Node, inserted bool) {
+	at, inserted := t.a.Upsert(key(n))
+	return itemOf(at).n, inserted
+}
+
+// Find looks up a node, like `btree.BTree.Find()`. Its payload must not be changed in a way that
+// changes its value, except via `Update()`.
+func (t *Tree) Find(n *btree.Node) (intree *btree.Node, found bool) {
+	if at, found := t.a.Find(key(n)); found {
+		return itemOf(at).n, true
+	}
+	return nil, false
+}
+
+// Update calls `fn` with the node like `n`, so that its payload may be changed, and then
+// recomputes the aggregates that depend on it. The payload must keep its place in the order. The
+// return value is `false` when there is no such node.
+func (t *Tree) Update(n *btree.Node, fn btree.WalkFunc) bool {
+	return t.a.Update(key(n), func(at *btree.Node) { fn(itemOf(at).n) })
+}
+
+// Delete removes a node, like `btree.BTree.Delete()`.
+func (t *Tree) Delete(n *btree.Node) (removed *btree.Node, deleted bool) {
+	if removed, deleted = t.a.Delete(key(n)); !deleted {
+		return nil, false
+	}
+	return itemOf(removed).n, true
+}
+
+// Total returns the aggregate of all nodes, or `false` when the tree is empty.
+func (t *Tree) Total() (agg interface{}, ok bool) {
+	return aggOf(t.a.Root())
+}
+
+// QueryRange returns the aggregate of the nodes `n` with `from <= n < to`, or `false` when there
+// are none. When `from` is `nil`, the range starts at the smallest node; when `to` is `nil`, it
+// runs up to and including the largest node. These are the bounds of
+// `btree.BTree.AscendRange()`.
+func (t *Tree) QueryRange(from, to *btree.Node) (agg interface{}, ok bool) {
+	return t.query(t.a.Root(), from, to)
+}
+
+func (t *Tree) query(n, from, to *btree.Node) (agg interface{}, ok bool) {
+	if n == nil {
+		return nil, false
+	}
+	if from == nil && to == nil {
+		return itemOf(n).agg, true // the whole subtree
+	}
+	it := itemOf(n)
+	if from != nil && t.less(it.n, from) {
+		return t.query(n.Right, from, to)
+	}
+	if to != nil && !t.less(it.n, to) {
+		return t.query(n.Left, from, to)
+	}
+	// `n` is in the range, so everything right of it is above `from`, and everything left of it
+	// is below `to`: each side has just one bound, and one of the sub-queries of each level
+	// below is a whole subtree.
+	left, lok := t.query(n.Left, from, nil)
+	agg, _ = t.join(left, lok, t.value(it.n), true)
+	right, rok := t.query(n.Right, nil, to)
+	return t.join(agg, true, right, rok)
+}
+
+// DepthFirstInOrder calls `walk` for each node, in order.
+func (t *Tree) DepthFirstInOrder(walk btree.WalkFunc) {
+	t.a.Tree().DepthFirstInOrder(func(n *btree.Node) { walk(itemOf(n).n) })
+}
+
+// Rebalance rebalances the underlying tree, see `btree.Augmented.Rebalance()`. The tree stays
+// balanced without it.
+func (t *Tree) Rebalance() {
+	t.a.Rebalance()
+}
@@ -0,0 +1,135 @@
+package aggregate
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/KarelKubat/btree"
+)
+
+func intLess(a, b *btree.Node) bool {
+	return a.Payload.(int) < b.Payload.(int)
+}
+
+func node(v int) *btree.Node {
+	return &btree.Node{Payload: v}
+}
+
+func sum(a, b interface{}) interface{} {
+	return a.(int) + b.(int)
+}
+
+func TestQueryRange(t *testing.T) {
+	tr := New(intLess, func(n *btree.Node) interface{} { return n.Payload }, sum)
+	if _, ok := tr.Total(); ok {
+		t.Errorf("Total() of an empty tree = true, want false")
+	}
+	for _, v := range []int{50, 20, 80, 10, 30, 70, 90} {
+		tr.Upsert(node(v))
+	}
+	for _, test := range []struct {
+		from, to *btree.Node
+		want     int
+		ok       bool
+	}{
+		{from: nil, to: nil, want: 350, ok: true},
+		{from: node(20), to: node(80), want: 20 + 30 + 50 + 70, ok: true},
+		{from: node(25), to: nil, want: 30 + 50 + 70 + 80 + 90, ok: true},
+		{from: nil, to: node(30), want: 10 + 20, ok: true},
+		{from: node(31), to: node(49), ok: false},
+	} {
+		got, ok := tr.QueryRange(test.from, test.to)
+		if ok != test.ok || (ok && got != test.want) {
+			t.Errorf("QueryRange(%v, %v) = %v, %v, want %v, %v", test.from, test.to, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestOrderIsKept(t *testing.T) {
+	// Concatenation is not commutative.
+	tr := New(func(a, b *btree.Node) bool { return a.Payload.(string) < b.Payload.(string) },
+		func(n *btree.Node) interface{} { return n.Payload },
+		func(a, b interface{}) interface{} { return a.(string) + b.(string) })
+	for _, s := range []string{"d", "b", "f", "a", "c", "e", "g"} {
+		tr.Upsert(&btree.Node{Payload: s})
+	}
+	if got, _ := tr.Total(); got != "abcdefg" {
+		t.Errorf("Total() = %v, want abcdefg", got)
+	}
+	if got, _ := tr.QueryRange(&btree.Node{Payload: "b"}, &btree.Node{Payload: "f"}); got != "bcde" {
+		t.Errorf("QueryRange(b, f) = %v, want bcde", got)
+	}
+}
+
+type account struct {
+	id, balance int
+}
+
+func TestUpdate(t *testing.T) {
+	tr := New(func(a, b *btree.Node) bool { return a.Payload.(*account).id < b.Payload.(*account).id },
+		func(n *btree.Node) interface{} { return n.Payload.(*account).balance }, sum)
+	for i := 0; i < 10; i++ {
+		tr.Upsert(&btree.Node{Payload: &account{id: i, balance: 10}})
+	}
+	if !tr.Update(&btree.Node{Payload: &account{id: 3}}, func(n *btree.Node) { n.Payload.(*account).balance = 110 }) {
+		t.Fatalf("Update(3) = false, want true")
+	}
+	if got, _ := tr.Total(); got != 200 {
+		t.Errorf("Total() after Update() = %v, want 200", got)
+	}
+	if tr.Update(&btree.Node{Payload: &account{id: 10}}, func(*btree.Node) {}) {
+		t.Errorf("Update(10) = true, want false")
+	}
+}
+
+func TestAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tr := New(intLess, func(n *btree.Node) interface{} { return n.Payload },
+		func(a, b interface{}) interface{} { return max(a.(int), b.(int)) })
+	present := map[int]bool{}
+	for i := 0; i < 5000; i++ {
+		v := r.Intn(500)
+		switch op := r.Intn(10); {
+		case op < 5:
+			tr.Upsert(node(v))
+			present[v] = true
+		case op < 7:
+			if _, deleted := tr.Delete(node(v)); deleted != present[v] {
+				t.Fatalf("op %v: Delete(%v) = %v", i, v, deleted)
+			}
+			delete(present, v)
+		case op < 8:
+			tr.Rebalance()
+		default:
+			lo, hi := v, v+r.Intn(100)
+			want, wantOK := 0, false
+			for p := range present {
+				if p >= lo && p < hi && (!wantOK || p > want) {
+					want, wantOK = p, true
+				}
+			}
+			got, ok := tr.QueryRange(node(lo), node(hi))
+			if ok != wantOK || (ok && got != want) {
+				t.Fatalf("op %v: max of [%v, %v) = %v, %v, want %v, %v", i, lo, hi, got, ok, want, wantOK)
+			}
+		}
+	}
+}
+
+func TestBalanced(t *testing.T) {
+	tr := New(intLess, func(n *btree.Node) interface{} { return n.Payload }, sum)
+	// Ascending insertions would make an unbalanced tree a chain.
+	for v := 1; v <= 4096; v++ {
+		tr.Upsert(node(v))
+	}
+	for v := 1; v <= 4096; v += 2 {
+		tr.Delete(node(v))
+	}
+	if h := tr.a.Tree().ShapeStats().Height; h > 2*12+2 {
+		t.Errorf("height = %v for 2048 nodes, want at most %v", h, 2*12+2)
+	}
+	// The even numbers from 2 to 100.
+	if got, ok := tr.QueryRange(node(1), node(101)); !ok || got != 2550 {
+		t.Errorf("QueryRange(1, 101) = %v, %v; want 2550", got, ok)
+	}
+}
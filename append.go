@@ -0,0 +1,32 @@
+package btree
+
+// Append adds `n` to the tree, like `Upsert()`, for workloads in which each new node is larger
+// than all nodes of the tree, such as time series or increasing IDs. The tree caches its largest
+// node, so that such a node is linked in after only one comparison instead of after a descent
+// from the root. Nodes that are not larger than the cached one are upserted normally, as are all
+// nodes when `MaxDepth` is set, since the depth of the cached node isn't known.
+//
+// As with `UseFinger`, the tree must only be changed via its methods; after changing `Root`,
+// `Left` or `Right` directly, call `ResetFinger()`.
+func (b *BTree) Append(n *Node) (intree *Node, inserted bool) {
+	defer b.beginWrite("Append")()
+	t := b.tail
+	if t.node == nil || t.root != b.Root || b.MaxDepth > 0 || !b.Less(t.node, n) {
+		return b.upsertCapped(n)
+	}
+	if b.CheckLess {
+		b.checkLess(n)
+	}
+	t.node.Right = n
+	b.descended(1)
+	b.added(n)
+	b.setTail(n)
+	b.setFinger(n, t.node, nil)
+	b.logUpsert(n, true)
+	return n, true
+}
+
+// setTail caches `n` as the largest node of the tree.
+func (b *BTree) setTail(n *Node) {
+	b.tail = finger{root: b.Root, node: n}
+}
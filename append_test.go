@@ -0,0 +1,61 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	b := New(intLess)
+	for _, v := range []int{1, 2, 3, 10, 5, 11, 11, 0, 12} {
+		b.Append(&Node{Payload: v})
+	}
+	if got, want := inOrderInts(b), []int{0, 1, 2, 3, 5, 10, 11, 12}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tree = %v, want %v", got, want)
+	}
+	b.Delete(&Node{Payload: 12})
+	b.Append(&Node{Payload: 13})
+	if got, want := inOrderInts(b), []int{0, 1, 2, 3, 5, 10, 11, 13}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tree after Delete() = %v, want %v", got, want)
+	}
+}
+
+func TestAppendChecked(t *testing.T) {
+	b := New(intLess)
+	b.MaxDepth = 10
+	for i := 0; i < 1000; i++ {
+		b.Append(&Node{Payload: i})
+	}
+	if h := b.ShapeStats().Height; h > b.MaxDepth {
+		t.Errorf("height after 1000 appends = %v, want at most MaxDepth %v", h, b.MaxDepth)
+	}
+	if got := inOrderInts(b); len(got) != 1000 || got[0] != 0 || got[999] != 999 {
+		t.Errorf("tree = %v, want 0..999", got)
+	}
+
+}
+
+func TestAppendComparesOnce(t *testing.T) {
+	compares := 0
+	b := New(func(x, y *Node) bool {
+		compares++
+		return intLess(x, y)
+	})
+	for i := 0; i < 1000; i++ {
+		b.Upsert(&Node{Payload: i})
+	}
+	compares = 0
+	for i := 1000; i < 2000; i++ {
+		b.Append(&Node{Payload: i})
+	}
+	if compares != 1000 {
+		t.Errorf("1000 appends compare %v times, want 1000", compares)
+	}
+}
+
+func BenchmarkAppend(b *testing.B) {
+	t := New(intLess)
+	for i := 0; i < b.N; i++ {
+		t.Append(&Node{Payload: i})
+	}
+}
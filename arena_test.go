@@ -0,0 +1,48 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArena(t *testing.T) {
+	b := New(intLess)
+	b.Arena = NewArena(4)
+	for _, v := range []int{5, 3, 8, 1, 4, 7, 9, 2, 6} {
+		if _, inserted := b.Upsert(b.NewNode(v)); !inserted {
+			t.Errorf("Upsert(%v) = not inserted", v)
+		}
+	}
+	if got, want := inOrderInts(b), []int{1, 2, 3, 4, 5, 6, 7, 8, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tree = %v, want %v", got, want)
+	}
+	if got := b.Arena.Blocks(); got != 3 {
+		t.Errorf("Blocks() = %v, want 3", got)
+	}
+	b.Clear()
+	if b.Root != nil || b.Arena.Blocks() != 0 {
+		t.Errorf("Clear() leaves %v, %v blocks", b.Root, b.Arena.Blocks())
+	}
+	if n := b.NewNode(1); n.Payload != 1 || n.Left != nil || n.Right != nil {
+		t.Errorf("NewNode(1) after Clear() = %+v, want a fresh node", n)
+	}
+}
+
+func BenchmarkArena(b *testing.B) {
+	for _, test := range []struct {
+		desc  string
+		arena *Arena
+	}{
+		{desc: "new", arena: nil},
+		{desc: "arena", arena: NewArena(0)},
+	} {
+		b.Run(test.desc, func(b *testing.B) {
+			b.ReportAllocs()
+			t := New(intLess)
+			t.Arena = test.arena
+			for i := 0; i < b.N; i++ {
+				t.NewNode(nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package btree
+
+// FixFunc recomputes the data that a node of an `Augmented` tree records about its subtree, such
+// as its size or the sum of a field, from the node's own payload and from the data of its
+// sub-nodes, which are up to date when it is called.
+type FixFunc func(n *Node)
+
+// Augmented is a binary tree that keeps itself balanced on insertion and deletion, and in which
+// each node may record data about its subtree, see `FixFunc`. It is the basis for trees that
+// answer questions about ranges of nodes in O(log n), such as the subpackages `orderstat`,
+// `interval`, `aggregate` and `merkle`.
+//
+// The tree is a scapegoat tree: when an insertion makes the tree too high for its number of
+// nodes, the lowest subtree above the new node that is more than twice as high as a balanced one
+// is rebalanced, and when deletions halve the number of nodes, the whole tree is. Lookups thus
+// take O(log n), and insertions and deletions O(log n) amortized. No balancing data is kept in the
+// nodes, so their payloads are the caller's.
+//
+// The fix function is called for each node whose subtree changed, from the bottom up: after
+// insertion and unlinking of a node, for the nodes above it, and after rebalancing, for the nodes
+// of the rebalanced subtree and above. It is thus called O(log n) times per mutation, amortized.
+//
+// An `Augmented` tree is not safe for concurrent use.
+type Augmented struct {
+	root *Node
+	less LessFunc
+	fix  FixFunc
+	// len is the number of nodes, and maxLen the largest number since the whole tree was last
+	// rebalanced.
+	len, maxLen int
+}
+
+// NewAugmented returns an empty `Augmented` tree that orders its nodes using `less`, and that calls
+// `fix` for the nodes whose subtrees change. `fix` may be `nil` when the nodes record nothing.
+func NewAugmented(less LessFunc, fix FixFunc) *Augmented {
+	if fix == nil {
+		fix = func(*Node) {}
+	}
+	return &Augmented{less: less, fix: fix}
+}
+
+// Len returns the number of nodes in the tree.
+func (a *Augmented) Len() int {
+	return a.len
+}
+
+// Root returns the top of the tree, or `nil` when it is empty. The tree must not be changed
+// through it, but it may be descended, e.g. to use the data that the nodes record.
+func (a *Augmented) Root() *Node {
+	return a.root
+}
+
+// Tree returns the tree as a `BTree`, which can be examined using all read-only methods
+// (`DepthFirstInOrder()`, `AscendRange()` and so on). It must not be modified.
+func (a *Augmented) Tree() *BTree {
+	return &BTree{Root: a.root, Less: a.less}
+}
+
+// Path returns the nodes from the top of the tree down to where `n` is, or would be inserted.
+// The return value `at` is the node that matches `n`, or `nil`; it is not part of `path`.
+func (a *Augmented) Path(n *Node) (path []*Node, at *Node) {
+	for at = a.root; at != nil; {
+		switch {
+		case a.less(n, at):
+			path, at = append(path, at), at.Left
+		case a.less(at, n):
+			path, at = append(path, at), at.Right
+		default:
+			return path, at
+		}
+	}
+	return path, nil
+}
+
+// Find looks up a node, see `BTree.Find()`.
+func (a *Augmented) Find(n *Node) (intree *Node, found bool) {
+	if _, at := a.Path(n); at != nil {
+		return at, true
+	}
+	return nil, false
+}
+
+// fixPath calls the fix function for the nodes of `path`, from the bottom up.
+func (a *Augmented) fixPath(path []*Node) {
+	for i := len(path) - 1; i >= 0; i-- {
+		a.fix(path[i])
+	}
+}
+
+// Upsert adds `n`, unless an equal node is present, like `BTree.Upsert()`. The node `n` becomes
+// part of the tree, so its `Left` and `Right` are overwritten. The return value `intree` is the
+// node in the tree, and `inserted` is `true` when `n` was added.
+func (a *Augmented) Upsert(n *Node) (intree *Node, inserted bool) {
+	path, at := a.Path(n)
+	if at != nil {
+		return at, false
+	}
+	n.Left, n.Right = nil, nil
+	a.relink(path, len(path), n)
+	a.fix(n)
+	a.fixPath(path)
+	a.len++
+	a.maxLen = max(a.maxLen, a.len)
+	if skewed(a.len, len(path)+1) {
+		a.rebalanceAbove(path, n)
+	}
+	return n, true
+}
+
+// relink makes `n` the sub-node of `path[i-1]` that is on the way to the old `path[i]`, or the
+// top of the tree when `i` is 0.
+func (a *Augmented) relink(path []*Node, i int, n *Node) {
+	switch {
+	case i == 0:
+		a.root = n
+	case a.less(n, path[i-1]):
+		path[i-1].Left = n
+	default:
+		path[i-1].Right = n
+	}
+}
+
+// rebalanceAbove rebalances the lowest subtree on `path` that is skewed because of the new node
+// `n` below it. Since the whole tree is skewed, there is such a subtree.
+func (a *Augmented) rebalanceAbove(path []*Node, n *Node) {
+	size, below := 1, n
+	for i := len(path) - 1; i >= 0; i-- {
+		sibling := path[i].Left
+		if sibling == below {
+			sibling = path[i].Right
+		}
+		size += 1 + count(sibling)
+		if skewed(size, len(path)-i+1) {
+			a.relink(path, i, a.rebalanced(path[i]))
+			a.fixPath(path[:i])
+			return
+		}
+		below = path[i]
+	}
+}
+
+// rebalanced rebalances the subtree under `top` and returns its new top, calling the fix function
+// for all of its nodes.
+func (a *Augmented) rebalanced(top *Node) *Node {
+	top, _ = rebalanced(top, nil)
+	var fixAll func(n *Node)
+	fixAll = func(n *Node) {
+		if n == nil {
+			return
+		}
+		fixAll(n.Left)
+		fixAll(n.Right)
+		a.fix(n)
+	}
+	fixAll(top)
+	return top
+}
+
+// count returns the number of nodes of the subtree under `n`.
+func count(n *Node) int {
+	if n == nil {
+		return 0
+	}
+	return 1 + count(n.Left) + count(n.Right)
+}
+
+// Update calls `fn` with the node like `n`, so that the data that it records may change, e.g. a
+// field of its payload, and then calls the fix function for it and the nodes above it. The
+// payload must keep its place in the order. The return value is `false` when there is no such
+// node.
+func (a *Augmented) Update(n *Node, fn WalkFunc) bool {
+	path, at := a.Path(n)
+	if at == nil {
+		return false
+	}
+	fn(at)
+	a.fix(at)
+	a.fixPath(path)
+	return true
+}
+
+// Delete removes the node like `n`, like `BTree.Delete()`. The removed node's `Left` and `Right`
+// are cleared.
+func (a *Augmented) Delete(n *Node) (removed *Node, deleted bool) {
+	if _, found := a.Find(n); !found {
+		return nil, false
+	}
+	a.root, removed = a.deleteFrom(a.root, n)
+	removed.Left, removed.Right = nil, nil
+	a.len--
+	if a.len <= a.maxLen/2 {
+		a.Rebalance()
+	}
+	return removed, true
+}
+
+// deleteFrom removes the node like `n`, which is known to be present, from the subtree under
+// `from`.
+func (a *Augmented) deleteFrom(from, n *Node) (top, removed *Node) {
+	switch {
+	case a.less(n, from):
+		from.Left, removed = a.deleteFrom(from.Left, n)
+	case a.less(from, n):
+		from.Right, removed = a.deleteFrom(from.Right, n)
+	default:
+		removed = from
+		switch {
+		case from.Left == nil:
+			from = from.Right
+		case from.Right == nil:
+			from = from.Left
+		default:
+			var succ *Node
+			succ, from.Right = a.removeMin(from.Right)
+			succ.Left, succ.Right = from.Left, from.Right
+			from = succ
+		}
+		if from == nil {
+			return nil, removed
+		}
+	}
+	a.fix(from)
+	return from, removed
+}
+
+// removeMin unlinks the smallest node of the subtree under `n`, and returns it and the new top.
+func (a *Augmented) removeMin(n *Node) (min, top *Node) {
+	if n.Left == nil {
+		return n, n.Right
+	}
+	min, n.Left = a.removeMin(n.Left)
+	a.fix(n)
+	return min, n
+}
+
+// Rebalance rebalances the whole tree, see `BTree.Rebalance()`. Trees with the same number of
+// nodes then have the same shape. It is not needed to keep the tree balanced.
+func (a *Augmented) Rebalance() {
+	a.root = a.rebalanced(a.root)
+	a.maxLen = a.len
+}
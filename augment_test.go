@@ -0,0 +1,138 @@
+package btree
+
+import (
+	"math/bits"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// sized is a payload that records the size of its subtree.
+type sized struct {
+	v, size int
+}
+
+func sizedLess(a, b *Node) bool {
+	return a.Payload.(*sized).v < b.Payload.(*sized).v
+}
+
+func fixSize(n *Node) {
+	s := n.Payload.(*sized)
+	s.size = 1
+	for _, sub := range []*Node{n.Left, n.Right} {
+		if sub != nil {
+			s.size += sub.Payload.(*sized).size
+		}
+	}
+}
+
+// checkSized fails when a node of `a` records a wrong size, or when `a` is skewed.
+func checkSized(t *testing.T, a *Augmented, when string) {
+	t.Helper()
+	var walk func(n *Node) int
+	walk = func(n *Node) int {
+		if n == nil {
+			return 0
+		}
+		size := 1 + walk(n.Left) + walk(n.Right)
+		if got := n.Payload.(*sized).size; got != size {
+			t.Fatalf("%v: node %v records size %v, want %v", when, n.Payload.(*sized).v, got, size)
+		}
+		return size
+	}
+	if size := walk(a.Root()); size != a.Len() {
+		t.Fatalf("%v: %v nodes, Len() = %v", when, size, a.Len())
+	}
+	if h, limit := height(a.Root()), 2*bits.Len(uint(a.Len()))+2; h > limit {
+		t.Fatalf("%v: height %v for %v nodes, want at most %v", when, h, a.Len(), limit)
+	}
+}
+
+func TestAugmented(t *testing.T) {
+	a := NewAugmented(sizedLess, fixSize)
+	// Sorted insertions would make a chain of an unbalanced tree.
+	for v := 0; v < 1000; v++ {
+		if _, inserted := a.Upsert(&Node{Payload: &sized{v: v}}); !inserted {
+			t.Fatalf("Upsert(%v) = not inserted", v)
+		}
+		checkSized(t, a, "ascending upserts")
+	}
+	if in, inserted := a.Upsert(&Node{Payload: &sized{v: 7}}); inserted || in.Payload.(*sized).v != 7 {
+		t.Errorf("Upsert(7) again = %v, %v; want 7, not inserted", in, inserted)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for _, v := range rng.Perm(1000)[:900] {
+		removed, deleted := a.Delete(&Node{Payload: &sized{v: v}})
+		if !deleted || removed.Payload.(*sized).v != v || removed.Left != nil || removed.Right != nil {
+			t.Fatalf("Delete(%v) = %v, %v; want the detached node", v, removed, deleted)
+		}
+		checkSized(t, a, "random deletes")
+	}
+	if _, deleted := a.Delete(&Node{Payload: &sized{v: -1}}); deleted {
+		t.Errorf("Delete(-1) = deleted, want not")
+	}
+	for v := 2000; v > 1000; v-- {
+		a.Upsert(&Node{Payload: &sized{v: v}})
+		checkSized(t, a, "descending upserts")
+	}
+	if got := len(inOrderAugmented(a)); got != 1100 {
+		t.Errorf("%v nodes, want 1100", got)
+	}
+}
+
+func TestAugmentedUpdate(t *testing.T) {
+	// The sums of the subtrees of ints.
+	sum := func(n *Node) int {
+		if n == nil {
+			return 0
+		}
+		return n.Payload.([]int)[1]
+	}
+	a := NewAugmented(func(x, y *Node) bool {
+		return x.Payload.([]int)[0] < y.Payload.([]int)[0]
+	}, func(n *Node) {
+		n.Payload.([]int)[1] = n.Payload.([]int)[0] + sum(n.Left) + sum(n.Right)
+	})
+	for _, v := range []int{4, 2, 6, 1, 3, 5, 7} {
+		a.Upsert(&Node{Payload: []int{v, 0}})
+	}
+	if got := sum(a.Root()); got != 28 {
+		t.Fatalf("sum = %v, want 28", got)
+	}
+	if !a.Update(&Node{Payload: []int{3, 0}}, func(n *Node) { n.Payload.([]int)[0] = 3 }) {
+		t.Errorf("Update(3) = false, want true")
+	}
+	if a.Update(&Node{Payload: []int{8, 0}}, func(*Node) {}) {
+		t.Errorf("Update(8) = true, want false")
+	}
+	a.Delete(&Node{Payload: []int{4, 0}})
+	if got := sum(a.Root()); got != 24 {
+		t.Errorf("sum after Delete(4) = %v, want 24", got)
+	}
+	path, at := a.Path(&Node{Payload: []int{7, 0}})
+	if at == nil || len(path) == 0 || path[0] != a.Root() {
+		t.Errorf("Path(7) = %v, %v; want a path from the root to 7", path, at)
+	}
+}
+
+func TestAugmentedRebalance(t *testing.T) {
+	a, b := NewAugmented(intLess, nil), NewAugmented(intLess, nil)
+	for v := 0; v < 20; v++ {
+		a.Upsert(&Node{Payload: v})
+		b.Upsert(&Node{Payload: 19 - v})
+	}
+	a.Rebalance()
+	b.Rebalance()
+	if !reflect.DeepEqual(a.Root(), b.Root()) {
+		t.Errorf("rebalanced trees with the same nodes have different shapes")
+	}
+	if got := inOrderInts(a.Tree()); len(got) != 20 || got[0] != 0 || got[19] != 19 {
+		t.Errorf("Tree() = %v, want 0..19", got)
+	}
+}
+
+func inOrderAugmented(a *Augmented) []int {
+	out := []int{}
+	a.Tree().DepthFirstInOrder(func(n *Node) { out = append(out, n.Payload.(*sized).v) })
+	return out
+}
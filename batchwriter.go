@@ -0,0 +1,112 @@
+package btree
+
+import "sync"
+
+// BatchWriter is an asynchronous front-end for a `SyncTree`, for high ingest rates. Its
+// `Upsert()` only queues the node; a background goroutine collects queued nodes and applies them
+// in batches, taking the lock of the tree once per batch. The price is a little latency: nodes
+// appear in the tree shortly after `Upsert()` returns. `Flush()` waits until they have.
+//
+// A `BatchWriter` is safe for concurrent use. It must be closed using `Close()` when done.
+type BatchWriter struct {
+	s        *SyncTree
+	in       chan batchItem
+	maxBatch int
+	wg       sync.WaitGroup
+	closeMu  sync.Mutex
+	closed   bool
+}
+
+// batchItem is a node to upsert, or when `flushed` is set, a request to signal that all earlier
+// nodes are applied.
+type batchItem struct {
+	n       *Node
+	flushed chan struct{}
+}
+
+// NewBatchWriter returns a `BatchWriter` for `s` that applies up to `maxBatch` nodes per lock
+// acquisition.
+func NewBatchWriter(s *SyncTree, maxBatch int) *BatchWriter {
+	if maxBatch < 1 {
+		maxBatch = 1
+	}
+	w := &BatchWriter{s: s, in: make(chan batchItem, maxBatch), maxBatch: maxBatch}
+	w.wg.Add(1)
+	go w.run()
+	return w
+}
+
+// Upsert queues `n` for `SyncTree.Upsert()`. Nodes are applied in the order in which they are
+// queued; of equal nodes, the first is kept. `Upsert()` must not be called after `Close()`.
+func (w *BatchWriter) Upsert(n *Node) {
+	w.in <- batchItem{n: n}
+}
+
+// Flush waits until all nodes that were queued before are applied.
+func (w *BatchWriter) Flush() {
+	flushed := make(chan struct{})
+	w.in <- batchItem{flushed: flushed}
+	<-flushed
+}
+
+// Close applies all queued nodes and stops the background goroutine. Further calls have no
+// effect.
+func (w *BatchWriter) Close() {
+	w.closeMu.Lock()
+	defer w.closeMu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	close(w.in)
+	w.wg.Wait()
+}
+
+func (w *BatchWriter) run() {
+	defer w.wg.Done()
+	batch := make([]*Node, 0, w.maxBatch)
+	var flushes []chan struct{}
+	for item := range w.in {
+		batch, flushes = batch[:0], flushes[:0]
+		w.collect(item, &batch, &flushes)
+	drain:
+		for len(batch) < w.maxBatch {
+			select {
+			case item, ok := <-w.in:
+				if !ok {
+					break drain
+				}
+				w.collect(item, &batch, &flushes)
+			default:
+				break drain
+			}
+		}
+		w.apply(batch)
+		for _, f := range flushes {
+			close(f)
+		}
+	}
+}
+
+func (w *BatchWriter) collect(item batchItem, batch *[]*Node, flushes *[]chan struct{}) {
+	if item.flushed != nil {
+		*flushes = append(*flushes, item.flushed)
+		return
+	}
+	*batch = append(*batch, item.n)
+}
+
+// apply upserts the batch under one lock acquisition, in the order in which the nodes were
+// queued. Sorting the batch first would be kinder to the CPU caches, but then each batch is linked
+// in as a chain of ascending nodes, which skews the tree.
+func (w *BatchWriter) apply(batch []*Node) {
+	if len(batch) == 0 {
+		return
+	}
+	w.s.mu.Lock()
+	defer w.s.mu.Unlock()
+	for i, n := range batch {
+		batch[i] = nil
+		w.s.upsert(n)
+	}
+}
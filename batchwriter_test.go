@@ -0,0 +1,87 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestBatchWriter(t *testing.T) {
+	s := Synchronized(New(kvLess))
+	w := NewBatchWriter(s, 16)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				w.Upsert(&Node{Payload: kv{key: string(rune('a' + i%26)), val: "x"}})
+			}
+		}(g)
+	}
+	wg.Wait()
+	w.Upsert(&Node{Payload: kv{key: "zz", val: "first"}})
+	w.Upsert(&Node{Payload: kv{key: "zz", val: "second"}})
+	w.Flush()
+
+	n, found := s.Find(&Node{Payload: kv{key: "zz"}})
+	if !found || n.Payload.(kv).val != "first" {
+		t.Errorf("after Flush(): Find(zz) = %v, %v; want zz=first", n, found)
+	}
+	w.Upsert(&Node{Payload: kv{key: "zzz"}})
+	w.Close()
+	w.Close()
+	var got []string
+	s.DepthFirstInOrder(func(n *Node) { got = append(got, n.Payload.(kv).key) })
+	want := []string{}
+	for i := 0; i < 26; i++ {
+		want = append(want, string(rune('a'+i)))
+	}
+	want = append(want, "zz", "zzz")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("after Close() = %v, want %v", got, want)
+	}
+}
+
+func TestBatchWriterKeepsShape(t *testing.T) {
+	// Batches are applied in arrival order, so the tree has the shape of direct upserts.
+	keys := rand.New(rand.NewSource(1)).Perm(100000)
+	s := Synchronized(New(intLess))
+	w := NewBatchWriter(s, 1000)
+	for _, k := range keys {
+		w.Upsert(&Node{Payload: k})
+	}
+	w.Close()
+	direct := newIntTree(keys...)
+	got, want := s.t.ShapeStats().Height, direct.ShapeStats().Height
+	if got != want {
+		t.Errorf("height after BatchWriter upserts = %v, want %v as with direct upserts", got, want)
+	}
+}
+
+func BenchmarkBatchWriter(b *testing.B) {
+	s := Synchronized(New(intLess))
+	w := NewBatchWriter(s, 256)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			w.Upsert(&Node{Payload: i * 7919 % 100000})
+			i++
+		}
+	})
+	w.Close()
+}
+
+func BenchmarkSyncTreeUpsert(b *testing.B) {
+	s := Synchronized(New(intLess))
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			s.Upsert(&Node{Payload: i * 7919 % 100000})
+			i++
+		}
+	})
+}
@@ -0,0 +1,376 @@
+package btree
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The binary format stores the nodes in preorder. Each node is a flags byte, the length of the
+// encoded payload as a varint, and the encoded payload itself. The flags tell whether the node
+// has a left and/or a right sub-node. An empty tree is a single `binEmpty` byte.
+//
+// Since format version 1, a stream starts with a header: `binMagic`, the version byte, and an
+// options byte that holds the `Compression` of the rest of the stream. Furthermore each node
+// ends in an extension: a varint length and as many bytes, which decoders skip unless they know
+// what to do with it. That way later versions can add information to nodes without breaking
+// older decoders. Streams without a header are version 0, which has no node extensions.
+const (
+	binHasLeft  = 1 << 0
+	binHasRight = 1 << 1
+	binEmpty    = 1 << 7
+)
+
+// BinaryFormatVersion is the version of the binary format that an `Encoder` writes. A `Decoder`
+// reads this version and all older ones.
+const BinaryFormatVersion = 1
+
+// binMagic starts a versioned stream. Its first byte can't be confused with the first byte of a
+// version 0 stream, which is a node flags byte or `binEmpty`.
+var binMagic = []byte{0xb7, 'B', 'T'}
+
+// Compression selects how an `Encoder` compresses its output, see `WithCompression()`.
+type Compression byte
+
+const (
+	// NoCompression leaves the output uncompressed. This is the default.
+	NoCompression Compression = iota
+	// GzipCompression compresses the output using gzip.
+	GzipCompression
+)
+
+// maxBinPayload limits the size of a payload that a `Decoder` accepts. Within it, payloads are
+// read incrementally (see `readPayload()`), so that a corrupt size costs no more memory than the
+// input that backs it.
+const maxBinPayload = 1 << 30
+
+// Encoder writes trees to an output stream in a compact binary form. The nodes are streamed while
+// the tree is walked, so that memory usage is bounded by the height of the tree, not by its size.
+// Several trees may be written to the same stream; a `Decoder` reads them back one by one.
+type Encoder struct {
+	// raw writes to the output stream. The trees are written to w, which is either the same as
+	// raw, or writes via gz to raw.
+	raw, w        *bufio.Writer
+	gz            *gzip.Writer
+	compression   Compression
+	codec         PayloadCodec
+	lenbuf        [binary.MaxVarintLen64]byte
+	headerWritten bool
+}
+
+// EncoderOption configures an `Encoder`, see e.g. `WithCompression()`.
+type EncoderOption func(e *Encoder)
+
+// WithCompression returns an `EncoderOption` that compresses the encoded trees. The stream header
+// stays uncompressed and records the compression, so that a `Decoder` detects it by itself.
+func WithCompression(c Compression) EncoderOption {
+	return func(e *Encoder) {
+		e.compression = c
+	}
+}
+
+// NewEncoder returns an `Encoder` that writes to `w`, using `codec` for the payloads. When the
+// `Encoder` compresses, `Close()` must be called when done.
+func NewEncoder(w io.Writer, codec PayloadCodec, opts ...EncoderOption) *Encoder {
+	e := &Encoder{
+		raw:   bufio.NewWriter(w),
+		codec: codec,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// writeHeader writes the stream header and sets up compression.
+func (e *Encoder) writeHeader() error {
+	if _, err := e.raw.Write(binMagic); err != nil {
+		return err
+	}
+	if _, err := e.raw.Write([]byte{BinaryFormatVersion, byte(e.compression)}); err != nil {
+		return err
+	}
+	switch e.compression {
+	case NoCompression:
+		e.w = e.raw
+	case GzipCompression:
+		e.gz = gzip.NewWriter(e.raw)
+		e.w = bufio.NewWriter(e.gz)
+	default:
+		return fmt.Errorf("btree: unknown compression %v", e.compression)
+	}
+	e.headerWritten = true
+	return nil
+}
+
+// Encode writes the tree `b` to the stream. The first call also writes the stream header.
+func (e *Encoder) Encode(b *BTree) error {
+	if !e.headerWritten {
+		if err := e.writeHeader(); err != nil {
+			return err
+		}
+	}
+	if b.Root == nil {
+		if err := e.w.WriteByte(binEmpty); err != nil {
+			return err
+		}
+		return e.flush()
+	}
+	stack := []*Node{b.Root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n.Right != nil {
+			stack = append(stack, n.Right)
+		}
+		if n.Left != nil {
+			stack = append(stack, n.Left)
+		}
+		if err := e.encodeNode(n); err != nil {
+			return err
+		}
+	}
+	return e.flush()
+}
+
+// flush pushes everything that was encoded so far to the output stream, so that a `Decoder` on
+// the other side can read it.
+func (e *Encoder) flush() error {
+	if e.gz == nil {
+		return e.w.Flush()
+	}
+	if err := e.w.Flush(); err != nil {
+		return err
+	}
+	if err := e.gz.Flush(); err != nil {
+		return err
+	}
+	return e.raw.Flush()
+}
+
+// Close finishes the stream. It is required when the `Encoder` compresses; otherwise it is a
+// no-op. The underlying writer is not closed.
+func (e *Encoder) Close() error {
+	if e.gz == nil {
+		return nil
+	}
+	if err := e.w.Flush(); err != nil {
+		return err
+	}
+	if err := e.gz.Close(); err != nil {
+		return err
+	}
+	return e.raw.Flush()
+}
+
+func (e *Encoder) encodeNode(n *Node) error {
+	var flags byte
+	if n.Left != nil {
+		flags |= binHasLeft
+	}
+	if n.Right != nil {
+		flags |= binHasRight
+	}
+	payload, err := e.codec.EncodePayload(n.Payload)
+	if err != nil {
+		return fmt.Errorf("btree: cannot encode payload %v: %v", n.Payload, err)
+	}
+	if err := e.w.WriteByte(flags); err != nil {
+		return err
+	}
+	if err := e.writeUvarint(uint64(len(payload))); err != nil {
+		return err
+	}
+	if _, err = e.w.Write(payload); err != nil {
+		return err
+	}
+	// No node extensions are defined yet.
+	return e.writeUvarint(0)
+}
+
+func (e *Encoder) writeUvarint(v uint64) error {
+	_, err := e.w.Write(e.lenbuf[:binary.PutUvarint(e.lenbuf[:], v)])
+	return err
+}
+
+// Decoder reads trees that an `Encoder` wrote. Nodes are decoded straight from the input stream,
+// without an intermediate buffer holding the encoded tree.
+type Decoder struct {
+	r          *bufio.Reader
+	codec      PayloadCodec
+	version    int
+	headerRead bool
+}
+
+// NewDecoder returns a `Decoder` that reads from `r`, using `codec` for the payloads. The
+// `Decoder` may read ahead from `r`.
+func NewDecoder(r io.Reader, codec PayloadCodec) *Decoder {
+	return &Decoder{
+		r:     bufio.NewReader(r),
+		codec: codec,
+	}
+}
+
+// Version returns the format version of the stream. It is known after the first call of
+// `Decode()`.
+func (d *Decoder) Version() int {
+	return d.version
+}
+
+// readHeader determines the format version of the stream.
+func (d *Decoder) readHeader() error {
+	start, err := d.r.Peek(len(binMagic))
+	if err != nil && !(errors.Is(err, io.EOF) && len(start) > 0) {
+		return err
+	}
+	if len(start) < len(binMagic) && bytes.HasPrefix(binMagic, start) {
+		return io.ErrUnexpectedEOF
+	}
+	if !bytes.Equal(start, binMagic) {
+		d.version = 0
+		return nil
+	}
+	d.r.Discard(len(binMagic))
+	var header [2]byte
+	if _, err := io.ReadFull(d.r, header[:]); err != nil {
+		return unexpectedEOF(err)
+	}
+	if header[0] > BinaryFormatVersion {
+		return fmt.Errorf("btree: binary format version %v is not supported, at most %v is", header[0], BinaryFormatVersion)
+	}
+	d.version = int(header[0])
+	switch Compression(header[1]) {
+	case NoCompression:
+	case GzipCompression:
+		gz, err := gzip.NewReader(d.r)
+		if err != nil {
+			return fmt.Errorf("btree: cannot decompress binary input: %v", unexpectedEOF(err))
+		}
+		d.r = bufio.NewReader(gz)
+	default:
+		return fmt.Errorf("btree: corrupt binary input, unknown compression %#x", header[1])
+	}
+	return nil
+}
+
+// Decode reads the next tree from the stream and stores its nodes in `b`, replacing what `b`
+// held. The `LessFunc` of `b` is kept. At the end of the stream, `io.EOF` is returned.
+func (d *Decoder) Decode(b *BTree) error {
+	if !d.headerRead {
+		if err := d.readHeader(); err != nil {
+			return err
+		}
+		d.headerRead = true
+	}
+	flags, err := d.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if flags == binEmpty {
+		b.load(nil)
+		return nil
+	}
+	if err := d.r.UnreadByte(); err != nil {
+		return err
+	}
+
+	var root *Node
+	slots := []**Node{&root}
+	for len(slots) > 0 {
+		slot := slots[len(slots)-1]
+		slots = slots[:len(slots)-1]
+
+		n, flags, err := d.decodeNode()
+		if err != nil {
+			return err
+		}
+		*slot = n
+		if flags&binHasRight != 0 {
+			slots = append(slots, &n.Right)
+		}
+		if flags&binHasLeft != 0 {
+			slots = append(slots, &n.Left)
+		}
+	}
+	b.load(root)
+	return nil
+}
+
+func (d *Decoder) decodeNode() (*Node, byte, error) {
+	flags, err := d.r.ReadByte()
+	if err != nil {
+		return nil, 0, unexpectedEOF(err)
+	}
+	if flags&^(binHasLeft|binHasRight) != 0 {
+		return nil, 0, fmt.Errorf("btree: corrupt binary input, bad node flags %#x", flags)
+	}
+	size, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return nil, 0, unexpectedEOF(err)
+	}
+	if size > maxBinPayload {
+		return nil, 0, fmt.Errorf("btree: corrupt binary input, payload size %v too large", size)
+	}
+	data, err := readPayload(d.r, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	payload, err := d.codec.DecodePayload(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("btree: cannot decode payload: %v", err)
+	}
+	if d.version >= 1 {
+		// Skip the node extension, which this version of the decoder doesn't use.
+		size, err := binary.ReadUvarint(d.r)
+		if err != nil {
+			return nil, 0, unexpectedEOF(err)
+		}
+		if size > maxBinPayload {
+			return nil, 0, fmt.Errorf("btree: corrupt binary input, extension size %v too large", size)
+		}
+		if _, err := d.r.Discard(int(size)); err != nil {
+			return nil, 0, unexpectedEOF(err)
+		}
+	}
+	return &Node{Payload: payload}, flags, nil
+}
+
+// readPayload reads a payload of `size` bytes from `r`. The size comes from the input, so the
+// buffer grows as the bytes arrive rather than being allocated up front.
+func readPayload(r io.Reader, size uint64) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(size)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// unexpectedEOF turns `io.EOF` into `io.ErrUnexpectedEOF`: once a tree has started, its end may
+// not be missing.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// WriteBinary writes the tree to `w` in a compact binary form, using `codec` for the payloads. It
+// is a shorthand for encoding the tree using `NewEncoder(w, codec, opts...)`.
+func (b *BTree) WriteBinary(w io.Writer, codec PayloadCodec, opts ...EncoderOption) error {
+	e := NewEncoder(w, codec, opts...)
+	if err := e.Encode(b); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+// ReadBinary replaces the nodes of the tree with the ones read from `r`, which must hold the
+// output of `WriteBinary()`. It is a shorthand for `NewDecoder(r, codec).Decode(b)`, and may
+// therefore read ahead from `r`. To read several trees from one stream, use a `Decoder`.
+func (b *BTree) ReadBinary(r io.Reader, codec PayloadCodec) error {
+	return NewDecoder(r, codec).Decode(b)
+}
@@ -0,0 +1,241 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+// intCodec is a `PayloadCodec` for `int` payloads.
+type intCodec struct{}
+
+func (intCodec) EncodePayload(p interface{}) ([]byte, error) {
+	return []byte(strconv.Itoa(p.(int))), nil
+}
+
+func (intCodec) DecodePayload(data []byte) (interface{}, error) {
+	return strconv.Atoi(string(data))
+}
+
+func TestBinary(t *testing.T) {
+	for _, vals := range [][]int{nil, {1}, {5, 3, 8, 1, 4, 7, 9, 2, 6}, {1, 2, 3, 4, 5}} {
+		b := newIntTree(vals...)
+		var buf bytes.Buffer
+		if err := b.WriteBinary(&buf, intCodec{}); err != nil {
+			t.Fatalf("WriteBinary(%v) = %v", vals, err)
+		}
+		back := New(intLess)
+		if err := back.ReadBinary(&buf, intCodec{}); err != nil {
+			t.Fatalf("ReadBinary(%v) = %v", vals, err)
+		}
+		if !b.StructurallyEqual(back, intEqual) {
+			t.Errorf("ReadBinary(WriteBinary(%v)) = %v, want the same tree", vals, inOrderInts(back))
+		}
+	}
+}
+
+func TestBinaryIsSmallerThanJSON(t *testing.T) {
+	b := New(intLess)
+	for i := 0; i < 1000; i++ {
+		b.Upsert(&Node{Payload: (i * 7919) % 1000})
+	}
+	var buf bytes.Buffer
+	if err := b.WriteBinary(&buf, intCodec{}); err != nil {
+		t.Fatalf("WriteBinary() = %v", err)
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("json.Marshal() = %v", err)
+	}
+	if buf.Len()*2 > len(data) {
+		t.Errorf("WriteBinary() = %v bytes, json.Marshal() = %v bytes", buf.Len(), len(data))
+	}
+}
+
+func TestBinaryJSONCodec(t *testing.T) {
+	b := newPersonTree("mary", "john", "zoe")
+	var buf bytes.Buffer
+	if err := b.WriteBinary(&buf, JSONCodec{}); err != nil {
+		t.Fatalf("WriteBinary() = %v", err)
+	}
+	back := New(personLess)
+	if err := back.ReadBinary(&buf, JSONCodec{Unmarshal: unmarshalPerson}); err != nil {
+		t.Fatalf("ReadBinary() = %v", err)
+	}
+	if !b.StructurallyEqual(back, personEqual) {
+		t.Errorf("ReadBinary(WriteBinary()) differs from the original")
+	}
+}
+
+func TestBinaryTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newIntTree(2, 1, 3).WriteBinary(&buf, intCodec{}); err != nil {
+		t.Fatalf("WriteBinary() = %v", err)
+	}
+	data := buf.Bytes()
+	const headerLen = 5 // a stream without trees ends in io.EOF, which is tested elsewhere
+	for i := 0; i < len(data); i++ {
+		err := New(intLess).ReadBinary(bytes.NewReader(data[:i]), intCodec{})
+		if i > 0 && i != headerLen && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("ReadBinary() of %v out of %v bytes = %v, want %v", i, len(data), err, io.ErrUnexpectedEOF)
+		}
+		if err == nil {
+			t.Errorf("ReadBinary() of %v out of %v bytes succeeds", i, len(data))
+		}
+	}
+}
+
+func TestBinaryHugePayloadSize(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New(intLess).WriteBinary(&buf, intCodec{}); err != nil {
+		t.Fatalf("WriteBinary() = %v", err)
+	}
+	// The header, then a leaf that claims a payload of 1GB but holds 3 bytes.
+	data := append(buf.Bytes()[:5], 0)
+	data = binary.AppendUvarint(data, maxBinPayload)
+	data = append(data, "123"...)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	err := New(intLess).ReadBinary(bytes.NewReader(data), intCodec{})
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadBinary() = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<20 {
+		t.Errorf("ReadBinary() allocated %v bytes for a 3-byte payload", alloc)
+	}
+}
+
+func TestEncoderDecoderStream(t *testing.T) {
+	trees := []*BTree{newIntTree(2, 1, 3), New(intLess), newIntTree(9, 8, 7, 6)}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, intCodec{})
+	for _, b := range trees {
+		if err := enc.Encode(b); err != nil {
+			t.Fatalf("Encode(%v) = %v", inOrderInts(b), err)
+		}
+	}
+
+	dec := NewDecoder(&buf, intCodec{})
+	for _, want := range trees {
+		got := New(intLess)
+		if err := dec.Decode(got); err != nil {
+			t.Fatalf("Decode() = %v", err)
+		}
+		if !want.StructurallyEqual(got, intEqual) {
+			t.Errorf("Decode() = %v, want %v", inOrderInts(got), inOrderInts(want))
+		}
+	}
+	if err := dec.Decode(New(intLess)); err != io.EOF {
+		t.Errorf("Decode() at end of stream = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestBinaryVersions(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		data    []byte
+		want    []int
+		version int
+	}{
+		{
+			desc:    "version 0, empty",
+			data:    []byte{binEmpty},
+			want:    []int{},
+			version: 0,
+		},
+		{
+			desc:    "version 0",
+			data:    []byte{binHasLeft | binHasRight, 1, '2', 0, 1, '1', 0, 1, '3'},
+			want:    []int{1, 2, 3},
+			version: 0,
+		},
+		{
+			desc:    "version 1",
+			data:    []byte{0xb7, 'B', 'T', 1, 0, binHasRight, 1, '1', 0, 0, 1, '2', 0},
+			want:    []int{1, 2},
+			version: 1,
+		},
+		{
+			desc:    "version 1, unknown node extension",
+			data:    []byte{0xb7, 'B', 'T', 1, 0, binHasRight, 1, '1', 2, 'x', 'y', 0, 1, '2', 0},
+			want:    []int{1, 2},
+			version: 1,
+		},
+	} {
+		dec := NewDecoder(bytes.NewReader(test.data), intCodec{})
+		b := New(intLess)
+		if err := dec.Decode(b); err != nil {
+			t.Fatalf("%s: Decode() = %v", test.desc, err)
+		}
+		if got := inOrderInts(b); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: Decode() = %v, want %v", test.desc, got, test.want)
+		}
+		if got := dec.Version(); got != test.version {
+			t.Errorf("%s: Version() = %v, want %v", test.desc, got, test.version)
+		}
+	}
+}
+
+func TestBinaryFutureVersion(t *testing.T) {
+	data := []byte{0xb7, 'B', 'T', BinaryFormatVersion + 1, 0, binEmpty}
+	if err := New(intLess).ReadBinary(bytes.NewReader(data), intCodec{}); err == nil {
+		t.Errorf("ReadBinary() of a future version succeeds, want error")
+	}
+}
+
+func TestBinaryCompression(t *testing.T) {
+	trees := []*BTree{New(intLess), New(intLess)}
+	for i := 0; i < 1000; i++ {
+		trees[0].Upsert(&Node{Payload: (i * 7919) % 1000})
+		trees[1].Upsert(&Node{Payload: i % 10})
+	}
+
+	var plain, compressed bytes.Buffer
+	enc := NewEncoder(&compressed, intCodec{}, WithCompression(GzipCompression))
+	for _, b := range trees {
+		if err := b.WriteBinary(&plain, intCodec{}); err != nil {
+			t.Fatalf("WriteBinary() = %v", err)
+		}
+		if err := enc.Encode(b); err != nil {
+			t.Fatalf("Encode() = %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if compressed.Len() >= plain.Len()/2 {
+		t.Errorf("compressed = %v bytes, uncompressed = %v bytes", compressed.Len(), plain.Len())
+	}
+
+	dec := NewDecoder(&compressed, intCodec{})
+	for _, want := range trees {
+		got := New(intLess)
+		if err := dec.Decode(got); err != nil {
+			t.Fatalf("Decode() = %v", err)
+		}
+		if !want.StructurallyEqual(got, intEqual) {
+			t.Errorf("Decode() differs from the encoded tree")
+		}
+	}
+	if err := dec.Decode(New(intLess)); err != io.EOF {
+		t.Errorf("Decode() at end of stream = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestBinaryCompressionUnknown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newIntTree(1).WriteBinary(&buf, intCodec{}, WithCompression(Compression(42))); err == nil {
+		t.Errorf("WriteBinary() with unknown compression succeeds, want error")
+	}
+	data := []byte{0xb7, 'B', 'T', 1, 42, binEmpty}
+	if err := New(intLess).ReadBinary(bytes.NewReader(data), intCodec{}); err == nil {
+		t.Errorf("ReadBinary() with unknown compression succeeds, want error")
+	}
+}
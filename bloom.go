@@ -0,0 +1,82 @@
+package btree
+
+import "math"
+
+// HashFunc returns a hash of the payload of `n`, for a `Bloom` filter or the checksum of
+// `EnableChecksum()`. Nodes that are equal according to the tree's `LessFunc` must have the same
+// hash.
+type HashFunc func(n *Node) uint64
+
+// Bloom is a Bloom filter of nodes: a set that may report false positives, but never false
+// negatives. When a tree has a `Bloom`, insertions add to it, and `Find()` and `Delete()`
+// consult it first, so that lookups of absent nodes mostly return without descending the tree.
+// That pays off when most lookups miss.
+//
+// Nodes can't be removed from a filter, so after many deletions its false positive rate rises;
+// `BTree.RebuildBloom()` then starts it afresh.
+type Bloom struct {
+	hash HashFunc
+	bits []uint64
+	k    uint32
+}
+
+// NewBloom returns a `Bloom` filter that is sized for `expected` nodes at the given rate of false
+// positives (e.g. 0.01).
+func NewBloom(expected int, falsePositives float64, hash HashFunc) *Bloom {
+	expected = max(expected, 1)
+	m := math.Ceil(-float64(expected) * math.Log(falsePositives) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(expected) * math.Ln2)
+	return &Bloom{
+		hash: hash,
+		bits: make([]uint64, (int(m)+63)/64),
+		k:    uint32(max(k, 1)),
+	}
+}
+
+// Add adds `n` to the filter.
+func (f *Bloom) Add(n *Node) {
+	h1, h2, size := f.probes(n)
+	for i := uint32(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % size
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// MayContain returns `false` when `n` was definitely not added to the filter.
+func (f *Bloom) MayContain(n *Node) bool {
+	h1, h2, size := f.probes(n)
+	for i := uint32(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % size
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// probes splits the hash of `n` into the two hashes from which the `k` bit positions are derived
+// (Kirsch and Mitzenmacher), and returns them with the number of bits.
+func (f *Bloom) probes(n *Node) (h1, h2, size uint32) {
+	h := f.hash(n)
+	return uint32(h), uint32(h>>32) | 1, uint32(len(f.bits) * 64)
+}
+
+// Reset empties the filter.
+func (f *Bloom) Reset() {
+	clear(f.bits)
+}
+
+// RebuildBloom empties the tree's `Bloom` and adds the nodes of the tree to it again, which
+// restores its rate of false positives after deletions.
+func (b *BTree) RebuildBloom() {
+	if b.Bloom == nil {
+		return
+	}
+	b.Bloom.Reset()
+	b.DepthFirstInOrder(func(n *Node) { b.Bloom.Add(n) })
+}
+
+// absent returns `true` when the tree's `Bloom` reports that `n` is definitely not in the tree.
+func (b *BTree) absent(n *Node) bool {
+	return b.Bloom != nil && !b.Bloom.MayContain(n)
+}
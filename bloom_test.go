@@ -0,0 +1,79 @@
+package btree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func intHash(n *Node) uint64 {
+	// The finalizer of SplitMix64.
+	h := uint64(n.Payload.(int))
+	h = (h ^ h>>30) * 0xbf58476d1ce4e5b9
+	h = (h ^ h>>27) * 0x94d049bb133111eb
+	return h ^ h>>31
+}
+
+func TestBloom(t *testing.T) {
+	f := NewBloom(10000, 0.01, intHash)
+	for i := 0; i < 10000; i++ {
+		f.Add(&Node{Payload: i})
+	}
+	for i := 0; i < 10000; i++ {
+		if !f.MayContain(&Node{Payload: i}) {
+			t.Fatalf("MayContain(%v) = false after Add()", i)
+		}
+	}
+	positives := 0
+	for i := 10000; i < 110000; i++ {
+		if f.MayContain(&Node{Payload: i}) {
+			positives++
+		}
+	}
+	if rate := float64(positives) / 100000; rate > 0.02 {
+		t.Errorf("false positive rate = %v, want about 0.01", rate)
+	}
+	f.Reset()
+	if f.MayContain(&Node{Payload: 1}) {
+		t.Errorf("MayContain() after Reset() = true, want false")
+	}
+}
+
+func TestTreeBloom(t *testing.T) {
+	b := New(intLess)
+	b.Bloom = NewBloom(1000, 0.01, intHash)
+	for _, v := range rand.New(rand.NewSource(1)).Perm(500) {
+		b.Upsert(&Node{Payload: 2 * v})
+	}
+	b.Append(&Node{Payload: 1000})
+	b.BulkUpsert(intNodes(2000, 2001, 2002))
+	for _, v := range []int{0, 998, 1000, 2000, 2002} {
+		if _, found := b.Find(&Node{Payload: v}); !found {
+			t.Errorf("Find(%v) = false, want true", v)
+		}
+	}
+	b.EnableStats()
+	for v := 1; v < 1000; v += 2 {
+		if _, found := b.Find(&Node{Payload: v}); found {
+			t.Errorf("Find(%v) = true, want false", v)
+		}
+	}
+	// Without the filter, 500 missed lookups visit about 500 * log2(500) nodes.
+	if visited := b.Stats().Visited; visited > 500 {
+		t.Errorf("missed lookups visit %v nodes, want most of them rejected", visited)
+	}
+
+	if _, deleted := b.Delete(&Node{Payload: 2}); !deleted {
+		t.Errorf("Delete(2) = false, want true")
+	}
+	if _, found := b.Find(&Node{Payload: 2}); found {
+		t.Errorf("Find(2) after Delete() = true, want false")
+	}
+	b.RebuildBloom()
+	if !b.Bloom.MayContain(&Node{Payload: 4}) {
+		t.Errorf("MayContain(4) after RebuildBloom() = false, want true")
+	}
+	b.Clear()
+	if b.Bloom.MayContain(&Node{Payload: 4}) {
+		t.Errorf("MayContain(4) after Clear() = true, want false")
+	}
+}
@@ -0,0 +1,99 @@
+package btree
+
+import "fmt"
+
+// EvictPolicy picks the node to remove from a `BoundedTree` that has grown beyond its maximum
+// size. It must return a node of `t`. `EvictMin` and `EvictMax` are the common policies; any other
+// function, e.g. one that evicts by a field of the payloads, may be supplied.
+type EvictPolicy func(t *BTree) *Node
+
+// EvictMin evicts the smallest node, which keeps the largest nodes: a top-N.
+func EvictMin(t *BTree) *Node {
+	return t.Min()
+}
+
+// EvictMax evicts the largest node, which keeps the smallest nodes: a bottom-N.
+func EvictMax(t *BTree) *Node {
+	return t.Max()
+}
+
+// BoundedTree wraps a `BTree` so that it holds at most a maximum number of nodes. Insertions
+// beyond that number evict a node, as picked by the `EvictPolicy`.
+type BoundedTree struct {
+	t       *BTree
+	maxSize int
+	policy  EvictPolicy
+	len     int
+}
+
+// WithMaxSize returns a `BoundedTree` that keeps `b` at no more than `maxSize` nodes, evicting per
+// `policy`. When `b` already holds more, the excess is evicted right away. From then on, `b`
+// should only be changed via the `BoundedTree`. It panics when `maxSize` is negative.
+func (b *BTree) WithMaxSize(maxSize int, policy EvictPolicy) *BoundedTree {
+	if maxSize < 0 {
+		panic(fmt.Sprintf("btree: WithMaxSize(%v), the maximum size must not be negative", maxSize))
+	}
+	bt := &BoundedTree{t: b, maxSize: maxSize, policy: policy}
+	b.DepthFirstInOrder(func(*Node) { bt.len++ })
+	for bt.len > bt.maxSize {
+		bt.evict()
+	}
+	return bt
+}
+
+// Tree returns the wrapped tree, e.g. to walk it. It must not be changed.
+func (bt *BoundedTree) Tree() *BTree {
+	return bt.t
+}
+
+// Len returns the number of nodes in the tree.
+func (bt *BoundedTree) Len() int {
+	return bt.len
+}
+
+// MaxSize returns the maximum number of nodes.
+func (bt *BoundedTree) MaxSize() int {
+	return bt.maxSize
+}
+
+// Upsert is `BTree.Upsert()`, followed by an eviction when the tree grew beyond its maximum size.
+// The return value `evicted` is the evicted node, or `nil`. It may be `n` itself, e.g. when a
+// top-N tree gets a node that is smaller than all others.
+func (bt *BoundedTree) Upsert(n *Node) (intree *Node, inserted bool, evicted *Node) {
+	intree, inserted = bt.t.Upsert(n)
+	if !inserted {
+		return intree, false, nil
+	}
+	bt.len++
+	if bt.len > bt.maxSize {
+		evicted = bt.evict()
+	}
+	return intree, true, evicted
+}
+
+// Find is `BTree.Find()`.
+func (bt *BoundedTree) Find(n *Node) (intree *Node, found bool) {
+	return bt.t.Find(n)
+}
+
+// Delete is `BTree.Delete()`.
+func (bt *BoundedTree) Delete(n *Node) (removed *Node, deleted bool) {
+	removed, deleted = bt.t.Delete(n)
+	if deleted {
+		bt.len--
+	}
+	return removed, deleted
+}
+
+// evict removes the node that the policy picks, and returns it.
+func (bt *BoundedTree) evict() *Node {
+	var removed *Node
+	if victim := bt.policy(bt.t); victim != nil {
+		removed, _ = bt.t.Delete(victim)
+	}
+	if removed == nil {
+		panic("btree: EvictPolicy returned a node that is not in the tree")
+	}
+	bt.len--
+	return removed
+}
@@ -0,0 +1,84 @@
+package btree
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBoundedTree(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		policy  EvictPolicy
+		want    []int
+		evicted []int
+	}{
+		{desc: "top-3", policy: EvictMin, want: []int{7, 8, 9}, evicted: []int{1, 4, 2, 5, 3}},
+		{desc: "bottom-3", policy: EvictMax, want: []int{1, 2, 3}, evicted: []int{9, 8, 5, 7, 4, 9}},
+	} {
+		bt := New(intLess).WithMaxSize(3, test.policy)
+		var evicted []int
+		for _, v := range []int{5, 9, 1, 8, 4, 2, 7, 3, 9} {
+			if _, _, e := bt.Upsert(&Node{Payload: v}); e != nil {
+				evicted = append(evicted, e.Payload.(int))
+			}
+		}
+		if got := inOrderInts(bt.Tree()); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: tree = %v, want %v", test.desc, got, test.want)
+		}
+		if !reflect.DeepEqual(evicted, test.evicted) {
+			t.Errorf("%s: evicted = %v, want %v", test.desc, evicted, test.evicted)
+		}
+		if bt.Len() != 3 {
+			t.Errorf("%s: Len() = %v, want 3", test.desc, bt.Len())
+		}
+	}
+}
+
+func TestBoundedTreeCustomPolicy(t *testing.T) {
+	// Evict the node with the shortest value.
+	shortest := func(t *BTree) *Node {
+		var victim *Node
+		t.DepthFirstInOrder(func(n *Node) {
+			if victim == nil || len(n.Payload.(kv).val) < len(victim.Payload.(kv).val) {
+				victim = n
+			}
+		})
+		return victim
+	}
+	bt := New(kvLess).WithMaxSize(2, shortest)
+	bt.Upsert(&Node{Payload: kv{"a", "xxx"}})
+	bt.Upsert(&Node{Payload: kv{"b", "x"}})
+	bt.Upsert(&Node{Payload: kv{"c", "xx"}})
+	if got, want := kvPairs(bt.Tree()), []string{"a", "xxx", "c", "xx"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tree = %v, want %v", got, want)
+	}
+}
+
+func TestWithMaxSizeEvictsExcess(t *testing.T) {
+	bt := newIntTree(5, 3, 8, 1, 4).WithMaxSize(2, EvictMin)
+	if got, want := inOrderInts(bt.Tree()), []int{5, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tree = %v, want %v", got, want)
+	}
+	if _, deleted := bt.Delete(&Node{Payload: 5}); !deleted || bt.Len() != 1 {
+		t.Errorf("Delete(5) = %v, Len() = %v, want true and 1", deleted, bt.Len())
+	}
+}
+
+func TestBoundedTreeBadPolicy(t *testing.T) {
+	bt := New(intLess).WithMaxSize(1, func(*BTree) *Node { return nil })
+	bt.Upsert(&Node{Payload: 1})
+	if got := panicOf(func() { bt.Upsert(&Node{Payload: 2}) }); !strings.Contains(got, "EvictPolicy") {
+		t.Errorf("panic = %q, want one about the EvictPolicy", got)
+	}
+}
+
+func TestWithMaxSizeNegative(t *testing.T) {
+	b := newIntTree(2, 1, 3)
+	if got := panicOf(func() { b.WithMaxSize(-1, EvictMin) }); !strings.Contains(got, "negative") {
+		t.Errorf("WithMaxSize(-1) panic = %q, want one about a negative size", got)
+	}
+	if got := inOrderInts(b); len(got) != 3 {
+		t.Errorf("tree after WithMaxSize(-1) = %v, want it unchanged", got)
+	}
+}
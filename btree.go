@@ -1,6 +1,12 @@
 // Package btree implements a binary tree.
 package btree
 
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
 // LessFunc must be supplied by the caller of `Upsert()`. It is responsible for comparing two nodes
 // `a` and `b` and must return `true` when `a` is "smaller".
 type LessFunc func(a, b *Node) bool
@@ -24,6 +30,98 @@ type BTree struct {
 	Root *Node
 	// Less is the `LessFunc` that is caller-supplied. It is repeatedly called when inserting.
 	Less LessFunc
+	// UnmarshalPayload is an optional, caller-supplied hook that decodes the JSON form of a payload
+	// into its proper type. When it is `nil`, `UnmarshalJSON()` stores payloads the way
+	// `encoding/json` decodes into an `interface{}`.
+	UnmarshalPayload func(data []byte) (interface{}, error)
+	// Pool is an optional `NodePool`. When set, `Delete()` and `Clear()` return the nodes that
+	// leave the tree to it, so that `NewNode()` can recycle them.
+	Pool *NodePool
+	// Arena is an optional `Arena`. When set, `NewNode()` allocates from it, and `Clear()`
+	// releases all of its nodes at once.
+	Arena *Arena
+	// UseFinger enables a cache of the position of the last upserted node, see `finger`. It
+	// speeds up localized access, such as repeatedly upserting the same node. The tree must then
+	// only be changed via its methods; after changing `Root`, `Left` or `Right` directly, call
+	// `ResetFinger()`.
+	UseFinger bool
+	// CheckConcurrentUse enables a debug mode, which panics when the tree is written while it is
+	// being read or written elsewhere. It must be set before the tree is used.
+	CheckConcurrentUse bool
+	// LazyDelete makes `Delete()` mark nodes deleted rather than unlink them, so that bursts of
+	// deletions don't restructure the tree. Marked nodes are skipped by lookups and traversals,
+	// and are physically removed by `Compact()`. Structural operations, such as `Clone()` or
+	// serialization, still see them; call `Compact()` first.
+	LazyDelete bool
+	// Bloom is an optional `Bloom` filter of the nodes, with which `Find()` and `Delete()` reject
+	// most absent nodes without descending the tree. Insertions add to it; it must be set while
+	// the tree is empty, or be followed by `RebuildBloom()`.
+	Bloom *Bloom
+	// ProfileContext enables profiling: operations such as `Upsert()`, `Find()`, `Delete()` and
+	// the traversals then carry the pprof label `btree.op` and run in a runtime/trace region, so
+	// that they show up attributably in CPU profiles and execution traces. The labels extend
+	// those of `ProfileContext`, and when an operation ends, the goroutine's labels are set back
+	// to those of `ProfileContext`; use `context.Background()` when the goroutines carry no labels
+	// of their own.
+	ProfileContext context.Context
+	// Now is an optional clock for the expiry of nodes, see `SetExpiry()`. When it is `nil`,
+	// `time.Now()` is used.
+	Now func() time.Time
+	// ShadowKey enables a debug mode for hunting bugs in the `LessFunc`: the tree then also keeps
+	// its nodes in a map by this key, checks the outcome of every `Find()`, `Upsert()` and
+	// `Delete()` against it, and panics with the details when they differ. The map is built from
+	// the tree by the first write, and rebuilt after writes other than `Upsert()` and `Delete()`;
+	// the tree must then only be changed via its methods.
+	ShadowKey ShadowKeyFunc
+	// CheckLess enables a debug mode that checks that the `LessFunc` is a strict weak ordering,
+	// which the tree relies on: an inconsistent comparator makes it lose nodes. `Upsert()`
+	// then compares each new payload with itself, and with a sample of the earlier ones, and
+	// panics when a node is less than itself, when two nodes are both less than the other, or
+	// when being less or being equal isn't transitive for the new payload and two sampled ones.
+	// This costs a few dozen extra comparisons per upsert.
+	CheckLess bool
+	// OnInsert, OnDelete and OnRotate are optional hooks, with which e.g. external indexes or
+	// metrics stay in sync with the tree. OnInsert is called for each node that enters the tree,
+	// including nodes that are revived after being marked deleted or having expired. OnDelete is
+	// called for each node that leaves it: by `Delete()`, which includes marking a node deleted,
+	// `ExpireBefore()`, `Clear()`, or when an expired node is replaced. OnRotate is called after
+	// each rotation of `Rebalance()` and `Maintain()`, in which `up` took the place of its parent
+	// `down`. Relinking the whole tree, as `Compact()` and `BulkUpsert()` may do, and loading or
+	// changing the tree directly do not call the hooks. The hooks must not change the tree.
+	OnInsert func(n *Node)
+	OnDelete func(n *Node)
+	OnRotate func(up, down *Node)
+	// MaxDepth optionally caps the depth of the tree, counting the root as 1, against inputs that
+	// would make it degenerate, such as sorted ones: their O(n) descents, and the deep recursion of
+	// e.g. `Delete()`. When `Upsert()` links a node in deeper than this, the tree is rebalanced;
+	// `TryUpsert()` refuses such a node instead. The cap must be well above log2 of the number of
+	// nodes, or `Upsert()` rebalances often. Other methods don't check it.
+	MaxDepth int
+
+	// uses is the state of the concurrent use check.
+	uses int32
+	// finger is the position of the last upserted node, when `UseFinger` is set.
+	finger finger
+	// tail is the largest node of the tree, for `Append()`.
+	tail finger
+	// tombstones are the nodes that are marked deleted, when `LazyDelete` is set.
+	tombstones map[*Node]struct{}
+	// expiry holds the expiry times of the nodes that have one, see `SetExpiry()`.
+	expiry map[*Node]time.Time
+	// shadow is the map of the nodes by key, when `ShadowKey` is set.
+	shadow map[interface{}]*Node
+	// logger receives the debug events, see `WithLogger()`, and path is the number of nodes that
+	// the last insertion or deletion visited, for its event and for `MaxDepth`.
+	logger *slog.Logger
+	path   int
+	// stats are the counters that `EnableStats()` started, or `nil`.
+	stats *counters
+	// metrics are the operation metrics that `EnableMetrics()` started, or `nil`.
+	metrics *Metrics
+	// checksum is the checksum of the nodes that `EnableChecksum()` started, or `nil`.
+	checksum *checksum
+	// lessSample is the state of `CheckLess`.
+	lessSample *lessSample
 }
 
 // New instantiates a new `BTree`.
@@ -35,37 +133,89 @@ func New(less LessFunc) *BTree {
 
 // Upsert examines the tree and if needed, inserts a new node. The return value `intree` points
 // to where the node was inserted (or where a previously inserted node was already found). The
-// return value `inserted` is `true` when the node was added to the tree.
+// return value `inserted` is `true` when the node was added to the tree. New nodes that are
+// smaller than a node go to its `Left`, larger ones to its `Right`.
 func (b *BTree) Upsert(n *Node) (intree *Node, inserted bool) {
-	if b.Root == nil {
-		b.Root = n
-		return b.Root, true
+	defer b.beginWrite("Upsert")()
+	return b.upsertCapped(n)
+}
+
+// upsertCapped is `upsertChecked()`, which then rebalances the tree when the node was linked in
+// deeper than `MaxDepth`.
+func (b *BTree) upsertCapped(n *Node) (intree *Node, inserted bool) {
+	intree, inserted = b.upsertChecked(n)
+	if inserted && b.MaxDepth > 0 && b.insertedDepth(intree) > b.MaxDepth {
+		b.rebalance()
 	}
-	return b.upsertFrom(b.Root, n)
+	return intree, inserted
 }
 
-func (b *BTree) upsertFrom(from, n *Node) (intree *Node, inserted bool) {
-	switch {
-	case b.Less(from, n):
-		if from.Left == nil {
-			from.Left = n
-			return from.Left, true
-		}
-		return b.upsertFrom(from.Left, n)
-	case b.Less(n, from):
-		if from.Right == nil {
-			from.Right = n
-			return from.Right, true
+// upsertChecked is `upsert()` with the checks of `CheckLess` and `ShadowKey`, and the event for
+// `WithLogger()`.
+func (b *BTree) upsertChecked(n *Node) (intree *Node, inserted bool) {
+	if b.CheckLess {
+		b.checkLess(n)
+	}
+	if b.logger != nil {
+		defer func() { b.logUpsert(n, inserted) }()
+	}
+	if b.ShadowKey != nil {
+		return b.shadowUpsert(n)
+	}
+	return b.upsert(n)
+}
+
+// upsert is `Upsert()` without the concurrent use check. It descends in a loop rather than
+// recursively, so that deep (skewed) trees don't cost stack.
+func (b *BTree) upsert(n *Node) (intree *Node, inserted bool) {
+	if b.Root == nil {
+		b.Root = n
+		b.descended(0)
+		b.setFinger(n, nil, nil)
+		b.setTail(n)
+		b.added(n)
+		return n, true
+	}
+	from, lo, hi := b.fingerFor(n)
+	for depth := 1; ; depth++ {
+		switch {
+		case b.Less(n, from):
+			if from.Left == nil {
+				from.Left = n
+				b.setFinger(n, lo, from)
+				b.descended(depth)
+				b.added(n)
+				return n, true
+			}
+			from, hi = from.Left, from
+		case b.Less(from, n):
+			if from.Right == nil {
+				from.Right = n
+				b.setFinger(n, from, hi)
+				if hi == nil {
+					b.setTail(n)
+				}
+				b.descended(depth)
+				b.added(n)
+				return n, true
+			}
+			from, lo = from.Right, from
+		default:
+			b.setFinger(from, lo, hi)
+			b.descended(depth)
+			if b.revive(from, n.Payload) {
+				// A node that is marked deleted or has expired is reused for the new payload.
+				return from, true
+			}
+			return from, false
 		}
-		return b.upsertFrom(from.Right, n)
-	default:
-		return from, false
 	}
 }
 
 // DepthFirstInOrder "walks" along the tree and calls the `WalkFunc` for each node. Nodes are
 // visited depth first, in order.
 func (b *BTree) DepthFirstInOrder(walk WalkFunc) {
+	defer b.beginRead("DepthFirstInOrder")()
 	if b.Root == nil {
 		return
 	}
@@ -76,7 +226,10 @@ func (b *BTree) depthFirstInOrderFrom(n *Node, walk WalkFunc) {
 	if n.Left != nil {
 		b.depthFirstInOrderFrom(n.Left, walk)
 	}
-	walk(n)
+	b.stats.visit()
+	if !b.hidden(n) {
+		walk(n)
+	}
 	if n.Right != nil {
 		b.depthFirstInOrderFrom(n.Right, walk)
 	}
@@ -85,6 +238,7 @@ func (b *BTree) depthFirstInOrderFrom(n *Node, walk WalkFunc) {
 // DepthFirstReverse "walks" along the tree and calls the `WalkFunc` for each node. Nodes are
 // visited depth first, reverse order.
 func (b *BTree) DepthFirstReverse(walk WalkFunc) {
+	defer b.beginRead("DepthFirstReverse")()
 	if b.Root == nil {
 		return
 	}
@@ -93,10 +247,227 @@ func (b *BTree) DepthFirstReverse(walk WalkFunc) {
 
 func (b *BTree) depthFirstReverseFrom(n *Node, walk WalkFunc) {
 	if n.Right != nil {
-		b.depthFirstInOrderFrom(n.Right, walk)
+		b.depthFirstReverseFrom(n.Right, walk)
+	}
+	b.stats.visit()
+	if !b.hidden(n) {
+		walk(n)
 	}
-	walk(n)
 	if n.Left != nil {
-		b.depthFirstInOrderFrom(n.Left, walk)
+		b.depthFirstReverseFrom(n.Left, walk)
+	}
+}
+
+// Find looks up a node in the tree. The argument `n` only needs to be filled in as far as the
+// `LessFunc` requires. The return value `intree` points to the matching node in the tree, and
+// `found` is `true` when there is such a node.
+func (b *BTree) Find(n *Node) (intree *Node, found bool) {
+	defer b.beginRead("Find")()
+	if b.ShadowKey != nil {
+		return b.shadowFind(n)
+	}
+	return b.find(n)
+}
+
+// find is `Find()` without the concurrent use check.
+func (b *BTree) find(n *Node) (intree *Node, found bool) {
+	if b.absent(n) {
+		return nil, false
+	}
+	if intree = b.lookup(n); intree == nil || b.hidden(intree) {
+		return nil, false
+	}
+	return intree, true
+}
+
+// lookup returns the node that matches `n`, or `nil`. Nodes that are marked deleted are returned
+// as well.
+func (b *BTree) lookup(n *Node) *Node {
+	intree, _ := b.lookupPath(n)
+	return intree
+}
+
+// lookupPath is `lookup()`, which also returns the number of nodes that it visited.
+func (b *BTree) lookupPath(n *Node) (intree *Node, path int) {
+	from, _, _ := b.fingerFor(n)
+	depth := 0
+	for ; from != nil; depth++ {
+		switch {
+		case b.Less(n, from):
+			from = from.Left
+		case b.Less(from, n):
+			from = from.Right
+		default:
+			b.stats.descent(depth + 1)
+			return from, depth + 1
+		}
+	}
+	b.stats.descent(depth)
+	return nil, depth
+}
+
+// Delete removes a node from the tree. The argument `n` only needs to be filled in as far as the
+// `LessFunc` requires. The return value `removed` points to the node that was taken out of the
+// tree, and `deleted` is `true` when there was such a node.
+//
+// When the tree has a `Pool`, the removed node is returned to it. Its payload may then be
+// examined, but only until the next node is taken from the pool.
+//
+// When `LazyDelete` is set, the node is only marked deleted; it stays linked into the tree until
+// `Compact()`. A node that has expired is removed as well, but reported absent.
+func (b *BTree) Delete(n *Node) (removed *Node, deleted bool) {
+	defer b.beginWrite("Delete")()
+	if b.logger != nil {
+		defer func() { b.logDelete(n, deleted) }()
+	}
+	if b.ShadowKey != nil {
+		return b.shadowDelete(n)
+	}
+	return b.delete(n)
+}
+
+// delete is `Delete()` without the concurrent use check.
+func (b *BTree) delete(n *Node) (removed *Node, deleted bool) {
+	b.path = 0
+	if b.absent(n) {
+		return nil, false
+	}
+	if b.LazyDelete {
+		var intree *Node
+		intree, b.path = b.lookupPath(n)
+		if intree == nil {
+			return nil, false
+		}
+		wasExpired := b.expired(intree)
+		delete(b.expiry, intree)
+		if !b.bury(intree) {
+			return nil, false
+		}
+		b.removed(intree)
+		if wasExpired {
+			return nil, false
+		}
+		return intree, true
+	}
+	b.ResetFinger()
+	b.Root, removed = b.deleteFrom(b.Root, n, 0)
+	if removed == nil {
+		return nil, false
+	}
+	wasDead := b.dead(removed) || b.expired(removed)
+	if !b.dead(removed) {
+		b.removed(removed)
+	}
+	delete(b.tombstones, removed)
+	delete(b.expiry, removed)
+	if b.Pool != nil {
+		b.Pool.put(removed)
+	}
+	if wasDead {
+		return nil, false
+	}
+	return removed, true
+}
+
+// load replaces the nodes of the tree with the ones under `root`, for the loaders such as
+// `UnmarshalJSON()`. The state that derives from the old nodes is reset: the finger, the marks of
+// `LazyDelete`, the expiry times, the shadow map and the sample of `CheckLess`. The `Bloom` filter
+// and the checksum are recomputed from the new nodes.
+func (b *BTree) load(root *Node) {
+	b.ResetFinger()
+	b.tombstones, b.expiry, b.shadow, b.lessSample = nil, nil, nil, nil
+	b.Root = root
+	b.RebuildBloom()
+	if b.checksum != nil {
+		b.checksum.sum = b.sumHashes(b.checksum.hash)
+	}
+}
+
+// Clear removes all nodes from the tree. When the tree has an `Arena`, it is reset; otherwise,
+// when the tree has a `Pool`, the nodes are returned to it.
+func (b *BTree) Clear() {
+	defer b.beginWrite("Clear")()
+	if b.OnDelete != nil {
+		var it inorderIter
+		for it.initLinked(b); ; {
+			n := it.next()
+			if n == nil {
+				break
+			}
+			b.OnDelete(n)
+		}
+	}
+	b.ResetFinger()
+	b.tombstones = nil
+	b.expiry = nil
+	if b.Bloom != nil {
+		b.Bloom.Reset()
+	}
+	if b.checksum != nil {
+		b.checksum.sum = 0
+	}
+	switch {
+	case b.Arena != nil:
+		b.Arena.Reset()
+	case b.Pool != nil:
+		stack := []*Node{}
+		if b.Root != nil {
+			stack = append(stack, b.Root)
+		}
+		for len(stack) > 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if n.Left != nil {
+				stack = append(stack, n.Left)
+			}
+			if n.Right != nil {
+				stack = append(stack, n.Right)
+			}
+			n.Payload = nil
+			b.Pool.put(n)
+		}
+	}
+	b.Root = nil
+}
+
+// deleteFrom removes `n` from the subtree under `from`, which is at the given depth, and
+// returns the new top of the subtree plus the removed node (or `nil`).
+func (b *BTree) deleteFrom(from, n *Node, depth int) (top, removed *Node) {
+	if from == nil {
+		b.descended(depth)
+		return nil, nil
+	}
+	switch {
+	case b.Less(n, from):
+		from.Left, removed = b.deleteFrom(from.Left, n, depth+1)
+		return from, removed
+	case b.Less(from, n):
+		from.Right, removed = b.deleteFrom(from.Right, n, depth+1)
+		return from, removed
+	}
+	b.descended(depth + 1)
+	top = unlink(from)
+	from.Left, from.Right = nil, nil
+	return top, from
+}
+
+// unlink returns what should take the place of `n` when `n` is removed: one of its children, or
+// when there are two, its in-order successor.
+func unlink(n *Node) *Node {
+	switch {
+	case n.Left == nil:
+		return n.Right
+	case n.Right == nil:
+		return n.Left
+	}
+	parent, succ := n, n.Right
+	for succ.Left != nil {
+		parent, succ = succ, succ.Left
+	}
+	if parent != n {
+		parent.Left = succ.Right
+		succ.Right = n.Right
 	}
+	succ.Left = n.Left
+	return succ
 }
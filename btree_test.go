@@ -1,7 +1,119 @@
 package btree
 
-import "testing"
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Test helpers: trees that hold plain `int` payloads.
+
+func intLess(a, b *Node) bool {
+	return a.Payload.(int) < b.Payload.(int)
+}
+
+func intEqual(a, b *Node) bool {
+	return a.Payload.(int) == b.Payload.(int)
+}
+
+func newIntTree(vals ...int) *BTree {
+	b := New(intLess)
+	for _, v := range vals {
+		b.Upsert(&Node{Payload: v})
+	}
+	return b
+}
+
+func inOrderInts(b *BTree) []int {
+	out := []int{}
+	b.DepthFirstInOrder(func(n *Node) {
+		out = append(out, n.Payload.(int))
+	})
+	return out
+}
 
 func TestAll(t *testing.T) {
 	// TODO: Add tests
 }
+
+func TestUpsert(t *testing.T) {
+	b := newIntTree(3, 1, 2)
+	if _, inserted := b.Upsert(&Node{Payload: 2}); inserted {
+		t.Errorf("Upsert(2) = inserted, want already present")
+	}
+	if got, want := inOrderInts(b), inOrderInts(newIntTree(2, 3, 1)); !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order contents = %v, want %v", got, want)
+	}
+}
+
+func TestDepthFirstOrder(t *testing.T) {
+	b := newIntTree(4, 2, 6, 1, 3, 5, 7)
+	if got, want := inOrderInts(b), []int{1, 2, 3, 4, 5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DepthFirstInOrder() = %v, want %v", got, want)
+	}
+	got := []int{}
+	b.DepthFirstReverse(func(n *Node) { got = append(got, n.Payload.(int)) })
+	if want := []int{7, 6, 5, 4, 3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DepthFirstReverse() = %v, want %v", got, want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	b := newIntTree(4, 2, 6)
+	if n, found := b.Find(&Node{Payload: 6}); !found || n.Payload.(int) != 6 {
+		t.Errorf("Find(6) = %v, %v, want node 6, true", n, found)
+	}
+	if n, found := b.Find(&Node{Payload: 5}); found || n != nil {
+		t.Errorf("Find(5) = %v, %v, want nil, false", n, found)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	vals := []int{50, 30, 70, 20, 40, 60, 80, 35, 45, 65}
+	for _, del := range append(vals, 99) {
+		b := newIntTree(vals...)
+		removed, deleted := b.Delete(&Node{Payload: del})
+		want := []int{}
+		for _, v := range inOrderInts(newIntTree(vals...)) {
+			if v != del {
+				want = append(want, v)
+			}
+		}
+		if got := inOrderInts(b); !reflect.DeepEqual(got, want) {
+			t.Errorf("Delete(%v): tree = %v, want %v", del, got, want)
+		}
+		if wantDeleted := del != 99; deleted != wantDeleted {
+			t.Errorf("Delete(%v) = %v, want %v", del, deleted, wantDeleted)
+		}
+		if deleted && removed.Payload.(int) != del {
+			t.Errorf("Delete(%v) removed %v", del, removed.Payload)
+		}
+		for _, v := range want {
+			if _, found := b.Find(&Node{Payload: v}); !found {
+				t.Errorf("Delete(%v): Find(%v) fails afterwards", del, v)
+			}
+		}
+	}
+}
+
+func TestUpsertDeepTree(t *testing.T) {
+	// Sorted insertions make a chain; its depth must not be a problem.
+	b := New(intLess)
+	for v := 0; v < 20000; v++ {
+		b.Upsert(&Node{Payload: v})
+	}
+	if _, inserted := b.Upsert(&Node{Payload: 19999}); inserted {
+		t.Errorf("Upsert(19999) again = inserted")
+	}
+}
+
+func BenchmarkUpsertRandom(b *testing.B) {
+	vals := rand.New(rand.NewSource(1)).Perm(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t := New(intLess)
+		for _, v := range vals {
+			t.Upsert(&Node{Payload: v})
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package btreepb holds the Protocol Buffers representation of a `btree.BTree`, so that trees can
+// be exchanged natively by e.g. gRPC services. The messages are defined in `tree.proto`; `ToProto()`
+// and `FromProto()` convert between trees and messages.
+package btreepb
+
+import (
+	"fmt"
+
+	"github.com/KarelKubat/btree"
+)
+
+// ToProto converts the tree `b` into a `Tree` message, using `codec` to turn the payloads into
+// bytes. The nodes are listed in preorder.
+func ToProto(b *btree.BTree, codec btree.PayloadCodec) (*Tree, error) {
+	t := &Tree{}
+	if b.Root == nil {
+		return t, nil
+	}
+	// Each stack entry is a node plus the index of the slot in `t.Nodes` that must point to it.
+	type pending struct {
+		n    *btree.Node
+		slot *uint32
+	}
+	stack := []pending{{n: b.Root}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		payload, err := codec.EncodePayload(p.n.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("btreepb: cannot encode payload %v: %v", p.n.Payload, err)
+		}
+		pn := &Node{Payload: payload}
+		t.Nodes = append(t.Nodes, pn)
+		if p.slot != nil {
+			*p.slot = uint32(len(t.Nodes))
+		}
+		if p.n.Right != nil {
+			stack = append(stack, pending{n: p.n.Right, slot: &pn.Right})
+		}
+		if p.n.Left != nil {
+			stack = append(stack, pending{n: p.n.Left, slot: &pn.Left})
+		}
+	}
+	return t, nil
+}
+
+// FromProto replaces the nodes of the tree `b` with the ones in the message `t`, using `codec` to
+// decode the payloads. The `LessFunc` of `b` is kept. The message is validated: each node except
+// the first must be referenced exactly once.
+func FromProto(t *Tree, b *btree.BTree, codec btree.PayloadCodec) error {
+	nodes := make([]*btree.Node, len(t.GetNodes()))
+	for i, pn := range t.GetNodes() {
+		payload, err := codec.DecodePayload(pn.GetPayload())
+		if err != nil {
+			return fmt.Errorf("btreepb: cannot decode payload of node %v: %v", i+1, err)
+		}
+		nodes[i] = &btree.Node{Payload: payload}
+	}
+
+	referenced := make([]bool, len(nodes))
+	ref := func(from int, idx uint32) (*btree.Node, error) {
+		switch {
+		case idx == 0:
+			return nil, nil
+		case int(idx) > len(nodes) || idx == 1:
+			return nil, fmt.Errorf("btreepb: node %v refers to invalid node %v", from+1, idx)
+		case referenced[idx-1]:
+			return nil, fmt.Errorf("btreepb: node %v is referenced more than once", idx)
+		}
+		referenced[idx-1] = true
+		return nodes[idx-1], nil
+	}
+	var err error
+	for i, pn := range t.GetNodes() {
+		if nodes[i].Left, err = ref(i, pn.GetLeft()); err != nil {
+			return err
+		}
+		if nodes[i].Right, err = ref(i, pn.GetRight()); err != nil {
+			return err
+		}
+	}
+	for i := 1; i < len(nodes); i++ {
+		if !referenced[i] {
+			return fmt.Errorf("btreepb: node %v is not referenced", i+1)
+		}
+	}
+
+	b.Root = nil
+	if len(nodes) == 0 {
+		return nil
+	}
+	// With each node referenced once, nodes that can't be reached from the root form a cycle.
+	if reached := countFrom(nodes[0]); reached != len(nodes) {
+		return fmt.Errorf("btreepb: only %v out of %v nodes are reachable from the root", reached, len(nodes))
+	}
+	b.Root = nodes[0]
+	return nil
+}
+
+func countFrom(n *btree.Node) int {
+	count := 0
+	for stack := []*btree.Node{n}; len(stack) > 0; {
+		n, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		count++
+		for _, sub := range []*btree.Node{n.Left, n.Right} {
+			if sub != nil {
+				stack = append(stack, sub)
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,92 @@
+package btreepb
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/KarelKubat/btree"
+	"google.golang.org/protobuf/proto"
+)
+
+type intCodec struct{}
+
+func (intCodec) EncodePayload(p interface{}) ([]byte, error) {
+	return []byte(strconv.Itoa(p.(int))), nil
+}
+
+func (intCodec) DecodePayload(data []byte) (interface{}, error) {
+	return strconv.Atoi(string(data))
+}
+
+func intLess(a, b *btree.Node) bool {
+	return a.Payload.(int) < b.Payload.(int)
+}
+
+func intEqual(a, b *btree.Node) bool {
+	return a.Payload.(int) == b.Payload.(int)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, vals := range [][]int{nil, {1}, {5, 3, 8, 1, 4, 7, 9}, {1, 2, 3, 4}} {
+		b := btree.New(intLess)
+		for _, v := range vals {
+			b.Upsert(&btree.Node{Payload: v})
+		}
+		msg, err := ToProto(b, intCodec{})
+		if err != nil {
+			t.Fatalf("ToProto(%v) = %v", vals, err)
+		}
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			t.Fatalf("proto.Marshal() = %v", err)
+		}
+		back := &Tree{}
+		if err := proto.Unmarshal(data, back); err != nil {
+			t.Fatalf("proto.Unmarshal() = %v", err)
+		}
+
+		restored := btree.New(intLess)
+		if err := FromProto(back, restored, intCodec{}); err != nil {
+			t.Fatalf("FromProto(%v) = %v", vals, err)
+		}
+		if !b.StructurallyEqual(restored, intEqual) {
+			t.Errorf("FromProto(ToProto(%v)) differs from the original", vals)
+		}
+	}
+}
+
+func TestFromProtoErrors(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		tree *Tree
+	}{
+		{
+			desc: "bad payload",
+			tree: &Tree{Nodes: []*Node{{Payload: []byte("x")}}},
+		},
+		{
+			desc: "out of range",
+			tree: &Tree{Nodes: []*Node{{Payload: []byte("1"), Left: 2}}},
+		},
+		{
+			desc: "refers to root",
+			tree: &Tree{Nodes: []*Node{{Payload: []byte("1"), Left: 1}}},
+		},
+		{
+			desc: "referenced twice",
+			tree: &Tree{Nodes: []*Node{{Payload: []byte("2"), Left: 2, Right: 2}, {Payload: []byte("1")}}},
+		},
+		{
+			desc: "cycle",
+			tree: &Tree{Nodes: []*Node{{Payload: []byte("1")}, {Payload: []byte("2"), Left: 3}, {Payload: []byte("3"), Left: 2}}},
+		},
+		{
+			desc: "unreferenced",
+			tree: &Tree{Nodes: []*Node{{Payload: []byte("2")}, {Payload: []byte("1")}}},
+		},
+	} {
+		if err := FromProto(test.tree, btree.New(intLess), intCodec{}); err == nil {
+			t.Errorf("%s: FromProto() succeeds, want error", test.desc)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: tree.proto
+
+package btreepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Node is one node of the tree. The payload is opaque: it is produced and consumed by a payload
+// codec on either side.
+type Node struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Payload []byte                 `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	// left and right point to the sub-nodes, as 1-based indices into Tree.nodes. Zero means that
+	// there is no sub-node.
+	Left          uint32 `protobuf:"varint,2,opt,name=left,proto3" json:"left,omitempty"`
+	Right         uint32 `protobuf:"varint,3,opt,name=right,proto3" json:"right,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Node) Reset() {
+	*x = Node{}
+	mi := &file_tree_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Node) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Node) ProtoMessage() {}
+
+func (x *Node) ProtoReflect() protoreflect.Message {
+	mi := &file_tree_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Node.ProtoReflect.Descriptor instead.
+func (*Node) Descriptor() ([]byte, []int) {
+	return file_tree_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Node) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Node) GetLeft() uint32 {
+	if x != nil {
+		return x.Left
+	}
+	return 0
+}
+
+func (x *Node) GetRight() uint32 {
+	if x != nil {
+		return x.Right
+	}
+	return 0
+}
+
+// Tree holds all nodes of a tree in a flat list, so that even deeply skewed trees don't run into
+// nesting limits. The first node is the root; an empty tree has no nodes.
+type Tree struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nodes         []*Node                `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tree) Reset() {
+	*x = Tree{}
+	mi := &file_tree_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tree) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tree) ProtoMessage() {}
+
+func (x *Tree) ProtoReflect() protoreflect.Message {
+	mi := &file_tree_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tree.ProtoReflect.Descriptor instead.
+func (*Tree) Descriptor() ([]byte, []int) {
+	return file_tree_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Tree) GetNodes() []*Node {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+var File_tree_proto protoreflect.FileDescriptor
+
+const file_tree_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"tree.proto\x12\x05btree\"J\n" +
+	"\x04Node\x12\x18\n" +
+	"\apayload\x18\x01 \x01(\fR\apayload\x12\x12\n" +
+	"\x04left\x18\x02 \x01(\rR\x04left\x12\x14\n" +
+	"\x05right\x18\x03 \x01(\rR\x05right\")\n" +
+	"\x04Tree\x12!\n" +
+	"\x05nodes\x18\x01 \x03(\v2\v.btree.NodeR\x05nodesB%Z#github.com/KarelKubat/btree/btreepbb\x06proto3"
+
+var (
+	file_tree_proto_rawDescOnce sync.Once
+	file_tree_proto_rawDescData []byte
+)
+
+func file_tree_proto_rawDescGZIP() []byte {
+	file_tree_proto_rawDescOnce.Do(func() {
+		file_tree_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_tree_proto_rawDesc), len(file_tree_proto_rawDesc)))
+	})
+	return file_tree_proto_rawDescData
+}
+
+var file_tree_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_tree_proto_goTypes = []any{
+	(*Node)(nil), // 0: btree.Node
+	(*Tree)(nil), // 1: btree.Tree
+}
+var file_tree_proto_depIdxs = []int32{
+	0, // 0: btree.Tree.nodes:type_name -> btree.Node
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_tree_proto_init() }
+func file_tree_proto_init() {
+	if File_tree_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tree_proto_rawDesc), len(file_tree_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_tree_proto_goTypes,
+		DependencyIndexes: file_tree_proto_depIdxs,
+		MessageInfos:      file_tree_proto_msgTypes,
+	}.Build()
+	File_tree_proto = out.File
+	file_tree_proto_goTypes = nil
+	file_tree_proto_depIdxs = nil
+}
@@ -0,0 +1,14 @@
+package btree
+
+// buildBalanced links `nodes`, which must be in in-order sequence, into a balanced (sub)tree and
+// returns its top. The `Left` and `Right` pointers of all nodes are overwritten.
+func buildBalanced(nodes []*Node) *Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := len(nodes) / 2
+	top := nodes[mid]
+	top.Left = buildBalanced(nodes[:mid])
+	top.Right = buildBalanced(nodes[mid+1:])
+	return top
+}
@@ -0,0 +1,169 @@
+package btree
+
+import "sort"
+
+// BulkUpsert adds a batch of nodes to the tree. It is optimized for batches that are sorted
+// according to the tree's `LessFunc`:
+//
+//   - When the tree is empty, the batch is linked into a balanced tree.
+//   - When the batch lies entirely beyond the largest (or before the smallest) node of the tree,
+//     the batch is linked into a balanced subtree that is spliced onto the tree's edge.
+//   - Otherwise the batch is merged with the nodes of the tree and the whole is rebuilt balanced.
+//
+// Just as with `Upsert()`, nodes that are already present in the tree are kept, and the duplicates
+// from the batch are ignored. Batches that are not sorted are added one by one using `Upsert()`.
+// The `Left` and `Right` pointers of the added nodes are overwritten.
+func (b *BTree) BulkUpsert(nodes []*Node) {
+	defer b.beginWrite("BulkUpsert")()
+	b.ResetFinger()
+	if !b.sorted(nodes) {
+		for _, n := range nodes {
+			n.Left, n.Right = nil, nil
+			b.upsertCapped(n)
+		}
+		return
+	}
+	nodes = b.dedup(nodes)
+	if len(nodes) == 0 {
+		return
+	}
+
+	if b.Root == nil {
+		b.addedAll(nodes)
+		b.Root = buildBalanced(nodes)
+		return
+	}
+	if max := rightmost(b.Root); b.Less(max, nodes[0]) {
+		b.addedAll(nodes)
+		max.Right = buildBalanced(nodes)
+		return
+	}
+	if min := leftmost(b.Root); b.Less(nodes[len(nodes)-1], min) {
+		b.addedAll(nodes)
+		min.Left = buildBalanced(nodes)
+		return
+	}
+	b.Root = buildBalanced(b.merge(nodes))
+	b.dropTombstones() // `merge()` skipped them
+}
+
+// UpsertBatch adds a batch of nodes to the tree, like calling `Upsert()` for each of them, and
+// returns how many were added. Unlike `BulkUpsert()`, it keeps the shape of the tree, but it
+// shares the work of the descents: the batch is sorted and split at each visited node into the
+// parts for its left and right subtrees, so that the nodes near the root are compared against
+// once per batch rather than once per node. A part that reaches an empty spot is linked in as a
+// balanced subtree. The batch itself is not reordered, but the `Left` and `Right` pointers of the
+// added nodes are overwritten.
+func (b *BTree) UpsertBatch(nodes []*Node) (inserted int) {
+	defer b.beginWrite("UpsertBatch")()
+	b.ResetFinger()
+	batch := append([]*Node(nil), nodes...)
+	sort.SliceStable(batch, func(i, j int) bool { return b.Less(batch[i], batch[j]) })
+	batch = b.dedup(batch)
+
+	type part struct {
+		slot  **Node
+		batch []*Node
+	}
+	todo := []part{{&b.Root, batch}}
+	for len(todo) > 0 {
+		p := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+		x := *p.slot
+		if x == nil {
+			for _, n := range p.batch {
+				n.Left, n.Right = nil, nil
+				b.added(n)
+			}
+			*p.slot = buildBalanced(p.batch)
+			inserted += len(p.batch)
+			continue
+		}
+		b.stats.visit()
+		lo := sort.Search(len(p.batch), func(i int) bool { return !b.Less(p.batch[i], x) })
+		hi := lo
+		if hi < len(p.batch) && !b.Less(x, p.batch[hi]) {
+			// `x` is already in the tree, unless it is marked deleted or has expired.
+			if b.revive(x, p.batch[hi].Payload) {
+				inserted++
+			}
+			hi++
+		}
+		if lo > 0 {
+			todo = append(todo, part{&x.Left, p.batch[:lo]})
+		}
+		if hi < len(p.batch) {
+			todo = append(todo, part{&x.Right, p.batch[hi:]})
+		}
+	}
+	return inserted
+}
+
+// sorted returns `true` when `nodes` is in ascending order, equal neighbors allowed.
+func (b *BTree) sorted(nodes []*Node) bool {
+	for i := 1; i < len(nodes); i++ {
+		if b.Less(nodes[i], nodes[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// dedup returns the sorted `nodes` with equal neighbors removed; the first one of a run is kept.
+func (b *BTree) dedup(nodes []*Node) []*Node {
+	out := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		if len(out) > 0 && !b.Less(out[len(out)-1], n) {
+			continue
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// merge returns the nodes of the tree merged with the sorted and deduplicated `nodes`. Nodes of
+// the tree take precedence over equal nodes in `nodes`, except that an expired node is reused for
+// the payload of the equal one, like `upsert()` does; the others are recorded as `added()`.
+func (b *BTree) merge(nodes []*Node) []*Node {
+	out := make([]*Node, 0, len(nodes))
+	var it inorderIter
+	it.initLinked(b)
+	cur := it.next()
+	for _, n := range nodes {
+		for cur != nil && b.Less(cur, n) {
+			out = append(out, cur)
+			cur = it.next()
+		}
+		if cur != nil && !b.Less(n, cur) {
+			b.revive(cur, n.Payload) // `n` is already in the tree, unless it has expired
+			continue
+		}
+		b.added(n)
+		out = append(out, n)
+	}
+	for ; cur != nil; cur = it.next() {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// addedAll records `added()` for each of `nodes`.
+func (b *BTree) addedAll(nodes []*Node) {
+	for _, n := range nodes {
+		b.added(n)
+	}
+}
+
+func leftmost(n *Node) *Node {
+	for n.Left != nil {
+		n = n.Left
+	}
+	return n
+}
+
+func rightmost(n *Node) *Node {
+	for n.Right != nil {
+		n = n.Right
+	}
+	return n
+}
@@ -0,0 +1,158 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func intNodes(vals ...int) []*Node {
+	nodes := make([]*Node, len(vals))
+	for i, v := range vals {
+		nodes[i] = &Node{Payload: v}
+	}
+	return nodes
+}
+
+func TestBulkUpsert(t *testing.T) {
+	for _, test := range []struct {
+		desc  string
+		tree  []int
+		batch []int
+		want  []int
+	}{
+		{desc: "empty tree", tree: nil, batch: []int{1, 2, 2, 3}, want: []int{1, 2, 3}},
+		{desc: "append", tree: []int{2, 1}, batch: []int{3, 4, 5}, want: []int{1, 2, 3, 4, 5}},
+		{desc: "prepend", tree: []int{5, 6}, batch: []int{1, 2}, want: []int{1, 2, 5, 6}},
+		{desc: "merge", tree: []int{5, 1, 9}, batch: []int{0, 1, 4, 6, 10}, want: []int{0, 1, 4, 5, 6, 9, 10}},
+		{desc: "unsorted", tree: []int{5}, batch: []int{3, 7, 1, 5}, want: []int{1, 3, 5, 7}},
+	} {
+		b := newIntTree(test.tree...)
+		b.BulkUpsert(intNodes(test.batch...))
+		if got := inOrderInts(b); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: BulkUpsert(%v) = %v, want %v", test.desc, test.batch, got, test.want)
+		}
+		for _, v := range test.want {
+			if _, inserted := b.Upsert(&Node{Payload: v}); inserted {
+				t.Errorf("%s: Upsert(%v) after BulkUpsert = inserted, want already present", test.desc, v)
+			}
+		}
+	}
+}
+
+func TestBulkUpsertKeepsExistingNodes(t *testing.T) {
+	b := newIntTree(1, 3)
+	existing := b.Root
+	b.BulkUpsert(intNodes(0, 1, 2))
+	if intree, _ := b.Upsert(&Node{Payload: 1}); intree != existing {
+		t.Errorf("BulkUpsert() replaced an existing node")
+	}
+}
+
+func BenchmarkBulkUpsert(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		t := New(intLess)
+		nodes := make([]*Node, 10000)
+		for j := range nodes {
+			nodes[j] = &Node{Payload: j}
+		}
+		t.BulkUpsert(nodes)
+	}
+}
+
+func BenchmarkUpsertSorted(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		t := New(intLess)
+		for j := 0; j < 10000; j++ {
+			t.Upsert(&Node{Payload: j})
+		}
+	}
+}
+
+func TestBulkUpsertUnsortedIsChecked(t *testing.T) {
+	// The fallback for unsorted batches upserts like `Upsert()`, so it keeps to `MaxDepth`.
+	b := New(intLess)
+	b.MaxDepth = 10
+	nodes := intNodes(1000)
+	for i := 0; i < 1000; i++ {
+		nodes = append(nodes, &Node{Payload: i})
+	}
+	b.BulkUpsert(nodes)
+	if h := b.ShapeStats().Height; h > b.MaxDepth {
+		t.Errorf("height after an unsorted BulkUpsert() = %v, want at most %v", h, b.MaxDepth)
+	}
+	if got := len(inOrderInts(b)); got != 1001 {
+		t.Errorf("BulkUpsert() added %v nodes, want 1001", got)
+	}
+
+	// It also checks the `LessFunc`.
+	b = New(func(x, y *Node) bool { return x.Payload.(int) <= y.Payload.(int) })
+	b.CheckLess = true
+	msg := panicOf(func() { b.BulkUpsert(intNodes(3, 1, 2)) })
+	if !strings.HasPrefix(msg, "btree: inconsistent LessFunc: ") {
+		t.Errorf("BulkUpsert() of an unsorted batch with CheckLess: panic = %q, want one", msg)
+	}
+}
+
+func TestUpsertBatch(t *testing.T) {
+	for _, test := range []struct {
+		desc  string
+		tree  []int
+		batch []int
+		want  int
+	}{
+		{desc: "empty tree", tree: nil, batch: []int{3, 1, 2, 2}, want: 3},
+		{desc: "empty batch", tree: []int{1}, batch: nil, want: 0},
+		{desc: "duplicates", tree: []int{5, 1, 9}, batch: []int{9, 0, 1, 4, 6, 10, 5}, want: 4},
+		{desc: "all present", tree: []int{2, 1, 3}, batch: []int{3, 2, 1}, want: 0},
+	} {
+		b := newIntTree(test.tree...)
+		ref := newIntTree(test.tree...)
+		for _, v := range test.batch {
+			ref.Upsert(&Node{Payload: v})
+		}
+		if got := b.UpsertBatch(intNodes(test.batch...)); got != test.want {
+			t.Errorf("%s: UpsertBatch(%v) = %v, want %v", test.desc, test.batch, got, test.want)
+		}
+		if got, want := inOrderInts(b), inOrderInts(ref); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: UpsertBatch(%v) = %v, want %v", test.desc, test.batch, got, want)
+		}
+	}
+}
+
+func TestUpsertBatchKeepsShape(t *testing.T) {
+	b := newIntTree(50, 25, 75)
+	b.UpsertBatch(intNodes(10, 20, 30, 60, 65, 70, 90))
+	if b.Root.Payload != 50 || b.Root.Left.Payload != 25 || b.Root.Right.Payload != 75 {
+		t.Errorf("UpsertBatch() moved the existing nodes")
+	}
+	// Each part of the batch is linked in balanced: 65 on top of 60 and 70.
+	if n := b.Root.Right.Left; n.Payload != 65 || n.Left.Payload != 60 || n.Right.Payload != 70 {
+		t.Errorf("part under 75 = %v, want 65 on top of 60 and 70", n.Payload)
+	}
+}
+
+func TestUpsertBatchSharesDescents(t *testing.T) {
+	compares := 0
+	b := New(func(x, y *Node) bool {
+		compares++
+		return intLess(x, y)
+	})
+	b.BulkUpsert(intNodes(rand.New(rand.NewSource(1)).Perm(10000)...))
+	batch := make([]int, 1000)
+	for i := range batch {
+		batch[i] = 10000 + 2*i
+	}
+	ref := b.Clone(nil)
+	compares = 0
+	for _, v := range batch {
+		ref.Upsert(&Node{Payload: v})
+	}
+	oneByOne := compares
+	compares = 0
+	b.UpsertBatch(intNodes(batch...))
+	if compares >= oneByOne/2 {
+		t.Errorf("UpsertBatch() compares %v times, Upsert() %v times, want much fewer", compares, oneByOne)
+	}
+}
@@ -0,0 +1,202 @@
+// Package cache implements a bounded cache whose keys are kept in order, in a `btree.Augmented`.
+// Next to what a cache that is built on a map offers, it looks up ranges of keys (`AscendRange()`),
+// and evicts them (`EvictRange()`), e.g. all entries of a prefix or of a period. When the cache is
+// full, it evicts the entry that was least recently used (`LRU`), or least frequently used
+// (`LFU`). The eviction order is kept in a second tree, so that `EvictOldest()` evicts the entries
+// that come first in it on demand. Both trees keep themselves balanced, even though each use adds
+// a new largest node to the eviction order, so that all operations take O(log n).
+//
+// The entries are the caller's `*btree.Node`s, ordered by the caller's `btree.LessFunc`; the
+// cache keeps them in trees of its own, so their `Left` and `Right` pointers are not used.
+package cache
+
+import (
+	"github.com/KarelKubat/btree"
+)
+
+// Policy determines which entry is evicted first.
+type Policy int
+
+const (
+	// LRU evicts the least recently used entry first.
+	LRU Policy = iota
+	// LFU evicts the least frequently used entry first; among entries that were used equally
+	// often, the least recently used one.
+	LFU
+)
+
+// item is the payload of the nodes of both underlying trees: a caller's node, when it was last
+// used, and how often it was used. `order` is its node in the tree of the eviction order.
+type item struct {
+	n     *btree.Node
+	tick  uint64
+	uses  uint64
+	order *btree.Node
+}
+
+func itemOf(n *btree.Node) *item {
+	return n.Payload.(*item)
+}
+
+// Cache is a bounded cache of ordered entries. It is not safe for concurrent use.
+type Cache struct {
+	keys    *btree.Augmented
+	order   *btree.Augmented
+	maxSize int
+	len     int
+	tick    uint64
+}
+
+// New returns an empty `Cache` that orders its entries using `less`, and holds at most `maxSize`
+// entries, evicting per `policy`. When `maxSize` is 0 or less, the cache is unbounded, and entries
+// are only evicted on demand.
+func New(less btree.LessFunc, maxSize int, policy Policy) *Cache {
+	order := func(a, b *btree.Node) bool {
+		return itemOf(a).tick < itemOf(b).tick
+	}
+	if policy == LFU {
+		order = func(a, b *btree.Node) bool {
+			ia, ib := itemOf(a), itemOf(b)
+			if ia.uses != ib.uses {
+				return ia.uses < ib.uses
+			}
+			return ia.tick < ib.tick
+		}
+	}
+	return &Cache{
+		keys: btree.NewAugmented(func(a, b *btree.Node) bool {
+			return less(itemOf(a).n, itemOf(b).n)
+		}, nil),
+		order:   btree.NewAugmented(order, nil),
+		maxSize: maxSize,
+	}
+}
+
+// Len returns the number of entries.
+func (c *Cache) Len() int {
+	return c.len
+}
+
+// lookup returns the item of the entry like `n`, or `nil`.
+func (c *Cache) lookup(n *btree.Node) *item {
+	if at, found := c.keys.Find(&btree.Node{Payload: &item{n: n}}); found {
+		return itemOf(at)
+	}
+	return nil
+}
+
+// use records a use of `it`, which moves it in the eviction order.
+func (c *Cache) use(it *item) {
+	c.order.Delete(it.order)
+	c.touch(it)
+}
+
+// touch records a use of `it`, which is not in the tree of the eviction order, and adds it there.
+func (c *Cache) touch(it *item) {
+	c.tick++
+	it.tick = c.tick
+	it.uses++
+	c.order.Upsert(it.order)
+}
+
+// Put adds `n` as a new entry, or when there is an entry like `n`, replaces it, which counts as a
+// use. When the cache is full, an entry is evicted first to make room, and returned; otherwise
+// `evicted` is `nil`. The new entry itself is never evicted, even though under `LFU` it is the
+// least frequently used.
+func (c *Cache) Put(n *btree.Node) (evicted *btree.Node) {
+	if it := c.lookup(n); it != nil {
+		it.n = n
+		c.use(it)
+		return nil
+	}
+	if c.maxSize > 0 && c.len >= c.maxSize {
+		evicted = c.EvictOldest(1)[0]
+	}
+	it := &item{n: n}
+	it.order = &btree.Node{Payload: it}
+	c.keys.Upsert(&btree.Node{Payload: it})
+	c.touch(it)
+	c.len++
+	return evicted
+}
+
+// Get returns the entry like `n`, which counts as a use. The return value `found` is `false` when
+// there is no such entry.
+func (c *Cache) Get(n *btree.Node) (entry *btree.Node, found bool) {
+	it := c.lookup(n)
+	if it == nil {
+		return nil, false
+	}
+	c.use(it)
+	return it.n, true
+}
+
+// Peek is `Get()`, except that it doesn't count as a use.
+func (c *Cache) Peek(n *btree.Node) (entry *btree.Node, found bool) {
+	if it := c.lookup(n); it != nil {
+		return it.n, true
+	}
+	return nil, false
+}
+
+// Delete removes the entry like `n`. The return value `deleted` is `false` when there was no such
+// entry.
+func (c *Cache) Delete(n *btree.Node) (removed *btree.Node, deleted bool) {
+	it := c.lookup(n)
+	if it == nil {
+		return nil, false
+	}
+	c.remove(it)
+	return it.n, true
+}
+
+// remove takes `it` out of both trees.
+func (c *Cache) remove(it *item) {
+	c.keys.Delete(&btree.Node{Payload: it})
+	c.order.Delete(it.order)
+	c.len--
+}
+
+// EvictOldest evicts the `k` entries that come first in the eviction order, and returns them in
+// that order. There are fewer when the cache holds fewer entries.
+func (c *Cache) EvictOldest(k int) []*btree.Node {
+	evicted := []*btree.Node{}
+	for ; k > 0 && c.len > 0; k-- {
+		it := itemOf(c.order.Tree().Min())
+		c.remove(it)
+		evicted = append(evicted, it.n)
+	}
+	return evicted
+}
+
+// EvictRange evicts the entries `n` with `from <= n < to`, and returns them in ascending order.
+// These are the bounds of `btree.BTree.AscendRange()`.
+func (c *Cache) EvictRange(from, to *btree.Node) []*btree.Node {
+	items := []*item{}
+	c.keys.Tree().AscendRange(c.bound(from), c.bound(to), func(n *btree.Node) bool {
+		items = append(items, itemOf(n))
+		return true
+	})
+	evicted := []*btree.Node{}
+	for _, it := range items {
+		c.remove(it)
+		evicted = append(evicted, it.n)
+	}
+	return evicted
+}
+
+// AscendRange calls `visit` for the entries `n` with `from <= n < to`, in ascending order, until
+// `visit` returns `false`, like `btree.BTree.AscendRange()`. Visiting doesn't count as a use.
+func (c *Cache) AscendRange(from, to *btree.Node, visit btree.VisitFunc) {
+	c.keys.Tree().AscendRange(c.bound(from), c.bound(to), func(n *btree.Node) bool {
+		return visit(itemOf(n).n)
+	})
+}
+
+// bound returns the node of the key tree for the caller's range bound `n`, which may be `nil`.
+func (c *Cache) bound(n *btree.Node) *btree.Node {
+	if n == nil {
+		return nil
+	}
+	return &btree.Node{Payload: &item{n: n}}
+}
@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/KarelKubat/btree"
+)
+
+func intLess(a, b *btree.Node) bool {
+	return a.Payload.(int) < b.Payload.(int)
+}
+
+func node(v int) *btree.Node {
+	return &btree.Node{Payload: v}
+}
+
+func ints(nodes []*btree.Node) []int {
+	out := []int{}
+	for _, n := range nodes {
+		out = append(out, n.Payload.(int))
+	}
+	return out
+}
+
+func TestLRU(t *testing.T) {
+	c := New(intLess, 3, LRU)
+	for _, v := range []int{1, 2, 3} {
+		if evicted := c.Put(node(v)); evicted != nil {
+			t.Errorf("Put(%v) evicted %v, want nil", v, evicted)
+		}
+	}
+	c.Get(node(1))
+	c.Peek(node(2)) // doesn't count
+	if evicted := c.Put(node(4)); evicted == nil || evicted.Payload != 2 {
+		t.Errorf("Put(4) evicted %v, want 2", evicted)
+	}
+	if _, found := c.Get(node(2)); found {
+		t.Errorf("Get(2) after its eviction = true, want false")
+	}
+	if got, want := ints(c.EvictOldest(2)), []int{3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EvictOldest(2) = %v, want %v", got, want)
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len() = %v, want 1", c.Len())
+	}
+}
+
+func TestLFU(t *testing.T) {
+	c := New(intLess, 3, LFU)
+	for _, v := range []int{1, 2, 3} {
+		c.Put(node(v))
+	}
+	c.Get(node(1))
+	c.Get(node(1))
+	c.Get(node(3))
+	c.Get(node(2))
+	// Uses: 1 three times, 2 and 3 twice, but 3 less recently.
+	if evicted := c.Put(node(4)); evicted == nil || evicted.Payload != 3 {
+		t.Errorf("Put(4) evicted %v, want 3", evicted)
+	}
+	if got, want := ints(c.EvictOldest(5)), []int{4, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EvictOldest(5) = %v, want %v", got, want)
+	}
+}
+
+func TestRanges(t *testing.T) {
+	c := New(intLess, 0, LRU)
+	for _, v := range []int{50, 20, 80, 10, 30, 70, 90} {
+		c.Put(node(v))
+	}
+	got := []int{}
+	c.AscendRange(node(20), node(80), func(n *btree.Node) bool {
+		got = append(got, n.Payload.(int))
+		return true
+	})
+	if want := []int{20, 30, 50, 70}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AscendRange(20, 80) = %v, want %v", got, want)
+	}
+	evicted := ints(c.EvictRange(node(25), nil))
+	if want := []int{30, 50, 70, 80, 90}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("EvictRange(25, nil) = %v, want %v", evicted, want)
+	}
+	if got, want := ints(c.EvictOldest(10)), []int{20, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EvictOldest() after EvictRange() = %v, want %v", got, want)
+	}
+}
+
+func TestAgainstList(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const maxSize = 20
+	c := New(intLess, maxSize, LRU)
+	// recent holds the keys from the least to the most recently used.
+	recent := []int{}
+	used := func(v int) {
+		for i, r := range recent {
+			if r == v {
+				recent = append(recent[:i], recent[i+1:]...)
+				break
+			}
+		}
+		recent = append(recent, v)
+	}
+	for i := 0; i < 5000; i++ {
+		v := rng.Intn(40)
+		switch op := rng.Intn(10); {
+		case op < 5:
+			evicted := c.Put(node(v))
+			used(v)
+			if len(recent) > maxSize {
+				if evicted == nil || evicted.Payload != recent[0] {
+					t.Fatalf("op %v: Put(%v) evicted %v, want %v", i, v, evicted, recent[0])
+				}
+				recent = recent[1:]
+			} else if evicted != nil {
+				t.Fatalf("op %v: Put(%v) evicted %v, want nil", i, v, evicted)
+			}
+		case op < 9:
+			_, found := c.Get(node(v))
+			want := false
+			for _, r := range recent {
+				want = want || r == v
+			}
+			if found != want {
+				t.Fatalf("op %v: Get(%v) = %v, want %v", i, v, found, want)
+			}
+			if found {
+				used(v)
+			}
+		default:
+			_, deleted := c.Delete(node(v))
+			for j, r := range recent {
+				if r == v {
+					recent = append(recent[:j], recent[j+1:]...)
+					deleted = !deleted
+					break
+				}
+			}
+			if deleted {
+				t.Fatalf("op %v: Delete(%v) disagrees with the list", i, v)
+			}
+		}
+		if c.Len() != len(recent) {
+			t.Fatalf("op %v: Len() = %v, want %v", i, c.Len(), len(recent))
+		}
+	}
+	if got := ints(c.EvictOldest(maxSize)); !reflect.DeepEqual(got, recent) {
+		t.Errorf("EvictOldest() = %v, want %v", got, recent)
+	}
+}
+
+func TestBalanced(t *testing.T) {
+	for _, policy := range []Policy{LRU, LFU} {
+		c := New(intLess, 1000, policy)
+		for i := 0; i < 5000; i++ {
+			c.Put(node(i))
+			c.Get(node(i / 2))
+		}
+		for name, tr := range map[string]*btree.Augmented{"keys": c.keys, "order": c.order} {
+			if h := tr.Tree().ShapeStats().Height; h > 30 {
+				t.Errorf("policy %v: height of the %s tree of %v entries = %v, want at most 30",
+					policy, name, c.Len(), h)
+			}
+		}
+	}
+}
+
+func BenchmarkPut(b *testing.B) {
+	for _, size := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			c := New(intLess, size, LRU)
+			for i := 0; i < size; i++ {
+				c.Put(node(i))
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Put(node(size + i))
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package btree
+
+// Cartesian is the Cartesian tree of a sequence: a binary tree whose in-order traversal is the
+// sequence, and which is heap-ordered by `less`, so that each node is smaller than (or equal to)
+// the nodes below it. The top of the subtree that spans a range of the sequence is the smallest
+// element of that range, which makes the tree a basis for range-minimum queries.
+//
+// The tree is not a search tree, since it is not ordered by `less` from left to right; it is
+// built from `Node`s so that the package's traversals and printing apply to it, e.g. via
+// `BTree{Root: c.Root}`.
+type Cartesian struct {
+	// Root is the top of the tree: the smallest element of the sequence.
+	Root  *Node
+	nodes []*Node
+	pos   map[*Node]int
+}
+
+// NewCartesian returns the Cartesian tree of `payloads`, in O(n). Of equal payloads, the first
+// one is the higher in the tree.
+func NewCartesian(less LessFunc, payloads []interface{}) *Cartesian {
+	c := &Cartesian{nodes: make([]*Node, len(payloads)), pos: make(map[*Node]int, len(payloads))}
+	// The right spine of the tree so far; each new node goes at its end, taking the nodes that are
+	// larger than it as its left subtree.
+	spine := []*Node{}
+	for i, p := range payloads {
+		n := &Node{Payload: p}
+		c.nodes[i], c.pos[n] = n, i
+		var last *Node
+		for len(spine) > 0 && less(n, spine[len(spine)-1]) {
+			last, spine = spine[len(spine)-1], spine[:len(spine)-1]
+		}
+		n.Left = last
+		if len(spine) > 0 {
+			spine[len(spine)-1].Right = n
+		}
+		spine = append(spine, n)
+	}
+	if len(spine) > 0 {
+		c.Root = spine[0]
+	}
+	return c
+}
+
+// Len returns the length of the sequence.
+func (c *Cartesian) Len() int {
+	return len(c.nodes)
+}
+
+// Node returns the node of the `i`-th element of the sequence.
+func (c *Cartesian) Node(i int) *Node {
+	return c.nodes[i]
+}
+
+// RangeMin returns the node of the smallest element of the sequence from position `i` up to (but
+// not including) `j`, and its position. Of equal elements, the first one is returned. It takes
+// time in the order of the height of the tree; for an empty range it returns `nil` and -1.
+func (c *Cartesian) RangeMin(i, j int) (n *Node, pos int) {
+	i, j = max(i, 0), min(j, len(c.nodes))
+	if i >= j {
+		return nil, -1
+	}
+	for n = c.Root; ; {
+		switch pos = c.pos[n]; {
+		case pos >= j:
+			n = n.Left
+		case pos < i:
+			n = n.Right
+		default:
+			return n, pos
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestCartesian(t *testing.T) {
+	seq := []interface{}{3, 2, 6, 1, 9, 1}
+	c := NewCartesian(intLess, seq)
+	if got := inOrderInts(&BTree{Root: c.Root}); !reflect.DeepEqual(got, []int{3, 2, 6, 1, 9, 1}) {
+		t.Errorf("in-order = %v, want the sequence", got)
+	}
+	// The first 1 is on top; 2 is its left child, with 3 and 6 below; the second 1 is its right
+	// child, with 9 left of it.
+	got := (&BTree{Root: c.Root}).SExpr(intLabel)
+	if want := "(1 (2 (3) (6)) (1 (9)))"; got != want {
+		t.Errorf("shape = %v, want %v", got, want)
+	}
+	if c.Root != c.Node(3) {
+		t.Errorf("Root is not the node of position 3")
+	}
+	if n, _ := NewCartesian(intLess, nil).RangeMin(0, 1); n != nil {
+		t.Errorf("RangeMin() of an empty sequence = %v, want nil", n)
+	}
+}
+
+func TestCartesianRangeMin(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	seq := make([]interface{}, 200)
+	for i := range seq {
+		seq[i] = r.Intn(50)
+	}
+	c := NewCartesian(intLess, seq)
+	for k := 0; k < 1000; k++ {
+		i, j := r.Intn(len(seq)), r.Intn(len(seq)+1)
+		wantPos := -1
+		for p := i; p < j; p++ {
+			if wantPos < 0 || seq[p].(int) < seq[wantPos].(int) {
+				wantPos = p
+			}
+		}
+		if n, pos := c.RangeMin(i, j); pos != wantPos || (pos >= 0 && n.Payload != seq[pos]) {
+			t.Fatalf("RangeMin(%v, %v) = %v, want %v", i, j, pos, wantPos)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package btree
+
+import "fmt"
+
+// checksum is the state of `EnableChecksum()`.
+type checksum struct {
+	hash HashFunc
+	sum  uint64
+}
+
+// mix spreads the bits of a node's hash, so that the sum of the hashes of different sets of
+// nodes rarely collides, even for simple hashes such as the value of an int.
+func mix(h uint64) uint64 {
+	// The finalizer of splitmix64.
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// EnableChecksum starts keeping a checksum of the nodes of the tree, computed using `hash`, for
+// `CheckIntegrity()`. The checksum is the sum of the node hashes, so each insertion and deletion
+// updates it in O(1). `hash` should cover the parts of the payloads that must not change while
+// they are in the tree, e.g. the fields that the `LessFunc` compares.
+//
+// The checksum is computed from the current nodes. Loading the tree, e.g. with `UnmarshalJSON()`
+// or `ReadBinary()`, recomputes it; after changing the tree on purpose, call `EnableChecksum()`
+// again.
+func (b *BTree) EnableChecksum(hash HashFunc) {
+	defer b.beginWrite("EnableChecksum")()
+	b.checksum = &checksum{hash: hash, sum: b.sumHashes(hash)}
+}
+
+// CheckIntegrity returns an error when the tree was changed other than through its methods, e.g.
+// by code elsewhere that holds on to its nodes and changed their payloads, `Left` or `Right`. It
+// recomputes the checksum of `EnableChecksum()` and compares it to the one that was kept up to
+// date, and checks the structure using `Verify()`. It takes time in proportion to the size of the
+// tree. When there is no checksum, only `Verify()` is done.
+func (b *BTree) CheckIntegrity() error {
+	if err := b.Verify(); err != nil {
+		return err
+	}
+	defer b.beginRead("CheckIntegrity")()
+	if b.checksum == nil {
+		return nil
+	}
+	if got := b.sumHashes(b.checksum.hash); got != b.checksum.sum {
+		return fmt.Errorf("btree: the checksum of the nodes is %016x, expected %016x; the tree "+
+			"was changed other than through its methods", got, b.checksum.sum)
+	}
+	return nil
+}
+
+// sumHashes returns the sum of the mixed hashes of the nodes that aren't marked deleted.
+func (b *BTree) sumHashes(hash HashFunc) uint64 {
+	var sum uint64
+	stack := []*Node{}
+	for n := b.Root; n != nil || len(stack) > 0; n = n.Right {
+		for ; n != nil; n = n.Left {
+			stack = append(stack, n)
+		}
+		n, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if !b.dead(n) {
+			sum += mix(hash(n))
+		}
+	}
+	return sum
+}
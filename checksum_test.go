@@ -0,0 +1,82 @@
+package btree
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChecksumFollowsMethods(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		clock := &fakeClock{t: time.Unix(1000, 0)}
+		b := newIntTree(5, 2, 8)
+		b.Now = clock.now
+		b.LazyDelete = lazy
+		b.EnableChecksum(intHash)
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 2000; i++ {
+			v := rng.Intn(50)
+			switch rng.Intn(9) {
+			case 0, 1:
+				b.Upsert(&Node{Payload: v})
+			case 2:
+				b.UpsertWithExpiry(&Node{Payload: v}, clock.t.Add(time.Second))
+			case 3, 4:
+				b.Delete(&Node{Payload: v})
+			case 5:
+				b.BulkUpsert([]*Node{{Payload: v}, {Payload: v + 2}})
+			case 6:
+				clock.t = clock.t.Add(time.Second)
+				b.ExpireBefore(clock.t)
+			case 7:
+				b.Compact()
+				b.Rebalance()
+			default:
+				if rng.Intn(30) == 0 {
+					b.Clear()
+				}
+			}
+			if err := b.CheckIntegrity(); err != nil {
+				t.Fatalf("LazyDelete=%v: op %v: CheckIntegrity() = %v", lazy, i, err)
+			}
+		}
+	}
+}
+
+func TestCheckIntegrityFindsChanges(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		change  func(b *BTree)
+		wantErr string
+	}{
+		{
+			desc:    "changed payload",
+			change:  func(b *BTree) { b.Root.Right.Payload = 7 },
+			wantErr: "btree: the checksum of the nodes is",
+		},
+		{
+			desc:    "detached subtree",
+			change:  func(b *BTree) { b.Root.Left = nil },
+			wantErr: "btree: the checksum of the nodes is",
+		},
+		{
+			desc: "swapped payloads",
+			change: func(b *BTree) {
+				b.Root.Payload, b.Root.Left.Payload = b.Root.Left.Payload, b.Root.Payload
+			},
+			wantErr: "is not smaller than",
+		},
+	} {
+		b := newIntTree(4, 2, 6, 1, 3)
+		b.EnableChecksum(intHash)
+		test.change(b)
+		if err := b.CheckIntegrity(); err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%v: CheckIntegrity() = %v, want an error with %q", test.desc, err, test.wantErr)
+		}
+		b.EnableChecksum(intHash)
+		if err := b.CheckIntegrity(); err != nil && test.desc != "swapped payloads" {
+			t.Errorf("%v: CheckIntegrity() after EnableChecksum() = %v, want nil", test.desc, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package btree
+
+// CopyFunc duplicates a payload. It is supplied by the caller of `Clone()`.
+type CopyFunc func(payload interface{}) interface{}
+
+// Clone returns a copy of the tree that has the same shape and the same `LessFunc`. The nodes of
+// the copy are fresh, so that adding nodes to the one tree doesn't affect the other. When
+// `copyPayload` is `nil`, then the payloads are shared between both trees; otherwise
+// `copyPayload` is called for each node to duplicate the payload.
+func (b *BTree) Clone(copyPayload CopyFunc) *BTree {
+	return &BTree{
+		Root: cloneFrom(b.Root, copyPayload),
+		Less: b.Less,
+	}
+}
+
+func cloneFrom(n *Node, copyPayload CopyFunc) *Node {
+	if n == nil {
+		return nil
+	}
+	c := &Node{
+		Payload: n.Payload,
+		Left:    cloneFrom(n.Left, copyPayload),
+		Right:   cloneFrom(n.Right, copyPayload),
+	}
+	if copyPayload != nil {
+		c.Payload = copyPayload(n.Payload)
+	}
+	return c
+}
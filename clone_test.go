@@ -0,0 +1,33 @@
+package btree
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	b := newIntTree(5, 3, 8, 1, 4)
+	c := b.Clone(nil)
+	if !b.StructurallyEqual(c, intEqual) {
+		t.Fatalf("Clone(nil) differs from original")
+	}
+	c.Upsert(&Node{Payload: 9})
+	if b.Equal(c, intEqual) {
+		t.Errorf("Upsert on clone affected the original")
+	}
+}
+
+func TestCloneCopiesPayloads(t *testing.T) {
+	type counter struct{ n int }
+	b := New(func(a, b *Node) bool { return a.Payload.(*counter).n < b.Payload.(*counter).n })
+	orig := &counter{n: 1}
+	b.Upsert(&Node{Payload: orig})
+
+	c := b.Clone(func(p interface{}) interface{} {
+		cp := *p.(*counter)
+		return &cp
+	})
+	if c.Root.Payload.(*counter) == orig {
+		t.Errorf("Clone(copyPayload) shares the payload with the original")
+	}
+	if got := b.Clone(nil).Root.Payload.(*counter); got != orig {
+		t.Errorf("Clone(nil) doesn't share the payload with the original")
+	}
+}
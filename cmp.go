@@ -0,0 +1,16 @@
+package btree
+
+import "github.com/google/go-cmp/cmp"
+
+// Comparer returns a `cmp.Option` for `github.com/google/go-cmp/cmp` that compares a `*BTree` by
+// its in-order contents using `eq`, just like `Equal()`. The shape of the trees doesn't matter,
+// so that tests using `cmp.Diff()` or `cmp.Equal()` don't fail because a tree was built in a
+// different order or was rebalanced.
+func Comparer(eq EqualFunc) cmp.Option {
+	return cmp.Comparer(func(a, b *BTree) bool {
+		if a == nil || b == nil {
+			return a == nil && b == nil
+		}
+		return a.Equal(b, eq)
+	})
+}
@@ -0,0 +1,27 @@
+package btree
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestComparer(t *testing.T) {
+	type inventory struct {
+		Name  string
+		Items *BTree
+	}
+	a := inventory{Name: "x", Items: newIntTree(1, 2, 3)}
+	b := inventory{Name: "x", Items: newIntTree(2, 3, 1)}
+	if diff := cmp.Diff(a, b, Comparer(intEqual)); diff != "" {
+		t.Errorf("cmp.Diff() of equal contents = %s, want none", diff)
+	}
+
+	b.Items.Upsert(&Node{Payload: 4})
+	if cmp.Equal(a, b, Comparer(intEqual)) {
+		t.Errorf("cmp.Equal() of different contents = true, want false")
+	}
+	if !cmp.Equal(inventory{}, inventory{}, Comparer(intEqual)) {
+		t.Errorf("cmp.Equal() of nil trees = false, want true")
+	}
+}
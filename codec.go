@@ -0,0 +1,62 @@
+package btree
+
+import (
+	"encoding/json"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// PayloadCodec converts payloads into bytes and back. It is supplied to the serialization
+// functions, such as `WriteBinary()` and `ReadBinary()`, which take care of the structure of the
+// tree but leave the payloads to the codec.
+type PayloadCodec interface {
+	// EncodePayload returns the byte form of a payload.
+	EncodePayload(payload interface{}) ([]byte, error)
+	// DecodePayload converts the byte form back into a payload.
+	DecodePayload(data []byte) (interface{}, error)
+}
+
+// JSONCodec is a `PayloadCodec` that uses `encoding/json`.
+type JSONCodec struct {
+	// Unmarshal decodes a payload into its proper type. When `nil`, payloads are decoded the way
+	// `encoding/json` decodes into an `interface{}`.
+	Unmarshal func(data []byte) (interface{}, error)
+}
+
+// EncodePayload implements `PayloadCodec`.
+func (c JSONCodec) EncodePayload(payload interface{}) ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+// DecodePayload implements `PayloadCodec`.
+func (c JSONCodec) DecodePayload(data []byte) (interface{}, error) {
+	if c.Unmarshal != nil {
+		return c.Unmarshal(data)
+	}
+	var p interface{}
+	err := json.Unmarshal(data, &p)
+	return p, err
+}
+
+// CBORCodec is a `PayloadCodec` that uses CBOR (RFC 8949), a compact and self-describing binary
+// encoding. It is a drop-in replacement for `JSONCodec` where size matters.
+type CBORCodec struct {
+	// Unmarshal decodes a payload into its proper type, typically by calling `cbor.Unmarshal()`
+	// of `github.com/fxamacker/cbor/v2`. When `nil`, payloads are decoded into an `interface{}`.
+	Unmarshal func(data []byte) (interface{}, error)
+}
+
+// EncodePayload implements `PayloadCodec`.
+func (c CBORCodec) EncodePayload(payload interface{}) ([]byte, error) {
+	return cbor.Marshal(payload)
+}
+
+// DecodePayload implements `PayloadCodec`.
+func (c CBORCodec) DecodePayload(data []byte) (interface{}, error) {
+	if c.Unmarshal != nil {
+		return c.Unmarshal(data)
+	}
+	var p interface{}
+	err := cbor.Unmarshal(data, &p)
+	return p, err
+}
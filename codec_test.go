@@ -0,0 +1,74 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func unmarshalCBORPerson(data []byte) (interface{}, error) {
+	p := &person{}
+	err := cbor.Unmarshal(data, p)
+	return p, err
+}
+
+func TestCodecs(t *testing.T) {
+	for _, test := range []struct {
+		desc  string
+		codec PayloadCodec
+	}{
+		{desc: "JSON", codec: JSONCodec{Unmarshal: unmarshalPerson}},
+		{desc: "CBOR", codec: CBORCodec{Unmarshal: unmarshalCBORPerson}},
+	} {
+		b := newPersonTree("mary", "john", "zoe")
+		var buf bytes.Buffer
+		if err := b.WriteBinary(&buf, test.codec); err != nil {
+			t.Fatalf("%s: WriteBinary() = %v", test.desc, err)
+		}
+		back := New(personLess)
+		if err := back.ReadBinary(&buf, test.codec); err != nil {
+			t.Fatalf("%s: ReadBinary() = %v", test.desc, err)
+		}
+		if !b.StructurallyEqual(back, personEqual) {
+			t.Errorf("%s: ReadBinary(WriteBinary()) differs from the original", test.desc)
+		}
+	}
+}
+
+func TestCodecsUntyped(t *testing.T) {
+	for _, test := range []struct {
+		desc  string
+		codec PayloadCodec
+		want  interface{}
+	}{
+		{desc: "JSON", codec: JSONCodec{}, want: []interface{}{"a", 1.0}},
+		{desc: "CBOR", codec: CBORCodec{}, want: []interface{}{"a", uint64(1)}},
+	} {
+		data, err := test.codec.EncodePayload([]interface{}{"a", 1})
+		if err != nil {
+			t.Fatalf("%s: EncodePayload() = %v", test.desc, err)
+		}
+		got, err := test.codec.DecodePayload(data)
+		if err != nil {
+			t.Fatalf("%s: DecodePayload() = %v", test.desc, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: DecodePayload(EncodePayload()) = %#v, want %#v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestCBORIsSmallerThanJSON(t *testing.T) {
+	p := &person{Name: "john", Count: 42}
+	j, _ := json.Marshal(p)
+	c, err := CBORCodec{}.EncodePayload(p)
+	if err != nil {
+		t.Fatalf("EncodePayload() = %v", err)
+	}
+	if len(c) >= len(j) {
+		t.Errorf("CBOR = %v bytes, JSON = %v bytes", len(c), len(j))
+	}
+}
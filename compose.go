@@ -0,0 +1,26 @@
+package btree
+
+// Lexicographic returns a `LessFunc` for composite keys: nodes are ordered by the first of
+// `fields`, nodes that are equal in it by the second, and so on. E.g., persons ordered by last
+// name and then by first name:
+//
+//	less := btree.Lexicographic(byLastName, byFirstName)
+func Lexicographic(fields ...LessFunc) LessFunc {
+	return func(a, b *Node) bool {
+		for _, less := range fields {
+			switch {
+			case less(a, b):
+				return true
+			case less(b, a):
+				return false
+			}
+		}
+		return false
+	}
+}
+
+// Reverse returns the `LessFunc` of the opposite order than `less`, e.g. for a field of a
+// `Lexicographic()` key that is to be sorted descending.
+func Reverse(less LessFunc) LessFunc {
+	return inverse(less)
+}
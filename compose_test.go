@@ -0,0 +1,59 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLexicographic(t *testing.T) {
+	byKey := func(a, b *Node) bool { return a.Payload.(kv).key < b.Payload.(kv).key }
+	byVal := func(a, b *Node) bool { return a.Payload.(kv).val < b.Payload.(kv).val }
+	for _, test := range []struct {
+		name string
+		less LessFunc
+		want []string
+	}{
+		{"key, val", Lexicographic(byKey, byVal), []string{"a", "1", "a", "2", "b", "1", "b", "2"}},
+		{"val, key", Lexicographic(byVal, byKey), []string{"a", "1", "b", "1", "a", "2", "b", "2"}},
+		{"key, -val", Lexicographic(byKey, Reverse(byVal)),
+			[]string{"a", "2", "a", "1", "b", "2", "b", "1"}},
+	} {
+		b := New(test.less)
+		for _, p := range []kv{{"b", "1"}, {"a", "2"}, {"b", "2"}, {"a", "1"}, {"a", "1"}} {
+			b.Upsert(&Node{Payload: p})
+		}
+		if got := kvPairs(b); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Lexicographic(%v) = %v, want %v", test.name, got, test.want)
+		}
+	}
+	if Lexicographic()(&Node{Payload: 1}, &Node{Payload: 2}) {
+		t.Errorf("Lexicographic() of no fields orders nodes, want all equal")
+	}
+}
+
+func TestReverse(t *testing.T) {
+	b := New(Reverse(intLess))
+	for _, v := range []int{3, 1, 4, 1, 5} {
+		b.Upsert(&Node{Payload: v})
+	}
+	if got, want := inOrderInts(b), []int{5, 4, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order with Reverse(intLess) = %v, want %v", got, want)
+	}
+	one, two := &Node{Payload: 1}, &Node{Payload: 2}
+	if rev := Reverse(intLess); !rev(two, one) || rev(one, two) || rev(one, one) {
+		t.Errorf("Reverse(intLess) doesn't order 2 before 1, or orders equal nodes")
+	}
+	if rev := Reverse(Reverse(intLess)); !rev(one, two) || rev(two, one) {
+		t.Errorf("Reverse(Reverse(intLess)) isn't the original order")
+	}
+}
+
+func TestLexicographicOneField(t *testing.T) {
+	less := Lexicographic(intLess)
+	for _, test := range []struct{ a, b int }{{1, 2}, {2, 1}, {1, 1}} {
+		x, y := &Node{Payload: test.a}, &Node{Payload: test.b}
+		if got, want := less(x, y), intLess(x, y); got != want {
+			t.Errorf("Lexicographic(intLess)(%v, %v) = %v, want %v", test.a, test.b, got, want)
+		}
+	}
+}
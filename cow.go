@@ -0,0 +1,237 @@
+package btree
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// COWTree is a binary tree for read-mostly workloads. Mutations don't change nodes in place:
+// they copy the nodes on the path from the root to the change (copy-on-write), and then publish
+// the new root atomically. Readers therefore never need a lock; they see either the tree before
+// or after a mutation, never something in between. Writers are serialized by a mutex.
+//
+// Since published nodes may be in use by readers, they must never be changed, and that includes
+// their payloads. To change a payload, use `Replace()` with a fresh payload.
+type COWTree struct {
+	// Pool is an optional `NodePool`, to be set before the tree is used. When set, the nodes that
+	// mutations replace are recycled, once no reader can see them anymore (see `Read()`).
+	Pool *NodePool
+
+	mu   sync.Mutex // serializes writers
+	root atomic.Pointer[Node]
+	less LessFunc
+	epochs
+}
+
+// NewCOW returns an empty `COWTree` that orders its nodes using `less`.
+func NewCOW(less LessFunc) *COWTree {
+	return &COWTree{less: less}
+}
+
+// Snapshot returns the current state of the tree as a `BTree`, which can be examined using all
+// read-only methods (`Find()`, `DepthFirstInOrder()`, `AscendRange()` and so on) without any
+// locking. Later mutations of the `COWTree` don't affect the snapshot. The snapshot must not be
+// modified.
+//
+// When the tree has a `Pool`, the nodes of a snapshot may be recycled at any time; use `Read()`
+// instead.
+func (c *COWTree) Snapshot() *BTree {
+	return &BTree{Root: c.root.Load(), Less: c.less}
+}
+
+// Find looks up a node without locking, see `BTree.Find()`. When the tree has a `Pool`, the
+// returned node is a copy that holds the payload, but has no sub-nodes.
+func (c *COWTree) Find(n *Node) (intree *Node, found bool) {
+	if c.Pool == nil {
+		return c.Snapshot().Find(n)
+	}
+	c.Read(func(b *BTree) {
+		if intree, found = b.Find(n); found {
+			intree = &Node{Payload: intree.Payload}
+		}
+	})
+	return intree, found
+}
+
+// Upsert adds `n` to the tree, unless an equal node is present. The return values are as for
+// `BTree.Upsert()`. The node `n` becomes part of the tree and must not be changed afterwards.
+// When the tree has a `Pool`, `intree` must not be used after the next mutation.
+func (c *COWTree) Upsert(n *Node) (intree *Node, inserted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n.Left, n.Right = nil, nil
+	var retired []*Node
+	top, intree, inserted := c.copier(&retired).upsert(c.root.Load(), n, false)
+	if inserted {
+		c.publish(top, retired)
+	}
+	return intree, inserted
+}
+
+// Replace adds `n` to the tree, or when an equal node is present, puts `n` in its place. The
+// return value `old` is the node that was replaced (or `nil`), and `replaced` is `true` when there
+// was such a node. When the tree has a `Pool`, `old` is a copy that has no sub-nodes.
+func (c *COWTree) Replace(n *Node) (old *Node, replaced bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n.Left, n.Right = nil, nil
+	var retired []*Node
+	top, old, inserted := c.copier(&retired).upsert(c.root.Load(), n, true)
+	c.publish(top, retired)
+	if inserted {
+		return nil, false
+	}
+	return c.detached(old), true
+}
+
+// Delete removes the node that is equal to `n`. The return values are as for `BTree.Delete()`.
+// The removed node may still be in use by readers of older snapshots, so it must not be changed.
+// When the tree has a `Pool`, `removed` is a copy that has no sub-nodes.
+func (c *COWTree) Delete(n *Node) (removed *Node, deleted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var retired []*Node
+	top, removed := c.copier(&retired).delete(c.root.Load(), n)
+	if removed == nil {
+		return nil, false
+	}
+	c.publish(top, retired)
+	return c.detached(removed), true
+}
+
+// copier returns the `pathCopier` for mutations. When the tree has a `Pool`, replaced nodes are
+// collected in `retired`.
+func (c *COWTree) copier(retired *[]*Node) pathCopier {
+	if c.Pool == nil {
+		return pathCopier{less: c.less}
+	}
+	return pathCopier{less: c.less, pool: c.Pool, retired: retired}
+}
+
+// publish makes `top` the new root; the `retired` nodes are recycled when it is safe to do so.
+// The writer lock must be held.
+func (c *COWTree) publish(top *Node, retired []*Node) {
+	c.root.Store(top)
+	if c.Pool != nil {
+		c.epochs.retire(retired, c.Pool)
+	}
+}
+
+// detached returns `n`, or when nodes are recycled, a copy that holds its payload.
+func (c *COWTree) detached(n *Node) *Node {
+	if c.Pool == nil {
+		return n
+	}
+	return &Node{Payload: n.Payload}
+}
+
+// pathCopier implements the copy-on-write mutations of the subtrees of `COWTree`, `MVCCTree`,
+// `Persistent` and `Txn`. Nodes on the path to a change are copied instead of changed.
+type pathCopier struct {
+	less LessFunc
+	// pool, when set, provides the copies.
+	pool *NodePool
+	// retired, when set, collects the nodes that are no longer part of the new subtree.
+	retired *[]*Node
+}
+
+// copy returns a copy of `n`, to be changed.
+func (p pathCopier) copy(n *Node) *Node {
+	p.retire(n)
+	var cp *Node
+	if p.pool != nil {
+		cp = p.pool.Get(nil)
+	} else {
+		cp = &Node{}
+	}
+	*cp = *n
+	return cp
+}
+
+func (p pathCopier) retire(n *Node) {
+	if p.retired != nil {
+		*p.retired = append(*p.retired, n)
+	}
+}
+
+// upsert returns the new top of the subtree under `from` having `n`, plus the node in the tree
+// and whether `n` was inserted. When `n` was not inserted, `from` is returned as-is, unless
+// `replace` is set; then `n` takes the place of the equal node, and that node is returned.
+func (p pathCopier) upsert(from, n *Node, replace bool) (top, intree *Node, inserted bool) {
+	if from == nil {
+		return n, n, true
+	}
+	var sub *Node
+	switch {
+	case p.less(n, from):
+		if sub, intree, inserted = p.upsert(from.Left, n, replace); inserted || replace {
+			cp := p.copy(from)
+			cp.Left = sub
+			return cp, intree, inserted
+		}
+	case p.less(from, n):
+		if sub, intree, inserted = p.upsert(from.Right, n, replace); inserted || replace {
+			cp := p.copy(from)
+			cp.Right = sub
+			return cp, intree, inserted
+		}
+	default:
+		if replace {
+			p.retire(from)
+			n.Left, n.Right = from.Left, from.Right
+			return n, from, false
+		}
+		return from, from, false
+	}
+	return from, intree, false
+}
+
+// delete returns the new top of the subtree under `from` without the node that is equal to `n`,
+// plus that node (or `nil`).
+func (p pathCopier) delete(from, n *Node) (top, removed *Node) {
+	if from == nil {
+		return nil, nil
+	}
+	var sub *Node
+	switch {
+	case p.less(n, from):
+		if sub, removed = p.delete(from.Left, n); removed != nil {
+			cp := p.copy(from)
+			cp.Left = sub
+			return cp, removed
+		}
+		return from, nil
+	case p.less(from, n):
+		if sub, removed = p.delete(from.Right, n); removed != nil {
+			cp := p.copy(from)
+			cp.Right = sub
+			return cp, removed
+		}
+		return from, nil
+	}
+	switch {
+	case from.Left == nil:
+		p.retire(from)
+		return from.Right, from
+	case from.Right == nil:
+		p.retire(from)
+		return from.Left, from
+	}
+	right, succ := p.removeMin(from.Right)
+	cp := p.copy(from)
+	cp.Payload, cp.Right = succ.Payload, right
+	return cp, from
+}
+
+// removeMin returns the new top of the subtree under `n` without its smallest node, plus that
+// node.
+func (p pathCopier) removeMin(n *Node) (top, min *Node) {
+	if n.Left == nil {
+		p.retire(n)
+		return n.Right, n
+	}
+	left, min := p.removeMin(n.Left)
+	cp := p.copy(n)
+	cp.Left = left
+	return cp, min
+}
@@ -0,0 +1,93 @@
+package btree
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCOWTree(t *testing.T) {
+	c := NewCOW(intLess)
+	for _, v := range []int{5, 3, 8, 1, 4, 7, 9} {
+		if _, inserted := c.Upsert(&Node{Payload: v}); !inserted {
+			t.Errorf("Upsert(%v) = not inserted", v)
+		}
+	}
+	before := c.Snapshot()
+	beforeShape := before.SExpr(intLabel)
+
+	if _, inserted := c.Upsert(&Node{Payload: 4}); inserted {
+		t.Errorf("Upsert(4) again = inserted")
+	}
+	c.Upsert(&Node{Payload: 6})
+	c.Delete(&Node{Payload: 5})
+	c.Delete(&Node{Payload: 1})
+	if _, deleted := c.Delete(&Node{Payload: 42}); deleted {
+		t.Errorf("Delete(42) = deleted")
+	}
+
+	if got := before.SExpr(intLabel); got != beforeShape {
+		t.Errorf("snapshot changed from %v to %v", beforeShape, got)
+	}
+	if got, want := inOrderInts(c.Snapshot()), []int{3, 4, 6, 7, 8, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after mutations = %v, want %v", got, want)
+	}
+	if _, found := c.Find(&Node{Payload: 6}); !found {
+		t.Errorf("Find(6) = not found")
+	}
+}
+
+func TestCOWTreeReplace(t *testing.T) {
+	c := NewCOW(kvLess)
+	c.Upsert(&Node{Payload: kv{key: "b", val: "1"}})
+	c.Upsert(&Node{Payload: kv{key: "a", val: "1"}})
+	before := c.Snapshot()
+
+	if _, replaced := c.Replace(&Node{Payload: kv{key: "b", val: "2"}}); !replaced {
+		t.Errorf("Replace(b) = not replaced")
+	}
+	if _, replaced := c.Replace(&Node{Payload: kv{key: "c", val: "3"}}); replaced {
+		t.Errorf("Replace(c) = replaced, want inserted")
+	}
+	if got, want := kvPairs(c.Snapshot()), []string{"a", "1", "b", "2", "c", "3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after Replace() = %v, want %v", got, want)
+	}
+	if got, want := kvPairs(before), []string{"a", "1", "b", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot after Replace() = %v, want %v", got, want)
+	}
+}
+
+func TestCOWTreeConcurrentReaders(t *testing.T) {
+	c := NewCOW(intLess)
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				prev := -1
+				c.Snapshot().DepthFirstInOrder(func(n *Node) {
+					if v := n.Payload.(int); v <= prev {
+						t.Errorf("snapshot out of order: %v after %v", v, prev)
+					} else {
+						prev = v
+					}
+				})
+			}
+		}()
+	}
+	for i := 0; i < 2000; i++ {
+		c.Upsert(&Node{Payload: (i * 7919) % 1000})
+		if i%3 == 0 {
+			c.Delete(&Node{Payload: (i * 31) % 1000})
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,92 @@
+package btree
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// RowCodec converts payloads into CSV rows and back. It is supplied to `WriteCSV()` and
+// `ReadCSV()`. By convention the first column holds the key by which the tree is ordered, and the
+// remaining columns hold the rest of the payload.
+type RowCodec interface {
+	// Header returns the column names, or `nil` when the CSV data has no header row.
+	Header() []string
+	// EncodeRow returns the columns for a payload.
+	EncodeRow(payload interface{}) ([]string, error)
+	// DecodeRow converts the columns of a row back into a payload.
+	DecodeRow(row []string) (interface{}, error)
+}
+
+// WriteCSV writes the contents of the tree to `w` as CSV, one row per node in the order of
+// `DepthFirstInOrder()`, preceded by the header of `codec` (if any).
+func (b *BTree) WriteCSV(w io.Writer, codec RowCodec) error {
+	return b.WriteDelimited(w, codec, ',')
+}
+
+// WriteDelimited is `WriteCSV()`, but separates the columns by `comma`, e.g. '\t' for TSV.
+func (b *BTree) WriteDelimited(w io.Writer, codec RowCodec, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if header := codec.Header(); header != nil {
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	var it inorderIter
+	it.init(b)
+	for n := it.next(); n != nil; n = it.next() {
+		row, err := codec.EncodeRow(n.Payload)
+		if err != nil {
+			return fmt.Errorf("btree: cannot encode payload %v: %v", n.Payload, err)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV reads CSV rows from `r` and adds them to the tree, using `codec` to convert the rows into
+// payloads. When `codec` has a header, the first row of `r` must match it. The rows don't need to
+// be sorted, but sorted input (such as the output of `WriteCSV()`) is loaded fastest, using
+// `BulkUpsert()`.
+func (b *BTree) ReadCSV(r io.Reader, codec RowCodec) error {
+	return b.ReadDelimited(r, codec, ',')
+}
+
+// ReadDelimited is `ReadCSV()`, for rows whose columns are separated by `comma`.
+func (b *BTree) ReadDelimited(r io.Reader, codec RowCodec, comma rune) error {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	header := codec.Header()
+	if header != nil {
+		got, err := cr.Read()
+		if err != nil {
+			return fmt.Errorf("btree: cannot read CSV header: %v", err)
+		}
+		if !reflect.DeepEqual(got, header) {
+			return fmt.Errorf("btree: CSV header %q doesn't match %q", got, header)
+		}
+	}
+	var nodes []*Node
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		payload, err := codec.DecodeRow(row)
+		if err != nil {
+			line, _ := cr.FieldPos(0)
+			return fmt.Errorf("btree: cannot decode CSV row %q on line %v: %v", row, line, err)
+		}
+		nodes = append(nodes, &Node{Payload: payload})
+	}
+	b.BulkUpsert(nodes)
+	return nil
+}
@@ -0,0 +1,80 @@
+package btree
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// personRows is a `RowCodec` for `*person` payloads.
+type personRows struct{}
+
+func (personRows) Header() []string {
+	return []string{"name", "count"}
+}
+
+func (personRows) EncodeRow(p interface{}) ([]string, error) {
+	return []string{p.(*person).Name, strconv.Itoa(p.(*person).Count)}, nil
+}
+
+func (personRows) DecodeRow(row []string) (interface{}, error) {
+	if len(row) != 2 {
+		return nil, errors.New("want 2 columns")
+	}
+	count, err := strconv.Atoi(row[1])
+	return &person{Name: row[0], Count: count}, err
+}
+
+func TestCSV(t *testing.T) {
+	b := newPersonTree("mary", "john", "zoe, jr.")
+	var buf bytes.Buffer
+	if err := b.WriteCSV(&buf, personRows{}); err != nil {
+		t.Fatalf("WriteCSV() = %v", err)
+	}
+	want := "name,count\njohn,1\nmary,0\n\"zoe, jr.\",2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteCSV() = %q, want %q", got, want)
+	}
+
+	back := New(personLess)
+	if err := back.ReadCSV(&buf, personRows{}); err != nil {
+		t.Fatalf("ReadCSV() = %v", err)
+	}
+	if !b.Equal(back, personEqual) {
+		t.Errorf("ReadCSV(WriteCSV()) differs from the original")
+	}
+}
+
+func TestDelimited(t *testing.T) {
+	b := newPersonTree("mary", "john", "zoe, jr.")
+	var buf bytes.Buffer
+	if err := b.WriteDelimited(&buf, personRows{}, '\t'); err != nil {
+		t.Fatalf("WriteDelimited() = %v", err)
+	}
+	want := "name\tcount\njohn\t1\nmary\t0\nzoe, jr.\t2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteDelimited() = %q, want %q", got, want)
+	}
+
+	back := New(personLess)
+	if err := back.ReadDelimited(&buf, personRows{}, '\t'); err != nil {
+		t.Fatalf("ReadDelimited() = %v", err)
+	}
+	if !b.Equal(back, personEqual) {
+		t.Errorf("ReadDelimited(WriteDelimited()) differs from the original")
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"who,count\njohn,1\n",
+		"name,count\njohn,one\n",
+	} {
+		if err := New(personLess).ReadCSV(strings.NewReader(in), personRows{}); err == nil {
+			t.Errorf("ReadCSV(%q) succeeds, want error", in)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package btree
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// debugMaxNodes is the number of nodes that the page of `DebugHandler()` shows when there is no
+// `max` parameter.
+const debugMaxNodes = 1000
+
+// DebugHandler returns an `http.Handler` that shows the wrapped tree in a running service, e.g.
+// when mounted under /debug/btree:
+//
+//	http.Handle("/debug/btree", s.DebugHandler(label))
+//
+// A `GET` returns an HTML page with the `ShapeStats()`, the `Stats()` and `Metrics` when they are
+// enabled, and the tree itself as nested, collapsible lists, using `label` for the text of each
+// node. At most `max` nodes are shown (default 1000). With `?format=dot`, the tree is returned as
+// a Graphviz DOT graph instead (see `WriteDOT()`), e.g. to render it as SVG:
+//
+//	curl 'localhost:8080/debug/btree?format=dot' | dot -Tsvg > tree.svg
+//
+// The tree is read under the read lock.
+func (s *SyncTree) DebugHandler(label LabelFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		maxNodes := debugMaxNodes
+		if m := r.URL.Query().Get("max"); m != "" {
+			var err error
+			if maxNodes, err = strconv.Atoi(m); err != nil || maxNodes < 0 {
+				http.Error(w, fmt.Sprintf("bad max %q", m), http.StatusBadRequest)
+				return
+			}
+		}
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		switch format := r.URL.Query().Get("format"); format {
+		case "dot":
+			w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+			s.t.WriteDOT(w, label)
+		case "", "html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			writeDebugPage(w, s.t, label, maxNodes)
+		default:
+			http.Error(w, fmt.Sprintf("bad format %q, want html or dot", format),
+				http.StatusBadRequest)
+		}
+	})
+}
+
+// writeDebugPage writes the HTML page of `DebugHandler()`.
+func writeDebugPage(w http.ResponseWriter, b *BTree, label LabelFunc, maxNodes int) {
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	fmt.Fprint(bw, "<!DOCTYPE html>\n<html><head><title>btree</title><style>\n"+
+		"body { font-family: monospace; }\n"+
+		"details, .leaf { margin-left: 1.5em; }\n"+
+		"td { padding-right: 1em; }\n"+
+		"</style></head><body>\n")
+
+	shape := b.shapeStats()
+	fmt.Fprint(bw, "<h2>Shape</h2>\n<table>\n")
+	for _, row := range [][2]interface{}{
+		{"nodes", shape.Nodes},
+		{"marked deleted", len(b.tombstones)},
+		{"height", shape.Height},
+		{"leaves", shape.Leaves},
+		{"min leaf depth", shape.MinLeafDepth},
+		{"avg leaf depth", fmt.Sprintf("%.2f", shape.AvgLeafDepth)},
+		{"balance", fmt.Sprintf("%.2f", shape.Balance)},
+		{"max imbalance", shape.MaxImbalance},
+	} {
+		fmt.Fprintf(bw, "<tr><td>%v</td><td>%v</td></tr>\n", row[0], row[1])
+	}
+	fmt.Fprint(bw, "</table>\n")
+
+	if b.stats != nil {
+		st := b.Stats()
+		fmt.Fprintf(bw, "<h2>Stats</h2>\n<table>\n"+
+			"<tr><td>comparisons</td><td>%v</td></tr>\n"+
+			"<tr><td>visited</td><td>%v</td></tr>\n"+
+			"<tr><td>max depth</td><td>%v</td></tr>\n"+
+			"<tr><td>rotations</td><td>%v</td></tr>\n</table>\n",
+			st.Comparisons, st.Visited, st.MaxDepth, st.Rotations)
+	}
+
+	if b.metrics != nil {
+		ops := b.metrics.Ops()
+		names := make([]string, 0, len(ops))
+		for op := range ops {
+			names = append(names, op)
+		}
+		sort.Strings(names)
+		fmt.Fprint(bw, "<h2>Operations</h2>\n<table>\n"+
+			"<tr><th>method</th><th>count</th><th>total</th><th>max</th></tr>\n")
+		for _, op := range names {
+			om := ops[op]
+			fmt.Fprintf(bw, "<tr><td>%v</td><td>%v</td><td>%v</td><td>%v</td></tr>\n",
+				op, om.Count, om.Total, om.Max)
+		}
+		fmt.Fprint(bw, "</table>\n")
+	}
+
+	fmt.Fprint(bw, "<h2>Tree</h2>\n")
+	shown := 0
+	writeDebugNode(bw, b, b.Root, "", label, 0, maxNodes, &shown)
+	if shown < shape.Nodes {
+		fmt.Fprintf(bw, "<p>%d more nodes are not shown; see ?max=</p>\n", shape.Nodes-shown)
+	}
+	fmt.Fprint(bw, "</body></html>\n")
+}
+
+// writeDebugNode writes the subtree under `n`, at the given depth, as nested `<details>`; the top
+// levels are expanded. `side` is "L" or "R" for a sub-node. It stops when `shown` reaches
+// `maxNodes`.
+func writeDebugNode(bw *bufio.Writer, b *BTree, n *Node, side string, label LabelFunc, depth,
+	maxNodes int, shown *int) {
+	if n == nil || *shown >= maxNodes {
+		return
+	}
+	*shown++
+	text := html.EscapeString(label(n))
+	if side != "" {
+		text = side + ": " + text
+	}
+	if b.dead(n) {
+		text = "<s>" + text + "</s>"
+	}
+	if n.Left == nil && n.Right == nil {
+		fmt.Fprintf(bw, "<div class=\"leaf\">%s</div>\n", text)
+		return
+	}
+	open := ""
+	if depth < 3 {
+		open = " open"
+	}
+	fmt.Fprintf(bw, "<details%s><summary>%s</summary>\n", open, text)
+	writeDebugNode(bw, b, n.Left, "L", label, depth+1, maxNodes, shown)
+	writeDebugNode(bw, b, n.Right, "R", label, depth+1, maxNodes, shown)
+	fmt.Fprint(bw, "</details>\n")
+}
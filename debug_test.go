@@ -0,0 +1,60 @@
+package btree
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	b := newIntTree(2, 1, 3, 4)
+	b.EnableStats()
+	b.EnableMetrics()
+	b.LazyDelete = true
+	b.Delete(&Node{Payload: 3})
+	srv := httptest.NewServer(Synchronized(b).DebugHandler(intLabel))
+	defer srv.Close()
+
+	get := func(query string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	code, page := get("")
+	for _, want := range []string{
+		"<tr><td>nodes</td><td>4</td></tr>",
+		"<tr><td>marked deleted</td><td>1</td></tr>",
+		"<tr><td>height</td><td>3</td></tr>",
+		"<tr><td>comparisons</td>",
+		"<tr><td>Delete</td><td>1</td>",
+		"<details open><summary>2</summary>\n<div class=\"leaf\">L: 1</div>\n" +
+			"<details open><summary><s>R: 3</s></summary>\n<div class=\"leaf\">R: 4</div>\n",
+	} {
+		if code != http.StatusOK || !strings.Contains(page, want) {
+			t.Errorf("GET: %v, page without %q:\n%v", code, want, page)
+		}
+	}
+	if _, page := get("?max=2"); !strings.Contains(page, "2 more nodes are not shown") {
+		t.Errorf("GET ?max=2: page without the note about the hidden nodes:\n%v", page)
+	}
+	if code, dot := get("?format=dot"); code != http.StatusOK ||
+		!strings.HasPrefix(dot, "digraph btree {") {
+		t.Errorf("GET ?format=dot = %v, %q, want a DOT graph", code, dot)
+	}
+	for _, query := range []string{"?format=svg", "?max=x"} {
+		if code, _ := get(query); code != http.StatusBadRequest {
+			t.Errorf("GET %v = %v, want %v", query, code, http.StatusBadRequest)
+		}
+	}
+}
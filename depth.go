@@ -0,0 +1,48 @@
+package btree
+
+import "errors"
+
+// ErrTooDeep is returned by `TryUpsert()` for a node that would be linked in deeper than
+// `MaxDepth`.
+var ErrTooDeep = errors.New("btree: the node would be deeper than MaxDepth")
+
+// TryUpsert is `Upsert()`, except that it returns `ErrTooDeep`, and leaves the tree unchanged,
+// when the node would be linked in deeper than `MaxDepth`. Nodes that are already in the tree are
+// found as usual. Unlike `Upsert()`, it never rebalances, so the caller decides what to do: e.g.
+// reject the input, or call `Rebalance()` and retry.
+func (b *BTree) TryUpsert(n *Node) (intree *Node, inserted bool, err error) {
+	defer b.beginWrite("TryUpsert")()
+	if b.MaxDepth > 0 {
+		if found, path := b.rootPath(n); found == nil && path+1 > b.MaxDepth {
+			return nil, false, ErrTooDeep
+		}
+	}
+	intree, inserted = b.upsertChecked(n)
+	return intree, inserted, nil
+}
+
+// insertedDepth returns the depth of `intree`, which was just linked in by `upsert()`.
+func (b *BTree) insertedDepth(intree *Node) int {
+	if b.UseFinger {
+		// The descent started at the finger, so `path` doesn't count from the root.
+		_, path := b.rootPath(intree)
+		return path
+	}
+	return b.path + 1
+}
+
+// rootPath is `lookupPath()`, but always from the root rather than from the finger.
+func (b *BTree) rootPath(n *Node) (intree *Node, path int) {
+	for from := b.Root; from != nil; {
+		path++
+		switch {
+		case b.Less(n, from):
+			from = from.Left
+		case b.Less(from, n):
+			from = from.Right
+		default:
+			return from, path
+		}
+	}
+	return nil, path
+}
@@ -0,0 +1,47 @@
+package btree
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMaxDepthRebalances(t *testing.T) {
+	for _, finger := range []bool{false, true} {
+		b := New(intLess)
+		b.MaxDepth = 12
+		b.UseFinger = finger
+		for v := 0; v < 1000; v++ {
+			b.Upsert(&Node{Payload: v}) // sorted, so without the cap a chain
+			if h := b.ShapeStats().Height; h > b.MaxDepth {
+				t.Fatalf("UseFinger=%v: height after upserting %v = %v, want at most %v",
+					finger, v, h, b.MaxDepth)
+			}
+		}
+		if err := b.Verify(); err != nil {
+			t.Errorf("UseFinger=%v: Verify() = %v", finger, err)
+		}
+		if got := b.ShapeStats().Nodes; got != 1000 {
+			t.Errorf("UseFinger=%v: %v nodes, want 1000", finger, got)
+		}
+	}
+}
+
+func TestTryUpsert(t *testing.T) {
+	b := newIntTree(1, 2, 3)
+	b.MaxDepth = 3
+	if _, _, err := b.TryUpsert(&Node{Payload: 4}); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("TryUpsert(4) = %v, want ErrTooDeep", err)
+	}
+	if intree, inserted, err := b.TryUpsert(&Node{Payload: 3}); err != nil || inserted ||
+		intree.Payload != 3 {
+		t.Errorf("TryUpsert(3) = %v, %v, %v, want the node in the tree", intree, inserted, err)
+	}
+	if got, want := inOrderInts(b), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after a refused TryUpsert(): %v, want %v", got, want)
+	}
+	b.Rebalance()
+	if _, inserted, err := b.TryUpsert(&Node{Payload: 4}); err != nil || !inserted {
+		t.Errorf("TryUpsert(4) after Rebalance() = %v, %v, want it inserted", inserted, err)
+	}
+}
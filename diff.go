@@ -0,0 +1,50 @@
+package btree
+
+// Change describes a node that is present in two trees, but with a different payload.
+type Change struct {
+	// From is the node in the original tree, To is the node in the other tree.
+	From, To *Node
+}
+
+// Diff describes how two trees differ. All slices are in order of the trees' `LessFunc`.
+type Diff struct {
+	// Added holds the nodes that are only in the other tree.
+	Added []*Node
+	// Removed holds the nodes that are only in the original tree.
+	Removed []*Node
+	// Changed holds the nodes that are in both trees, but with different payloads.
+	Changed []Change
+}
+
+// Empty returns `true` when the diff holds no differences.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares the tree with `other` and reports the differences, as seen from the tree: what
+// would have to be added, removed or changed to get at `other`. Nodes are matched by the
+// `LessFunc` of the tree; matching nodes are compared using `cmpPayload`, which must return `true`
+// when the payloads are equal. Both trees are walked side by side, taking O(n+m) steps.
+func (b *BTree) Diff(other *BTree, cmpPayload EqualFunc) Diff {
+	var d Diff
+	var mine, theirs inorderIter
+	mine.init(b)
+	theirs.init(other)
+	n, o := mine.next(), theirs.next()
+	for n != nil || o != nil {
+		switch {
+		case o == nil || (n != nil && b.Less(n, o)):
+			d.Removed = append(d.Removed, n)
+			n = mine.next()
+		case n == nil || b.Less(o, n):
+			d.Added = append(d.Added, o)
+			o = theirs.next()
+		default:
+			if !cmpPayload(n, o) {
+				d.Changed = append(d.Changed, Change{From: n, To: o})
+			}
+			n, o = mine.next(), theirs.next()
+		}
+	}
+	return d
+}
@@ -0,0 +1,53 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+)
+
+type kv struct {
+	key, val string
+}
+
+func kvLess(a, b *Node) bool {
+	return a.Payload.(kv).key < b.Payload.(kv).key
+}
+
+func kvEqual(a, b *Node) bool {
+	return a.Payload.(kv) == b.Payload.(kv)
+}
+
+func newKVTree(pairs ...string) *BTree {
+	b := New(kvLess)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		b.Upsert(&Node{Payload: kv{key: pairs[i], val: pairs[i+1]}})
+	}
+	return b
+}
+
+func keys(nodes []*Node) []string {
+	out := []string{}
+	for _, n := range nodes {
+		out = append(out, n.Payload.(kv).key)
+	}
+	return out
+}
+
+func TestDiff(t *testing.T) {
+	a := newKVTree("a", "1", "b", "2", "c", "3", "e", "5")
+	b := newKVTree("b", "2", "c", "30", "d", "4", "f", "6")
+
+	d := a.Diff(b, kvEqual)
+	if got, want := keys(d.Added), []string{"d", "f"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff().Added = %v, want %v", got, want)
+	}
+	if got, want := keys(d.Removed), []string{"a", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff().Removed = %v, want %v", got, want)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].From.Payload.(kv).val != "3" || d.Changed[0].To.Payload.(kv).val != "30" {
+		t.Errorf("Diff().Changed = %v, want c: 3 -> 30", d.Changed)
+	}
+	if !a.Diff(a.Clone(nil), kvEqual).Empty() {
+		t.Errorf("Diff() of a tree and its clone is not empty")
+	}
+}
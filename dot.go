@@ -0,0 +1,46 @@
+package btree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// LabelFunc is supplied by the caller of output functions such as `WriteDOT()`. It returns the
+// text to show for a node.
+type LabelFunc func(n *Node) string
+
+// WriteDOT writes the tree to `w` as a Graphviz DOT graph, using `label` to get the text of each
+// node. When a node has only one sub-node, the missing one is drawn as a point, so that left and
+// right can be told apart. The output can be rendered using e.g. `dot -Tpng`.
+func (b *BTree) WriteDOT(w io.Writer, label LabelFunc) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph btree {")
+	fmt.Fprintln(bw, "  node [shape=box];")
+	if b.Root != nil {
+		id := 0
+		writeDOTFrom(bw, b.Root, label, &id)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// writeDOTFrom writes the node `n`, which gets the identifier `*id`, and its sub-nodes.
+func writeDOTFrom(w io.Writer, n *Node, label LabelFunc, id *int) {
+	me := *id
+	fmt.Fprintf(w, "  n%d [label=%s];\n", me, strconv.Quote(label(n)))
+	if n.Left == nil && n.Right == nil {
+		return
+	}
+	for _, sub := range []*Node{n.Left, n.Right} {
+		*id++
+		if sub == nil {
+			fmt.Fprintf(w, "  n%d [shape=point];\n", *id)
+			fmt.Fprintf(w, "  n%d -> n%d;\n", me, *id)
+			continue
+		}
+		fmt.Fprintf(w, "  n%d -> n%d;\n", me, *id)
+		writeDOTFrom(w, sub, label, id)
+	}
+}
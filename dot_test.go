@@ -0,0 +1,47 @@
+package btree
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func intLabel(n *Node) string {
+	return strconv.Itoa(n.Payload.(int))
+}
+
+func TestWriteDOT(t *testing.T) {
+	for _, test := range []struct {
+		vals []int
+		want string
+	}{
+		{
+			vals: nil,
+			want: "digraph btree {\n  node [shape=box];\n}\n",
+		},
+		{
+			vals: []int{2, 1, 3, 4},
+			want: `digraph btree {
+  node [shape=box];
+  n0 [label="2"];
+  n0 -> n1;
+  n1 [label="1"];
+  n0 -> n2;
+  n2 [label="3"];
+  n3 [shape=point];
+  n2 -> n3;
+  n2 -> n4;
+  n4 [label="4"];
+}
+`,
+		},
+	} {
+		var buf bytes.Buffer
+		if err := newIntTree(test.vals...).WriteDOT(&buf, intLabel); err != nil {
+			t.Fatalf("WriteDOT(%v) = %v", test.vals, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("WriteDOT(%v) = \n%s\nwant\n%s", test.vals, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package btree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dumpMaxDepth limits how deep `Dump()` descends into nested payloads, which also ends cycles.
+const dumpMaxDepth = 8
+
+// Dump writes a canonical text form of the tree to `w`, for golden-file tests of code that builds
+// trees. The same tree always gives the same text: it doesn't depend on pointer values or on the
+// order of map iteration. The first line gives the number of nodes and the height, and each
+// further line is a node in pre-order, prefixed by its path from the root: `.` for the root, and
+// e.g. `LR` for the right sub-node of its left sub-node. So the text shows the shape as well as
+// the contents:
+//
+//	btree: 3 nodes, height 2
+//	.   2
+//	L   1
+//	R   &{name:"Sponge Bob" count:2}
+//
+// Payloads are written like `fmt` does with `%+v`, except that strings are quoted, pointers are
+// followed rather than printed, map entries are sorted, functions and channels are only given by
+// their type, and times are in RFC 3339 format (unless they are in unexported fields).
+// Nodes that are marked deleted or have an expiry time are annotated.
+func (b *BTree) Dump(w io.Writer) error {
+	defer b.beginRead("Dump")()
+	shape := b.shapeStats()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "btree: %d nodes, height %d\n", shape.Nodes, shape.Height)
+	width := max(shape.Height, 1) + 2
+	var walk func(n *Node, path string)
+	walk = func(n *Node, path string) {
+		if n == nil {
+			return
+		}
+		shown := path
+		if shown == "" {
+			shown = "."
+		}
+		fmt.Fprintf(bw, "%-*s%s", width, shown, dumpValue(reflect.ValueOf(n.Payload), 0))
+		if b.dead(n) {
+			bw.WriteString(" (deleted)")
+		}
+		if at, ok := b.expiry[n]; ok {
+			fmt.Fprintf(bw, " (expires %v)", at.UTC().Format(time.RFC3339Nano))
+		}
+		bw.WriteString("\n")
+		walk(n.Left, path+"L")
+		walk(n.Right, path+"R")
+	}
+	walk(b.Root, "")
+	return bw.Flush()
+}
+
+// dumpValue returns the canonical text of `v`, which is nested `depth` levels deep.
+func dumpValue(v reflect.Value, depth int) string {
+	if !v.IsValid() {
+		return "<nil>"
+	}
+	if depth > dumpMaxDepth {
+		return "..."
+	}
+	if t, ok := reflectTime(v); ok {
+		// Not `String()`, which includes the monotonic clock reading.
+		return t.Format(time.RFC3339Nano)
+	}
+	if v.Type().Implements(reflect.TypeOf((*fmt.Stringer)(nil)).Elem()) &&
+		(v.Kind() != reflect.Pointer || !v.IsNil()) && v.CanInterface() {
+		return v.Interface().(fmt.Stringer).String()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return "<nil>"
+		}
+		if v.Kind() == reflect.Pointer {
+			return "&" + dumpValue(v.Elem(), depth+1)
+		}
+		return dumpValue(v.Elem(), depth)
+	case reflect.Struct:
+		fields := make([]string, v.NumField())
+		for i := range fields {
+			fields[i] = v.Type().Field(i).Name + ":" + dumpValue(v.Field(i), depth+1)
+		}
+		return "{" + strings.Join(fields, " ") + "}"
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return "[]"
+		}
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elems[i] = dumpValue(v.Index(i), depth+1)
+		}
+		return "[" + strings.Join(elems, " ") + "]"
+	case reflect.Map:
+		entries := make([]string, 0, v.Len())
+		for it := v.MapRange(); it.Next(); {
+			entries = append(entries, dumpValue(it.Key(), depth+1)+":"+dumpValue(it.Value(), depth+1))
+		}
+		sort.Strings(entries)
+		return "map[" + strings.Join(entries, " ") + "]"
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return v.Type().String()
+	case reflect.String:
+		return fmt.Sprintf("%q", v.String())
+	}
+	return fmt.Sprint(valueOf(v))
+}
+
+// reflectTime returns the `time.Time` in `v`, if it is one and can be read.
+func reflectTime(v reflect.Value) (time.Time, bool) {
+	if v.Type() != reflect.TypeOf(time.Time{}) || !v.CanInterface() {
+		return time.Time{}, false
+	}
+	return v.Interface().(time.Time), true
+}
+
+// valueOf returns the basic value in `v`, also when it is in an unexported field.
+func valueOf(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return v.Complex()
+	}
+	return v.Type().String()
+}
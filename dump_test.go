@@ -0,0 +1,67 @@
+package btree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDump(t *testing.T) {
+	b := newIntTree(4, 2, 6, 1, 3, 7)
+	b.LazyDelete = true
+	b.Delete(&Node{Payload: 3})
+	b.SetExpiry(&Node{Payload: 7}, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC))
+	var buf bytes.Buffer
+	if err := b.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `btree: 6 nodes, height 3
+.    4
+L    2
+LL   1
+LR   3 (deleted)
+R    6
+RR   7 (expires 2030-01-02T03:04:05Z)
+`
+	if buf.String() != want {
+		t.Errorf("Dump() =\n%v\nwant:\n%v", buf.String(), want)
+	}
+}
+
+func TestDumpPayloads(t *testing.T) {
+	type inner struct {
+		tags map[string]int
+	}
+	type payload struct {
+		Name  string
+		in    *inner
+		list  []float64
+		When  time.Time
+		print func()
+		empty *inner
+	}
+	dump := func() string {
+		b := New(func(a, b *Node) bool { return false })
+		b.Upsert(&Node{Payload: &payload{
+			Name: "Sponge Bob",
+			in:   &inner{tags: map[string]int{"z": 1, "a": 2, "m": 3}},
+			list: []float64{1.5, 2},
+			When: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		}})
+		var buf bytes.Buffer
+		b.Dump(&buf)
+		return buf.String()
+	}
+	got := dump()
+	want := `.  &{Name:"Sponge Bob" in:&{tags:map["a":2 "m":3 "z":1]} list:[1.5 2] ` +
+		`When:2030-01-02T03:04:05Z print:func() empty:<nil>}`
+	if !strings.Contains(got, want) {
+		t.Errorf("Dump() =\n%v\nwant a line:\n%v", got, want)
+	}
+	for i := 0; i < 10; i++ {
+		if again := dump(); again != got {
+			t.Fatalf("Dump() is not stable:\n%v\nvs.\n%v", got, again)
+		}
+	}
+}
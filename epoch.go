@@ -0,0 +1,57 @@
+package btree
+
+import "sync/atomic"
+
+// epochs implements epoch-based reclamation for `COWTree`: nodes that a mutation replaced are
+// only recycled after all readers that might still see them have finished.
+//
+// Readers announce themselves in the counter of the current phase (0 or 1). Retired nodes are
+// first pending; they become waiting when the phase is flipped. Readers that start after the flip
+// load a root from which the waiting nodes are unreachable, so once the counter of the old phase
+// drops to zero, nobody can see the waiting nodes and they are recycled. Readers never lock or
+// wait; writers don't wait either, they reclaim what they can on the next mutation.
+type epochs struct {
+	phase   atomic.Uint32
+	readers [2]atomic.Int64
+	// pending and waiting are guarded by the writer lock.
+	pending, waiting []*Node
+}
+
+// enter registers a reader and returns the phase to pass to `leave()`.
+func (e *epochs) enter() uint32 {
+	p := e.phase.Load()
+	e.readers[p].Add(1)
+	return p
+}
+
+func (e *epochs) leave(p uint32) {
+	e.readers[p].Add(-1)
+}
+
+// retire adds nodes that are no longer reachable from the root, and recycles what is safe. The
+// writer lock must be held.
+func (e *epochs) retire(nodes []*Node, pool *NodePool) {
+	e.pending = append(e.pending, nodes...)
+	p := e.phase.Load()
+	if len(e.waiting) > 0 && e.readers[1-p].Load() == 0 {
+		for i, n := range e.waiting {
+			pool.Put(n)
+			e.waiting[i] = nil
+		}
+		e.waiting = e.waiting[:0]
+	}
+	if len(e.waiting) == 0 && len(e.pending) > 0 {
+		e.waiting, e.pending = e.pending, e.waiting
+		e.phase.Store(1 - p)
+	}
+}
+
+// Read calls `fn` with the current state of the tree, like `Snapshot()` does. While `fn` runs,
+// the nodes that it can see are not recycled, even when the tree has a `Pool`. Readers don't
+// lock, so `fn` may use the `COWTree` itself. The tree that `fn` receives must not be modified,
+// nor used after `fn` returns.
+func (c *COWTree) Read(fn func(b *BTree)) {
+	p := c.epochs.enter()
+	defer c.epochs.leave(p)
+	fn(c.Snapshot())
+}
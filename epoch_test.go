@@ -0,0 +1,71 @@
+package btree
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCOWTreePool(t *testing.T) {
+	c := NewCOW(intLess)
+	c.Pool = NewNodePool()
+	for _, v := range []int{5, 3, 8, 1, 4, 7, 9} {
+		c.Upsert(&Node{Payload: v})
+	}
+	c.Replace(&Node{Payload: 4})
+	if removed, deleted := c.Delete(&Node{Payload: 5}); !deleted || removed.Payload != 5 {
+		t.Errorf("Delete(5) = %v, %v; want 5, true", removed, deleted)
+	}
+	c.Delete(&Node{Payload: 1})
+	if n, found := c.Find(&Node{Payload: 7}); !found || n.Payload != 7 {
+		t.Errorf("Find(7) = %v, %v; want 7, true", n, found)
+	}
+	// Without readers, at most the nodes of the last two mutations await recycling.
+	if n := len(c.pending) + len(c.waiting); n > 6 {
+		t.Errorf("%v nodes await recycling, want at most 6", n)
+	}
+	var got []int
+	c.Read(func(b *BTree) { got = inOrderInts(b) })
+	if want := []int{3, 4, 7, 8, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after mutations = %v, want %v", got, want)
+	}
+}
+
+func TestCOWTreePoolConcurrentReaders(t *testing.T) {
+	c := NewCOW(intLess)
+	c.Pool = NewNodePool()
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				c.Read(func(b *BTree) {
+					prev := -1
+					b.DepthFirstInOrder(func(n *Node) {
+						// A recycled node would have lost its payload.
+						v, ok := n.Payload.(int)
+						if !ok || v <= prev {
+							t.Errorf("reader sees %v after %v", n.Payload, prev)
+						}
+						prev = v
+					})
+				})
+			}
+		}()
+	}
+	for i := 0; i < 5000; i++ {
+		c.Upsert(&Node{Payload: (i * 7919) % 500})
+		if i%2 == 0 {
+			c.Delete(&Node{Payload: (i * 31) % 500})
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,40 @@
+package btree
+
+// EqualFunc compares the payloads of two nodes and returns `true` when they are considered equal.
+type EqualFunc func(a, b *Node) bool
+
+// Equal returns `true` when `b` and `other` hold the same nodes in the same order, as determined
+// by `eq`. The shape of the trees is irrelevant: two trees that were filled in a different order
+// are still equal when their in-order contents match.
+func (b *BTree) Equal(other *BTree, eq EqualFunc) bool {
+	var ia, ib inorderIter
+	ia.init(b)
+	ib.init(other)
+	for {
+		na, nb := ia.next(), ib.next()
+		if na == nil || nb == nil {
+			return na == nil && nb == nil
+		}
+		if !eq(na, nb) {
+			return false
+		}
+	}
+}
+
+// StructurallyEqual returns `true` when `b` and `other` have the same shape, i.e., every node in
+// the one tree has a counterpart at the same position in the other tree. When `eq` is not `nil`,
+// then the counterparts must furthermore be equal according to `eq`.
+func (b *BTree) StructurallyEqual(other *BTree, eq EqualFunc) bool {
+	return structurallyEqualFrom(b.Root, other.Root, eq)
+}
+
+func structurallyEqualFrom(a, b *Node, eq EqualFunc) bool {
+	switch {
+	case a == nil || b == nil:
+		return a == nil && b == nil
+	case eq != nil && !eq(a, b):
+		return false
+	default:
+		return structurallyEqualFrom(a.Left, b.Left, eq) && structurallyEqualFrom(a.Right, b.Right, eq)
+	}
+}
@@ -0,0 +1,42 @@
+package btree
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	for _, test := range []struct {
+		a, b []int
+		want bool
+	}{
+		{a: nil, b: nil, want: true},
+		{a: []int{1}, b: nil, want: false},
+		{a: []int{2, 1, 3}, b: []int{1, 2, 3}, want: true},
+		{a: []int{2, 1, 3}, b: []int{1, 2}, want: false},
+		{a: []int{2, 1, 3}, b: []int{1, 2, 4}, want: false},
+	} {
+		a, b := newIntTree(test.a...), newIntTree(test.b...)
+		if got := a.Equal(b, intEqual); got != test.want {
+			t.Errorf("%v.Equal(%v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+		if got := b.Equal(a, intEqual); got != test.want {
+			t.Errorf("%v.Equal(%v) = %v, want %v", test.b, test.a, got, test.want)
+		}
+	}
+}
+
+func TestStructurallyEqual(t *testing.T) {
+	for _, test := range []struct {
+		a, b []int
+		eq   EqualFunc
+		want bool
+	}{
+		{a: []int{2, 1, 3}, b: []int{2, 1, 3}, eq: intEqual, want: true},
+		{a: []int{2, 1, 3}, b: []int{1, 2, 3}, eq: intEqual, want: false},
+		{a: []int{2, 1, 3}, b: []int{5, 4, 6}, eq: nil, want: true},
+		{a: []int{2, 1, 3}, b: []int{5, 4, 6}, eq: intEqual, want: false},
+	} {
+		a, b := newIntTree(test.a...), newIntTree(test.b...)
+		if got := a.StructurallyEqual(b, test.eq); got != test.want {
+			t.Errorf("%v.StructurallyEqual(%v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
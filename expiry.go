@@ -0,0 +1,121 @@
+package btree
+
+import "time"
+
+// now returns the current time, per `Now`.
+func (b *BTree) now() time.Time {
+	if b.Now != nil {
+		return b.Now()
+	}
+	return time.Now()
+}
+
+// expired returns `true` when `n` has an expiry time that has passed.
+func (b *BTree) expired(n *Node) bool {
+	if len(b.expiry) == 0 {
+		return false
+	}
+	at, ok := b.expiry[n]
+	return ok && at.Before(b.now())
+}
+
+// hidden returns `true` when lookups and traversals skip `n`: it is marked deleted, or has
+// expired.
+func (b *BTree) hidden(n *Node) bool {
+	return b.dead(n) || b.expired(n)
+}
+
+// revive reuses `n`, which is marked deleted or has expired, for a new payload. It returns
+// `false` when `n` is a live node, which is left alone.
+func (b *BTree) revive(n *Node, payload interface{}) bool {
+	if !b.dead(n) && !b.expired(n) {
+		return false
+	}
+	if !b.dead(n) {
+		b.removed(n) // the expired node
+	}
+	delete(b.tombstones, n)
+	delete(b.expiry, n)
+	n.Payload = payload
+	b.added(n)
+	return true
+}
+
+// UpsertWithExpiry is `Upsert()`, which also sets the expiry time of the node in the tree, see
+// `SetExpiry()`.
+func (b *BTree) UpsertWithExpiry(n *Node, at time.Time) (intree *Node, inserted bool) {
+	defer b.beginWrite("UpsertWithExpiry")()
+	intree, inserted = b.upsertCapped(n)
+	b.setExpiry(intree, at)
+	return intree, inserted
+}
+
+// SetExpiry sets the time at which the node like `n` expires; the zero time means never. Expired
+// nodes aren't found by `Find()`, nor visited by `DepthFirstInOrder()`, `AscendRange()`, `Min()`,
+// a `Walker` and the like, nor seen by `Equal()`, `Diff()`, `Merge()`, `EmitSorted()` and the
+// other in-order comparisons and exports; `Upsert()` and `BulkUpsert()` replace them as if they
+// were absent, and `Delete()` and `ExpireBefore()` remove them. Until then they are still linked
+// into the tree, and structural operations, such as `Clone()` or serialization, still see them;
+// call `ExpireBefore()` with the current time first to remove them. The return value is `false`
+// when there is no such node.
+func (b *BTree) SetExpiry(n *Node, at time.Time) bool {
+	defer b.beginWrite("SetExpiry")()
+	intree := b.lookup(n)
+	if intree == nil || b.dead(intree) || b.expired(intree) {
+		return false
+	}
+	b.setExpiry(intree, at)
+	return true
+}
+
+func (b *BTree) setExpiry(n *Node, at time.Time) {
+	if at.IsZero() {
+		delete(b.expiry, n)
+		return
+	}
+	if b.expiry == nil {
+		b.expiry = map[*Node]time.Time{}
+	}
+	b.expiry[n] = at
+}
+
+// Expiry returns the time at which the node like `n` expires. The return value `ok` is `false`
+// when there is no such node, or when it doesn't expire.
+func (b *BTree) Expiry(n *Node) (at time.Time, ok bool) {
+	defer b.beginRead("Expiry")()
+	intree := b.lookup(n)
+	if intree == nil || b.dead(intree) || b.expired(intree) {
+		return time.Time{}, false
+	}
+	at, ok = b.expiry[intree]
+	return at, ok
+}
+
+// ExpireBefore removes the nodes that expire before `t`, and returns how many there were. When
+// the tree has a `Pool`, the removed nodes are returned to it.
+func (b *BTree) ExpireBefore(t time.Time) int {
+	defer b.beginWrite("ExpireBefore")()
+	removed := 0
+	for n, at := range b.expiry {
+		if !at.Before(t) {
+			continue
+		}
+		delete(b.expiry, n)
+		if b.lookup(n) != n {
+			// Another, equal node took the place of `n`.
+			continue
+		}
+		b.ResetFinger()
+		b.Root, _ = b.deleteFrom(b.Root, n, 0)
+		if !b.dead(n) {
+			removed++
+			b.removed(n)
+		}
+		delete(b.tombstones, n)
+		if b.Pool != nil {
+			b.Pool.put(n)
+		}
+	}
+	b.logRemoved("ExpireBefore", removed)
+	return removed
+}
@@ -0,0 +1,196 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for `BTree.Now` that only moves when told.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func TestExpiry(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	b := newIntTree(50, 20, 80)
+	b.Now = clock.now
+	b.UpsertWithExpiry(&Node{Payload: 10}, clock.t.Add(time.Second))
+	b.UpsertWithExpiry(&Node{Payload: 30}, clock.t.Add(2*time.Second))
+	if !b.SetExpiry(&Node{Payload: 80}, clock.t.Add(3*time.Second)) {
+		t.Fatalf("SetExpiry(80) = false, want true")
+	}
+	if b.SetExpiry(&Node{Payload: 99}, clock.t) {
+		t.Errorf("SetExpiry(99) of an absent node = true, want false")
+	}
+	if at, ok := b.Expiry(&Node{Payload: 30}); !ok || !at.Equal(clock.t.Add(2*time.Second)) {
+		t.Errorf("Expiry(30) = %v, %v, want %v, true", at, ok, clock.t.Add(2*time.Second))
+	}
+	if _, ok := b.Expiry(&Node{Payload: 50}); ok {
+		t.Errorf("Expiry(50) of a node without expiry = true, want false")
+	}
+
+	clock.t = clock.t.Add(1500 * time.Millisecond)
+	if _, found := b.Find(&Node{Payload: 10}); found {
+		t.Errorf("Find(10) after its expiry = true, want false")
+	}
+	if _, found := b.Find(&Node{Payload: 30}); !found {
+		t.Errorf("Find(30) before its expiry = false, want true")
+	}
+	// Traversals skip the expired node, which is still linked in until it is purged.
+	if got, want := inOrderInts(b), []int{20, 30, 50, 80}; !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order before ExpireBefore() = %v, want %v", got, want)
+	}
+	ascended := []int{}
+	b.AscendRange(nil, nil, func(n *Node) bool {
+		ascended = append(ascended, n.Payload.(int))
+		return true
+	})
+	if want := []int{20, 30, 50, 80}; !reflect.DeepEqual(ascended, want) {
+		t.Errorf("AscendRange() before ExpireBefore() = %v, want %v", ascended, want)
+	}
+	if got := b.Min().Payload; got != 20 {
+		t.Errorf("Min() before ExpireBefore() = %v, want 20", got)
+	}
+	walked := []int{}
+	NewWalker(b).DepthFirstReverse(b, func(n *Node) { walked = append(walked, n.Payload.(int)) })
+	if want := []int{80, 50, 30, 20}; !reflect.DeepEqual(walked, want) {
+		t.Errorf("Walker before ExpireBefore() = %v, want %v", walked, want)
+	}
+	if got := b.ShapeStats().Nodes; got != 5 {
+		t.Errorf("ShapeStats().Nodes before ExpireBefore() = %v, want 5", got)
+	}
+	if got := b.ExpireBefore(clock.t); got != 1 {
+		t.Errorf("ExpireBefore() = %v, want 1", got)
+	}
+	if got, want := inOrderInts(b), []int{20, 30, 50, 80}; !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order after ExpireBefore() = %v, want %v", got, want)
+	}
+
+	// A later expiry may be cleared before it passes.
+	b.SetExpiry(&Node{Payload: 80}, time.Time{})
+	clock.t = clock.t.Add(time.Hour)
+	if _, found := b.Find(&Node{Payload: 80}); !found {
+		t.Errorf("Find(80) after clearing its expiry = false, want true")
+	}
+	if got := b.ExpireBefore(clock.t); got != 1 {
+		t.Errorf("ExpireBefore() = %v, want 1", got)
+	}
+	if got, want := inOrderInts(b), []int{20, 50, 80}; !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order after ExpireBefore() = %v, want %v", got, want)
+	}
+}
+
+func TestUpsertWithExpiryMaxDepth(t *testing.T) {
+	b := New(intLess)
+	b.MaxDepth = 10
+	at := time.Now().Add(time.Hour)
+	for i := 0; i < 1000; i++ {
+		b.UpsertWithExpiry(&Node{Payload: i}, at)
+	}
+	if h := b.ShapeStats().Height; h > b.MaxDepth {
+		t.Errorf("height after 1000 upserts = %v, want at most MaxDepth %v", h, b.MaxDepth)
+	}
+	if got, ok := b.Expiry(&Node{Payload: 500}); !ok || !got.Equal(at) {
+		t.Errorf("Expiry(500) = %v, %v; want %v", got, ok, at)
+	}
+}
+
+func TestExpiredNodesAreReplaced(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		clock := &fakeClock{t: time.Unix(1000, 0)}
+		b := New(intLess)
+		b.Now = clock.now
+		b.LazyDelete = lazy
+		b.UpsertWithExpiry(&Node{Payload: 1}, clock.t.Add(time.Second))
+		b.UpsertWithExpiry(&Node{Payload: 2}, clock.t.Add(time.Second))
+		clock.t = clock.t.Add(time.Minute)
+
+		if _, inserted := b.Upsert(&Node{Payload: 1}); !inserted {
+			t.Errorf("lazy=%v: Upsert() of an expired node = false, want true", lazy)
+		}
+		if _, ok := b.Expiry(&Node{Payload: 1}); ok {
+			t.Errorf("lazy=%v: Expiry() of a replaced node = true, want false", lazy)
+		}
+		if _, found := b.Find(&Node{Payload: 1}); !found {
+			t.Errorf("lazy=%v: Find() of a replaced node = false, want true", lazy)
+		}
+		if _, deleted := b.Delete(&Node{Payload: 2}); deleted {
+			t.Errorf("lazy=%v: Delete() of an expired node = true, want false", lazy)
+		}
+		if got := b.ExpireBefore(clock.t); got != 0 {
+			t.Errorf("lazy=%v: ExpireBefore() = %v, want 0", lazy, got)
+		}
+		b.Compact()
+		if got, want := inOrderInts(b), []int{1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("lazy=%v: in-order = %v, want %v", lazy, got, want)
+		}
+	}
+}
+
+func TestExpireBefore(t *testing.T) {
+	b := New(intLess)
+	at := time.Unix(1000, 0)
+	b.Now = func() time.Time { return at.Add(5 * time.Second) }
+	for _, v := range []int{5, 2, 8, 0, 3, 7, 9, 1, 4, 6} {
+		b.UpsertWithExpiry(&Node{Payload: v}, at.Add(time.Duration(v)*time.Second))
+	}
+	if got := b.ExpireBefore(at.Add(5 * time.Second)); got != 5 {
+		t.Errorf("ExpireBefore() = %v, want 5", got)
+	}
+	if got, want := inOrderInts(b), []int{5, 6, 7, 8, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order = %v, want %v", got, want)
+	}
+	if got := b.ExpireBefore(at.Add(5 * time.Second)); got != 0 {
+		t.Errorf("ExpireBefore() again = %v, want 0", got)
+	}
+}
+
+func TestExpiredNodesAreNotCompared(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	b := newIntTree(2, 1)
+	b.Now = clock.now
+	b.UpsertWithExpiry(&Node{Payload: 3}, clock.t.Add(time.Second))
+	clock.t = clock.t.Add(time.Minute)
+	other := newIntTree(2, 1)
+	eq := func(x, y *Node) bool { return x.Payload == y.Payload }
+
+	if !b.Equal(other, eq) {
+		t.Errorf("Equal() of {1, 2, expired 3} and {1, 2} = false, want true")
+	}
+	if !b.IsSubsetOf(other) {
+		t.Errorf("IsSubsetOf() of {1, 2, expired 3} and {1, 2} = false, want true")
+	}
+	if d := b.Diff(other, eq); !d.Empty() {
+		t.Errorf("Diff() of {1, 2, expired 3} and {1, 2} = %+v, want empty", d)
+	}
+	if got, want := inOrderInts(Merge(nil, b)), []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() of {1, 2, expired 3} = %v, want %v", got, want)
+	}
+}
+
+func TestBulkUpsertReplacesExpiredNodes(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	b := newIntTree(2, 1, 4)
+	b.Now = clock.now
+	b.UpsertWithExpiry(&Node{Payload: 3}, clock.t.Add(time.Second))
+	clock.t = clock.t.Add(time.Minute)
+
+	b.BulkUpsert(intNodes(0, 3, 5))
+	if _, found := b.Find(&Node{Payload: 3}); !found {
+		t.Errorf("Find(3) after the BulkUpsert() of an expired node = false, want true")
+	}
+	if _, ok := b.Expiry(&Node{Payload: 3}); ok {
+		t.Errorf("Expiry(3) after the BulkUpsert() of an expired node = true, want false")
+	}
+	if got, want := inOrderInts(b), []int{0, 1, 2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order after BulkUpsert() = %v, want %v", got, want)
+	}
+	if got := b.ExpireBefore(clock.t); got != 0 {
+		t.Errorf("ExpireBefore() after BulkUpsert() = %v, want 0", got)
+	}
+}
@@ -0,0 +1,315 @@
+// Package expr builds, prints and evaluates expression trees: binary trees of `btree.Node`s in
+// which the leaves hold operands and the other nodes hold the operators that combine the values of
+// their `Left` and `Right` sub-nodes. E.g., "2 * (x + 1)" becomes:
+//
+//	  *
+//	 / \
+//	2   +
+//	   / \
+//	  x   1
+//
+// The payload of an operator node is an `Op`, of a variable a `Var`, and of a number a `float64`.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/KarelKubat/btree"
+)
+
+// Op is the payload of an operator node: one of "+", "-", "*", "/" or "^" (exponentiation).
+type Op string
+
+// Var is the payload of a variable, whose value is looked up when evaluating.
+type Var string
+
+// precedence returns the binding strength of `op`, or 0 when it is not an operator.
+func precedence(op Op) int {
+	switch op {
+	case "+", "-":
+		return 1
+	case "*", "/":
+		return 2
+	case "^":
+		return 3
+	}
+	return 0
+}
+
+// rightAssoc returns `true` for operators that group to the right, as in 2^3^2 = 2^(3^2).
+func rightAssoc(op Op) bool {
+	return op == "^"
+}
+
+// Tokenize splits `s` into tokens: numbers, names, operators and parentheses.
+func Tokenize(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.ContainsRune("+-*/^()", c):
+			tokens = append(tokens, string(c))
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.') {
+				j++
+			}
+			tokens, i = append(tokens, s[i:j]), j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_') {
+				j++
+			}
+			tokens, i = append(tokens, s[i:j]), j
+		default:
+			return nil, fmt.Errorf("expr: unexpected %q at offset %d", c, i)
+		}
+	}
+	return tokens, nil
+}
+
+// leaf returns the node for the operand `token`.
+func leaf(token string) (*btree.Node, error) {
+	if f, err := strconv.ParseFloat(token, 64); err == nil {
+		return &btree.Node{Payload: f}, nil
+	}
+	if token == "" {
+		return nil, errors.New("expr: empty operand")
+	}
+	for i, c := range token {
+		if !(unicode.IsLetter(c) || c == '_' || (i > 0 && unicode.IsDigit(c))) {
+			return nil, fmt.Errorf("expr: bad operand %q", token)
+		}
+	}
+	return &btree.Node{Payload: Var(token)}, nil
+}
+
+// Parse builds the tree of the infix expression in `tokens` (see `Tokenize()`). The usual
+// precedence applies: "^" binds strongest and groups to the right, then "*" and "/", then "+"
+// and "-"; parentheses group.
+func Parse(tokens []string) (*btree.Node, error) {
+	// The shunting-yard algorithm, with a stack of built subtrees instead of an output queue.
+	var operands []*btree.Node
+	var operators []string
+	reduce := func() error {
+		op := operators[len(operators)-1]
+		operators = operators[:len(operators)-1]
+		if len(operands) < 2 {
+			return fmt.Errorf("expr: missing operand for %q", op)
+		}
+		l, r := operands[len(operands)-2], operands[len(operands)-1]
+		operands = append(operands[:len(operands)-2], &btree.Node{Payload: Op(op), Left: l, Right: r})
+		return nil
+	}
+	expectOperand := true
+	for _, tok := range tokens {
+		switch {
+		case tok == "(":
+			if !expectOperand {
+				return nil, errors.New("expr: unexpected \"(\"")
+			}
+			operators = append(operators, tok)
+		case tok == ")":
+			if expectOperand {
+				return nil, errors.New("expr: unexpected \")\"")
+			}
+			for len(operators) > 0 && operators[len(operators)-1] != "(" {
+				if err := reduce(); err != nil {
+					return nil, err
+				}
+			}
+			if len(operators) == 0 {
+				return nil, errors.New("expr: unbalanced \")\"")
+			}
+			operators = operators[:len(operators)-1]
+		case precedence(Op(tok)) > 0:
+			if expectOperand {
+				return nil, fmt.Errorf("expr: missing operand before %q", tok)
+			}
+			op := Op(tok)
+			for len(operators) > 0 {
+				top := Op(operators[len(operators)-1])
+				if precedence(top) < precedence(op) || (precedence(top) == precedence(op) && rightAssoc(op)) {
+					break
+				}
+				if err := reduce(); err != nil {
+					return nil, err
+				}
+			}
+			operators = append(operators, tok)
+			expectOperand = true
+		default:
+			if !expectOperand {
+				return nil, fmt.Errorf("expr: missing operator before %q", tok)
+			}
+			n, err := leaf(tok)
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, n)
+			expectOperand = false
+		}
+	}
+	for len(operators) > 0 {
+		if operators[len(operators)-1] == "(" {
+			return nil, errors.New("expr: unbalanced \"(\"")
+		}
+		if err := reduce(); err != nil {
+			return nil, err
+		}
+	}
+	if len(operands) != 1 || expectOperand {
+		return nil, errors.New("expr: incomplete expression")
+	}
+	return operands[0], nil
+}
+
+// ParsePostfix builds the tree of the postfix (reverse Polish) expression in `tokens`, e.g.
+// "2 x 1 + *".
+func ParsePostfix(tokens []string) (*btree.Node, error) {
+	var stack []*btree.Node
+	for _, tok := range tokens {
+		if precedence(Op(tok)) == 0 {
+			n, err := leaf(tok)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, n)
+			continue
+		}
+		if len(stack) < 2 {
+			return nil, fmt.Errorf("expr: missing operand for %q", tok)
+		}
+		l, r := stack[len(stack)-2], stack[len(stack)-1]
+		stack = append(stack[:len(stack)-2], &btree.Node{Payload: Op(tok), Left: l, Right: r})
+	}
+	if len(stack) != 1 {
+		return nil, errors.New("expr: incomplete expression")
+	}
+	return stack[0], nil
+}
+
+// Eval returns the value of the expression under `n`. Variables are looked up in `vars`.
+func Eval(n *btree.Node, vars map[string]float64) (float64, error) {
+	switch p := n.Payload.(type) {
+	case float64:
+		return p, nil
+	case Var:
+		v, ok := vars[string(p)]
+		if !ok {
+			return 0, fmt.Errorf("expr: undefined variable %q", p)
+		}
+		return v, nil
+	case Op:
+		if n.Left == nil || n.Right == nil {
+			return 0, fmt.Errorf("expr: operator %q lacks an operand", p)
+		}
+		l, err := Eval(n.Left, vars)
+		if err != nil {
+			return 0, err
+		}
+		r, err := Eval(n.Right, vars)
+		if err != nil {
+			return 0, err
+		}
+		switch p {
+		case "+":
+			return l + r, nil
+		case "-":
+			return l - r, nil
+		case "*":
+			return l * r, nil
+		case "/":
+			return l / r, nil
+		case "^":
+			return math.Pow(l, r), nil
+		}
+		return 0, fmt.Errorf("expr: unknown operator %q", p)
+	}
+	return 0, fmt.Errorf("expr: unexpected payload %v (%T)", n.Payload, n.Payload)
+}
+
+// label returns the text of a single node.
+func label(n *btree.Node) string {
+	switch p := n.Payload.(type) {
+	case float64:
+		return strconv.FormatFloat(p, 'g', -1, 64)
+	case Var:
+		return string(p)
+	case Op:
+		return string(p)
+	}
+	return fmt.Sprint(n.Payload)
+}
+
+// Infix returns the expression under `n` in infix notation, with only the parentheses that the
+// precedence requires.
+func Infix(n *btree.Node) string {
+	var sb strings.Builder
+	infix(&sb, n)
+	return sb.String()
+}
+
+func infix(sb *strings.Builder, n *btree.Node) {
+	op, ok := n.Payload.(Op)
+	if !ok {
+		sb.WriteString(label(n))
+		return
+	}
+	// A sub-expression needs parentheses when it binds weaker than `op`, or equally strong on the
+	// side against which `op` groups: a-(b-c), but (a^b)^c.
+	side := func(sub *btree.Node, right bool) {
+		subOp, isOp := sub.Payload.(Op)
+		paren := isOp && (precedence(subOp) < precedence(op) ||
+			(precedence(subOp) == precedence(op) && right != rightAssoc(op)))
+		if paren {
+			sb.WriteString("(")
+		}
+		infix(sb, sub)
+		if paren {
+			sb.WriteString(")")
+		}
+	}
+	side(n.Left, false)
+	sb.WriteString(" " + string(op) + " ")
+	side(n.Right, true)
+}
+
+// Prefix returns the expression under `n` in prefix (Polish) notation, e.g. "* 2 + x 1".
+func Prefix(n *btree.Node) string {
+	var parts []string
+	var walk func(n *btree.Node)
+	walk = func(n *btree.Node) {
+		parts = append(parts, label(n))
+		if _, ok := n.Payload.(Op); ok {
+			walk(n.Left)
+			walk(n.Right)
+		}
+	}
+	walk(n)
+	return strings.Join(parts, " ")
+}
+
+// Postfix returns the expression under `n` in postfix (reverse Polish) notation, e.g.
+// "2 x 1 + *". `ParsePostfix()` reads it back.
+func Postfix(n *btree.Node) string {
+	var parts []string
+	var walk func(n *btree.Node)
+	walk = func(n *btree.Node) {
+		if _, ok := n.Payload.(Op); ok {
+			walk(n.Left)
+			walk(n.Right)
+		}
+		parts = append(parts, label(n))
+	}
+	walk(n)
+	return strings.Join(parts, " ")
+}
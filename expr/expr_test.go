@@ -0,0 +1,87 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAndPrint(t *testing.T) {
+	for _, test := range []struct {
+		in, infix, prefix, postfix string
+	}{
+		{in: "2 * (x + 1)", infix: "2 * (x + 1)", prefix: "* 2 + x 1", postfix: "2 x 1 + *"},
+		{in: "1 + 2 * 3", infix: "1 + 2 * 3", prefix: "+ 1 * 2 3", postfix: "1 2 3 * +"},
+		{in: "(1 + 2) + 3", infix: "1 + 2 + 3", prefix: "+ + 1 2 3", postfix: "1 2 + 3 +"},
+		{in: "1 - (2 - 3)", infix: "1 - (2 - 3)", prefix: "- 1 - 2 3", postfix: "1 2 3 - -"},
+		{in: "2 ^ 3 ^ 2", infix: "2 ^ 3 ^ 2", prefix: "^ 2 ^ 3 2", postfix: "2 3 2 ^ ^"},
+		{in: "(2 ^ 3) ^ 2", infix: "(2 ^ 3) ^ 2", prefix: "^ ^ 2 3 2", postfix: "2 3 ^ 2 ^"},
+		{in: "((x))", infix: "x", prefix: "x", postfix: "x"},
+	} {
+		tokens, _ := Tokenize(test.in)
+		n, err := Parse(tokens)
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", test.in, err)
+			continue
+		}
+		if got := Infix(n); got != test.infix {
+			t.Errorf("Infix(%q) = %q, want %q", test.in, got, test.infix)
+		}
+		if got := Prefix(n); got != test.prefix {
+			t.Errorf("Prefix(%q) = %q, want %q", test.in, got, test.prefix)
+		}
+		if got := Postfix(n); got != test.postfix {
+			t.Errorf("Postfix(%q) = %q, want %q", test.in, got, test.postfix)
+		}
+		back, err := ParsePostfix(strings.Fields(test.postfix))
+		if err != nil || Infix(back) != test.infix {
+			t.Errorf("ParsePostfix(%q) = %v, %v, want %q", test.postfix, Infix(back), err, test.infix)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	vars := map[string]float64{"x": 4, "rate_2": 0.5}
+	for _, test := range []struct {
+		in   string
+		want float64
+	}{
+		{in: "2 * (x + 1)", want: 10},
+		{in: "10 - 4 - 3", want: 3},
+		{in: "2 ^ 3 ^ 2", want: 512},
+		{in: "x / 8 * rate_2", want: 0.25},
+		{in: "1.5", want: 1.5},
+	} {
+		tokens, _ := Tokenize(test.in)
+		n, err := Parse(tokens)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", test.in, err)
+		}
+		if got, err := Eval(n, vars); err != nil || got != test.want {
+			t.Errorf("Eval(%q) = %v, %v, want %v", test.in, got, err, test.want)
+		}
+	}
+	n, _ := Parse([]string{"y"})
+	if _, err := Eval(n, vars); err == nil || !strings.Contains(err.Error(), "undefined") {
+		t.Errorf("Eval(y) = %v, want an undefined variable error", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{"", "1 +", "+ 1", "(1 + 2", "1 + 2)", "1 2", "()", "1 (2)", "1..2"} {
+		tokens, err := Tokenize(in)
+		if err != nil {
+			t.Fatalf("Tokenize(%q) = %v", in, err)
+		}
+		if n, err := Parse(tokens); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", in, Infix(n))
+		}
+	}
+	if _, err := Tokenize("1 % 2"); err == nil {
+		t.Errorf("Tokenize(1 %% 2) = nil, want an error")
+	}
+	for _, in := range []string{"1 +", "1 2"} {
+		if _, err := ParsePostfix(strings.Fields(in)); err == nil {
+			t.Errorf("ParsePostfix(%q) = nil, want an error", in)
+		}
+	}
+}
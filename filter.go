@@ -0,0 +1,21 @@
+package btree
+
+// PredicateFunc is supplied by the caller of selection functions such as `Filter()`. It must
+// return `true` for nodes that should be selected.
+type PredicateFunc func(n *Node) bool
+
+// Filter returns a new tree that holds the nodes for which `pred` returns `true`. The new tree
+// has the same `LessFunc` and is built balanced. Its nodes are fresh, but their payloads are
+// shared with the original tree.
+func (b *BTree) Filter(pred PredicateFunc) *BTree {
+	var selected []*Node
+	b.DepthFirstInOrder(func(n *Node) {
+		if pred(n) {
+			selected = append(selected, &Node{Payload: n.Payload})
+		}
+	})
+	return &BTree{
+		Root: buildBalanced(selected),
+		Less: b.Less,
+	}
+}
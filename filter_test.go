@@ -0,0 +1,30 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	b := newIntTree(1, 2, 3, 4, 5, 6, 7)
+	even := b.Filter(func(n *Node) bool { return n.Payload.(int)%2 == 0 })
+
+	want := []int{}
+	for _, v := range inOrderInts(b) {
+		if v%2 == 0 {
+			want = append(want, v)
+		}
+	}
+	if got := inOrderInts(even); !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter(even) = %v, want %v", got, want)
+	}
+	if even.Root.Left == nil || even.Root.Right == nil {
+		t.Errorf("Filter(even) didn't build a balanced tree")
+	}
+	if got, want := len(inOrderInts(b)), 7; got != want {
+		t.Errorf("Filter() modified the original: %v nodes, want %v", got, want)
+	}
+	if _, inserted := even.Upsert(&Node{Payload: 4}); inserted {
+		t.Errorf("Upsert(4) on filtered tree = inserted, want already present")
+	}
+}
@@ -0,0 +1,37 @@
+package btree
+
+// finger is a cached position in the tree: a node, plus the bounds `lo < node < hi` that its
+// ancestors impose on its subtree (`nil` for no bound). A node `n` within the bounds, if it is in
+// the tree at all, is in the subtree, so both lookups and insertions may start at the finger
+// instead of at the root.
+//
+// The bounds remain true when nodes are inserted, but not when they are removed or the tree is
+// restructured; then the finger is reset. It is also ignored when `root` is no longer the root of
+// the tree.
+type finger struct {
+	root, node, lo, hi *Node
+}
+
+// ResetFinger forgets the cached positions of the last upserted node (see `UseFinger`) and of the
+// largest node (see `Append()`).
+func (b *BTree) ResetFinger() {
+	b.finger = finger{}
+	b.tail = finger{}
+}
+
+// fingerFor returns where to start a descent for `n`, plus the bounds of that subtree.
+func (b *BTree) fingerFor(n *Node) (from, lo, hi *Node) {
+	f := b.finger
+	if !b.UseFinger || f.node == nil || f.root != b.Root ||
+		(f.lo != nil && !b.Less(f.lo, n)) || (f.hi != nil && !b.Less(n, f.hi)) {
+		return b.Root, nil, nil
+	}
+	return f.node, f.lo, f.hi
+}
+
+// setFinger caches the position of `n` when `UseFinger` is set.
+func (b *BTree) setFinger(n, lo, hi *Node) {
+	if b.UseFinger {
+		b.finger = finger{root: b.Root, node: n, lo: lo, hi: hi}
+	}
+}
@@ -0,0 +1,90 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFinger(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	b := New(intLess)
+	b.UseFinger = true
+	want := map[int]bool{}
+	for i := 0; i < 5000; i++ {
+		v := r.Intn(200)
+		switch op := r.Intn(20); {
+		case op < 10:
+			if _, inserted := b.Upsert(&Node{Payload: v}); inserted == want[v] {
+				t.Fatalf("op %v: Upsert(%v) = %v, want %v", i, v, inserted, !want[v])
+			}
+			want[v] = true
+		case op < 16:
+			if _, found := b.Find(&Node{Payload: v}); found != want[v] {
+				t.Fatalf("op %v: Find(%v) = %v, want %v", i, v, found, want[v])
+			}
+		case op < 18:
+			if _, deleted := b.Delete(&Node{Payload: v}); deleted != want[v] {
+				t.Fatalf("op %v: Delete(%v) = %v, want %v", i, v, deleted, want[v])
+			}
+			delete(want, v)
+		case op < 19:
+			b.BulkUpsert(intNodes(v, v+1, v+2))
+			want[v], want[v+1], want[v+2] = true, true, true
+		default:
+			b.Rebalance()
+		}
+	}
+	var vals []int
+	for v := range want {
+		vals = append(vals, v)
+	}
+	sort.Ints(vals)
+	if got := inOrderInts(b); !reflect.DeepEqual(got, vals) {
+		t.Errorf("tree = %v, want %v", got, vals)
+	}
+}
+
+func TestFingerShortensRepeatedUpserts(t *testing.T) {
+	compares := 0
+	b := New(func(x, y *Node) bool {
+		compares++
+		return intLess(x, y)
+	})
+	b.UseFinger = true
+	b.BulkUpsert(intNodes(rand.New(rand.NewSource(1)).Perm(1000)...))
+	b.Upsert(&Node{Payload: 123})
+	compares = 0
+	b.Upsert(&Node{Payload: 123})
+	if compares > 4 {
+		t.Errorf("repeated Upsert() takes %v comparisons, want at most 4", compares)
+	}
+}
+
+func BenchmarkFinger(b *testing.B) {
+	// Each word repeats a few times in a row, as in natural text.
+	r := rand.New(rand.NewSource(1))
+	var keys []int
+	for len(keys) < 100000 {
+		k := r.Intn(10000)
+		for n := r.Intn(4) + 1; n > 0; n-- {
+			keys = append(keys, k)
+		}
+	}
+	for _, useFinger := range []bool{false, true} {
+		name := "root"
+		if useFinger {
+			name = "finger"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				t := New(intLess)
+				t.UseFinger = useFinger
+				for _, k := range keys {
+					t.Upsert(&Node{Payload: k})
+				}
+			}
+		})
+	}
+}
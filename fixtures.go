@@ -0,0 +1,55 @@
+package btree
+
+import "math/rand"
+
+// fixtureLess orders the `int` payloads of the generated trees.
+func fixtureLess(a, b *Node) bool {
+	return a.Payload.(int) < b.Payload.(int)
+}
+
+// fixtureNodes returns nodes with the payloads 0 to n-1, in order.
+func fixtureNodes(n int) []*Node {
+	nodes := make([]*Node, n)
+	for i := range nodes {
+		nodes[i] = &Node{Payload: i}
+	}
+	return nodes
+}
+
+// RandomInts returns a tree of the `int` payloads 0 to n-1, upserted in a random order that is
+// determined by `seed`: the shape of a typical tree that isn't rebalanced, of height about 3 log n.
+// Like `BalancedInts()` and `ChainInts()`, it is meant as a reproducible fixture for tests and
+// benchmarks.
+func RandomInts(n int, seed int64) *BTree {
+	b := New(fixtureLess)
+	nodes := fixtureNodes(n)
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(n, func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+	for _, nd := range nodes {
+		b.upsert(nd)
+	}
+	return b
+}
+
+// BalancedInts returns a perfectly balanced tree of the `int` payloads 0 to n-1: its levels are
+// full, except maybe the last.
+func BalancedInts(n int) *BTree {
+	b := New(fixtureLess)
+	b.Root = buildBalanced(fixtureNodes(n))
+	return b
+}
+
+// ChainInts returns the worst case, a degenerate tree of the `int` payloads 0 to n-1 in which each
+// node is the `Right` sub-node of the previous: the result of upserting in ascending order, with a
+// height of n. `Invert()` turns it into a chain of `Left` sub-nodes.
+func ChainInts(n int) *BTree {
+	b := New(fixtureLess)
+	nodes := fixtureNodes(n)
+	for i := 1; i < n; i++ {
+		nodes[i-1].Right = nodes[i]
+	}
+	if n > 0 {
+		b.Root = nodes[0]
+	}
+	return b
+}
@@ -0,0 +1,47 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFixtures(t *testing.T) {
+	const n = 100
+	want := inOrderInts(BalancedInts(n))
+	for _, test := range []struct {
+		desc       string
+		b          *BTree
+		wantHeight int
+	}{
+		{desc: "balanced", b: BalancedInts(n), wantHeight: 7},
+		{desc: "chain", b: ChainInts(n), wantHeight: n},
+		{desc: "random", b: RandomInts(n, 1)},
+	} {
+		if err := test.b.Verify(); err != nil {
+			t.Errorf("%v: Verify() = %v", test.desc, err)
+		}
+		if got := inOrderInts(test.b); !reflect.DeepEqual(got, want) || len(got) != n {
+			t.Errorf("%v: in order = %v, want 0 to %v", test.desc, got, n-1)
+		}
+		h := test.b.ShapeStats().Height
+		if test.wantHeight != 0 && h != test.wantHeight {
+			t.Errorf("%v: height = %v, want %v", test.desc, h, test.wantHeight)
+		}
+		if test.wantHeight == 0 && (h <= 7 || h >= n/2) {
+			t.Errorf("%v: height = %v, want between that of a balanced tree and a chain", test.desc, h)
+		}
+	}
+	if a, b := RandomInts(n, 1), RandomInts(n, 1); !a.StructurallyEqual(b, intEqual) {
+		t.Errorf("RandomInts() with the same seed returned trees of different shapes")
+	}
+	if BalancedInts(0).Root != nil || ChainInts(0).Root != nil || RandomInts(0, 1).Root != nil {
+		t.Errorf("trees of 0 nodes aren't empty")
+	}
+}
+
+func BenchmarkDepthFirstInOrderChain(b *testing.B) {
+	t := ChainInts(10_000)
+	for i := 0; i < b.N; i++ {
+		t.DepthFirstInOrder(func(*Node) {})
+	}
+}
@@ -0,0 +1,189 @@
+// Package googlecompat adapts a `btree.Augmented` to the `Item`-based API of
+// `github.com/google/btree`, so that projects using that package can switch implementations
+// without rewriting their call sites. Typically only the import and the call to `New()` change.
+//
+// The semantics of the methods follow `github.com/google/btree`, and so does their complexity:
+// the tree keeps itself balanced, even when items are inserted in order, so that insertions,
+// deletions and lookups take O(log n). The `degree` argument of `New()` is accepted for
+// compatibility, but has no meaning for a binary tree.
+package googlecompat
+
+import "github.com/KarelKubat/btree"
+
+// Item represents a single object in the tree.
+type Item interface {
+	// Less tests whether the current item is less than the given argument. Two items `a` and `b`
+	// are considered equal when `!a.Less(b) && !b.Less(a)`.
+	Less(than Item) bool
+}
+
+// ItemIterator is called by the `Ascend*()` and `Descend*()` methods for each visited item. When
+// it returns `false`, the iteration stops.
+type ItemIterator func(i Item) bool
+
+// BTree is a tree of `Item`s, backed by a `btree.Augmented`.
+type BTree struct {
+	t *btree.Augmented
+}
+
+// New creates a new tree. The `degree` is ignored.
+func New(degree int) *BTree {
+	return &BTree{t: btree.NewAugmented(itemLess, nil)}
+}
+
+func itemLess(a, b *btree.Node) bool {
+	return a.Payload.(Item).Less(b.Payload.(Item))
+}
+
+func key(i Item) *btree.Node {
+	if i == nil {
+		return nil
+	}
+	return &btree.Node{Payload: i}
+}
+
+func item(n *btree.Node) Item {
+	if n == nil {
+		return nil
+	}
+	return n.Payload.(Item)
+}
+
+// ReplaceOrInsert adds the item to the tree. When an equal item is already present, it is
+// replaced and returned; otherwise `nil` is returned. A `nil` item panics.
+func (b *BTree) ReplaceOrInsert(i Item) Item {
+	if i == nil {
+		panic("nil item being added to BTree")
+	}
+	intree, inserted := b.t.Upsert(key(i))
+	if inserted {
+		return nil
+	}
+	old := intree.Payload.(Item)
+	intree.Payload = i
+	return old
+}
+
+// Get returns the item in the tree that is equal to `key`, or `nil`.
+func (b *BTree) Get(k Item) Item {
+	n, _ := b.t.Find(key(k))
+	return item(n)
+}
+
+// Has returns `true` when the tree holds an item equal to `key`.
+func (b *BTree) Has(k Item) bool {
+	_, found := b.t.Find(key(k))
+	return found
+}
+
+// Delete removes the item equal to `i` from the tree and returns it, or returns `nil`.
+func (b *BTree) Delete(i Item) Item {
+	n, _ := b.t.Delete(key(i))
+	return item(n)
+}
+
+// DeleteMin removes the smallest item from the tree and returns it, or returns `nil`.
+func (b *BTree) DeleteMin() Item {
+	if min := b.t.Tree().Min(); min != nil {
+		return b.Delete(min.Payload.(Item))
+	}
+	return nil
+}
+
+// DeleteMax removes the largest item from the tree and returns it, or returns `nil`.
+func (b *BTree) DeleteMax() Item {
+	if max := b.t.Tree().Max(); max != nil {
+		return b.Delete(max.Payload.(Item))
+	}
+	return nil
+}
+
+// Min returns the smallest item in the tree, or `nil`.
+func (b *BTree) Min() Item {
+	return item(b.t.Tree().Min())
+}
+
+// Max returns the largest item in the tree, or `nil`.
+func (b *BTree) Max() Item {
+	return item(b.t.Tree().Max())
+}
+
+// Len returns the number of items in the tree.
+func (b *BTree) Len() int {
+	return b.t.Len()
+}
+
+// Clear removes all items from the tree. The argument is accepted for compatibility; there is no
+// free list.
+func (b *BTree) Clear(addNodesToFreelist bool) {
+	b.t = btree.NewAugmented(itemLess, nil)
+}
+
+// Clone returns a copy of the tree. The items themselves are shared.
+func (b *BTree) Clone() *BTree {
+	c := New(0)
+	b.t.Tree().DepthFirstInOrder(func(n *btree.Node) {
+		c.t.Upsert(key(item(n)))
+	})
+	return c
+}
+
+func visit(it ItemIterator) btree.VisitFunc {
+	return func(n *btree.Node) bool {
+		return it(n.Payload.(Item))
+	}
+}
+
+// Ascend calls `it` for every item in ascending order, until `it` returns `false`.
+func (b *BTree) Ascend(it ItemIterator) {
+	b.t.Tree().AscendRange(nil, nil, visit(it))
+}
+
+// AscendRange calls `it` for the items in the range `[greaterOrEqual, lessThan)` in ascending
+// order, until `it` returns `false`.
+func (b *BTree) AscendRange(greaterOrEqual, lessThan Item, it ItemIterator) {
+	b.t.Tree().AscendRange(key(greaterOrEqual), key(lessThan), visit(it))
+}
+
+// AscendLessThan calls `it` for the items in the range `[first, pivot)` in ascending order, until
+// `it` returns `false`.
+func (b *BTree) AscendLessThan(pivot Item, it ItemIterator) {
+	b.t.Tree().AscendRange(nil, key(pivot), visit(it))
+}
+
+// AscendGreaterOrEqual calls `it` for the items in the range `[pivot, last]` in ascending order,
+// until `it` returns `false`.
+func (b *BTree) AscendGreaterOrEqual(pivot Item, it ItemIterator) {
+	b.t.Tree().AscendRange(key(pivot), nil, visit(it))
+}
+
+// Descend calls `it` for every item in descending order, until `it` returns `false`.
+func (b *BTree) Descend(it ItemIterator) {
+	b.t.Tree().DescendRange(nil, nil, visit(it))
+}
+
+// DescendRange calls `it` for the items in the range `[lessOrEqual, greaterThan)` in descending
+// order, until `it` returns `false`.
+func (b *BTree) DescendRange(lessOrEqual, greaterThan Item, it ItemIterator) {
+	b.t.Tree().DescendRange(key(lessOrEqual), key(greaterThan), visit(it))
+}
+
+// DescendLessOrEqual calls `it` for the items in the range `[pivot, first]` in descending order,
+// until `it` returns `false`.
+func (b *BTree) DescendLessOrEqual(pivot Item, it ItemIterator) {
+	b.t.Tree().DescendRange(key(pivot), nil, visit(it))
+}
+
+// DescendGreaterThan calls `it` for the items in the range `[last, pivot)` in descending order,
+// until `it` returns `false`.
+func (b *BTree) DescendGreaterThan(pivot Item, it ItemIterator) {
+	b.t.Tree().DescendRange(nil, key(pivot), visit(it))
+}
+
+// Int implements the `Item` interface for integers.
+type Int int
+
+// Less returns `true` when `a < b`.
+func (a Int) Less(b Item) bool {
+	return a < b.(Int)
+}
@@ -0,0 +1,136 @@
+package googlecompat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func all(walk func(ItemIterator)) []Item {
+	out := []Item{}
+	walk(func(i Item) bool {
+		out = append(out, i)
+		return true
+	})
+	return out
+}
+
+func ints(vals ...int) []Item {
+	out := []Item{}
+	for _, v := range vals {
+		out = append(out, Int(v))
+	}
+	return out
+}
+
+func TestReplaceOrInsertGetDelete(t *testing.T) {
+	b := New(2)
+	for _, v := range []int{5, 3, 8, 1} {
+		if got := b.ReplaceOrInsert(Int(v)); got != nil {
+			t.Errorf("ReplaceOrInsert(%v) = %v, want nil", v, got)
+		}
+	}
+	if got := b.ReplaceOrInsert(Int(3)); got != Int(3) {
+		t.Errorf("ReplaceOrInsert(3) again = %v, want 3", got)
+	}
+	if got, want := b.Len(), 4; got != want {
+		t.Errorf("Len() = %v, want %v", got, want)
+	}
+	if got := b.Get(Int(8)); got != Int(8) {
+		t.Errorf("Get(8) = %v, want 8", got)
+	}
+	if got := b.Get(Int(7)); got != nil {
+		t.Errorf("Get(7) = %v, want nil", got)
+	}
+	if !b.Has(Int(1)) || b.Has(Int(2)) {
+		t.Errorf("Has(1), Has(2) = %v, %v, want true, false", b.Has(Int(1)), b.Has(Int(2)))
+	}
+	if got := b.Delete(Int(5)); got != Int(5) {
+		t.Errorf("Delete(5) = %v, want 5", got)
+	}
+	if got := b.Delete(Int(5)); got != nil {
+		t.Errorf("Delete(5) again = %v, want nil", got)
+	}
+	if got := b.DeleteMin(); got != Int(1) {
+		t.Errorf("DeleteMin() = %v, want 1", got)
+	}
+	if got := b.DeleteMax(); got != Int(8) {
+		t.Errorf("DeleteMax() = %v, want 8", got)
+	}
+	if got, want := all(b.Ascend), ints(3); !reflect.DeepEqual(got, want) {
+		t.Errorf("Ascend() = %v, want %v", got, want)
+	}
+	if got, want := b.Len(), 1; got != want {
+		t.Errorf("Len() = %v, want %v", got, want)
+	}
+
+	c := b.Clone()
+	b.Clear(false)
+	if b.Len() != 0 || b.Min() != nil || b.Max() != nil || b.DeleteMin() != nil {
+		t.Errorf("Clear() leaves items behind")
+	}
+	if got, want := c.Len(), 1; got != want {
+		t.Errorf("Len() of clone = %v, want %v", got, want)
+	}
+}
+
+func TestIteration(t *testing.T) {
+	b := New(2)
+	for i := 1; i <= 9; i++ {
+		b.ReplaceOrInsert(Int(i))
+	}
+	for _, test := range []struct {
+		desc string
+		walk func(ItemIterator)
+		want []Item
+	}{
+		{desc: "Ascend", walk: b.Ascend, want: ints(1, 2, 3, 4, 5, 6, 7, 8, 9)},
+		{desc: "AscendRange", walk: func(it ItemIterator) { b.AscendRange(Int(3), Int(6), it) }, want: ints(3, 4, 5)},
+		{desc: "AscendLessThan", walk: func(it ItemIterator) { b.AscendLessThan(Int(3), it) }, want: ints(1, 2)},
+		{desc: "AscendGreaterOrEqual", walk: func(it ItemIterator) { b.AscendGreaterOrEqual(Int(8), it) }, want: ints(8, 9)},
+		{desc: "Descend", walk: b.Descend, want: ints(9, 8, 7, 6, 5, 4, 3, 2, 1)},
+		{desc: "DescendRange", walk: func(it ItemIterator) { b.DescendRange(Int(6), Int(3), it) }, want: ints(6, 5, 4)},
+		{desc: "DescendLessOrEqual", walk: func(it ItemIterator) { b.DescendLessOrEqual(Int(2), it) }, want: ints(2, 1)},
+		{desc: "DescendGreaterThan", walk: func(it ItemIterator) { b.DescendGreaterThan(Int(7), it) }, want: ints(9, 8)},
+	} {
+		if got := all(test.walk); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s = %v, want %v", test.desc, got, test.want)
+		}
+	}
+
+	got := []Item{}
+	b.Ascend(func(i Item) bool {
+		got = append(got, i)
+		return len(got) < 2
+	})
+	if want := ints(1, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("Ascend() stopping early = %v, want %v", got, want)
+	}
+}
+
+func TestSortedInsertsStayBalanced(t *testing.T) {
+	b := New(2)
+	for i := 0; i < 10000; i++ {
+		b.ReplaceOrInsert(Int(i))
+	}
+	if h := b.t.Tree().ShapeStats().Height; h > 30 {
+		t.Errorf("height after 10000 sorted inserts = %v, want at most 30", h)
+	}
+	for i := 0; i < 5000; i++ {
+		b.DeleteMin()
+	}
+	if h := b.t.Tree().ShapeStats().Height; h > 30 {
+		t.Errorf("height after 5000 DeleteMin() = %v, want at most 30", h)
+	}
+	if got := b.Min(); got != Int(5000) {
+		t.Errorf("Min() = %v, want 5000", got)
+	}
+}
+
+func BenchmarkSortedReplaceOrInsert(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		t := New(2)
+		for j := 0; j < 20000; j++ {
+			t.ReplaceOrInsert(Int(j))
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package grpcserver implements the gRPC service `Store` (see `store.proto`): a simple keyed store
+// backed by a `btree.BTree`. It serves both as a ready-made integration point and as a worked
+// example of using the tree in a concurrent service:
+//
+//	s := grpc.NewServer()
+//	grpcserver.RegisterStoreServer(s, grpcserver.NewServer())
+//	s.Serve(listener)
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/KarelKubat/btree"
+)
+
+// entry is the payload of a node in the store's tree.
+type entry struct {
+	key, value []byte
+}
+
+func entryLess(a, b *btree.Node) bool {
+	return bytes.Compare(a.Payload.(*entry).key, b.Payload.(*entry).key) < 0
+}
+
+func lookup(key []byte) *btree.Node {
+	return &btree.Node{Payload: &entry{key: key}}
+}
+
+// scanBatch is the number of entries that `Scan()` collects under the lock before sending them.
+// This keeps the lock from being held while waiting for slow clients.
+const scanBatch = 256
+
+// Server implements `StoreServer`. All methods are safe for concurrent use.
+type Server struct {
+	UnimplementedStoreServer
+
+	mu   sync.RWMutex
+	tree *btree.BTree
+}
+
+// NewServer returns a `Server` with an empty store.
+func NewServer() *Server {
+	return &Server{tree: btree.New(entryLess)}
+}
+
+// Get implements `StoreServer`.
+func (s *Server) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, found := s.tree.Find(lookup(req.GetKey()))
+	if !found {
+		return &GetResponse{}, nil
+	}
+	return &GetResponse{Found: true, Value: n.Payload.(*entry).value}, nil
+}
+
+// Put implements `StoreServer`.
+func (s *Server) Put(ctx context.Context, req *PutRequest) (*PutResponse, error) {
+	e := &entry{key: bytes.Clone(req.GetKey()), value: bytes.Clone(req.GetValue())}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, inserted := s.tree.Upsert(&btree.Node{Payload: e})
+	if !inserted {
+		n.Payload = e
+	}
+	return &PutResponse{Created: inserted}, nil
+}
+
+// Delete implements `StoreServer`.
+func (s *Server) Delete(ctx context.Context, req *DeleteRequest) (*DeleteResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, deleted := s.tree.Delete(lookup(req.GetKey()))
+	return &DeleteResponse{Deleted: deleted}, nil
+}
+
+// Scan implements `StoreServer`. The entries are collected in batches; each batch is a consistent
+// view of the store, but the store may change between batches.
+func (s *Server) Scan(req *ScanRequest, stream Store_ScanServer) error {
+	var to *btree.Node
+	if len(req.GetTo()) > 0 {
+		to = lookup(req.GetTo())
+	}
+	from, skipFrom := lookup(req.GetFrom()), false
+	sent := uint32(0)
+	for {
+		var batch []*Entry
+		s.mu.RLock()
+		s.tree.AscendRange(from, to, func(n *btree.Node) bool {
+			e := n.Payload.(*entry)
+			if skipFrom && bytes.Equal(e.key, from.Payload.(*entry).key) {
+				return true
+			}
+			batch = append(batch, &Entry{Key: e.key, Value: e.value})
+			return len(batch) < scanBatch && (req.GetLimit() == 0 || sent+uint32(len(batch)) < req.GetLimit())
+		})
+		s.mu.RUnlock()
+
+		for _, e := range batch {
+			if err := stream.Context().Err(); err != nil {
+				return err
+			}
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+		sent += uint32(len(batch))
+		if len(batch) < scanBatch || (req.GetLimit() != 0 && sent >= req.GetLimit()) {
+			return nil
+		}
+		// Continue after the last sent key.
+		from, skipFrom = lookup(batch[len(batch)-1].Key), true
+	}
+}
@@ -0,0 +1,110 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newClient(t *testing.T) StoreClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	RegisterStoreServer(s, NewServer())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewStoreClient(conn)
+}
+
+func scan(t *testing.T, c StoreClient, req *ScanRequest) []string {
+	t.Helper()
+	stream, err := c.Scan(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Scan() = %v", err)
+	}
+	out := []string{}
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("Scan().Recv() = %v", err)
+		}
+		out = append(out, string(e.GetKey())+"="+string(e.GetValue()))
+	}
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t)
+
+	for _, kv := range [][2]string{{"b", "2"}, {"a", "1"}, {"c", "3"}} {
+		resp, err := c.Put(ctx, &PutRequest{Key: []byte(kv[0]), Value: []byte(kv[1])})
+		if err != nil || !resp.GetCreated() {
+			t.Fatalf("Put(%v) = %v, %v, want created", kv, resp, err)
+		}
+	}
+	if resp, err := c.Put(ctx, &PutRequest{Key: []byte("b"), Value: []byte("20")}); err != nil || resp.GetCreated() {
+		t.Errorf("Put(b) again = %v, %v, want not created", resp, err)
+	}
+	if resp, err := c.Get(ctx, &GetRequest{Key: []byte("b")}); err != nil || !resp.GetFound() || string(resp.GetValue()) != "20" {
+		t.Errorf("Get(b) = %v, %v, want 20", resp, err)
+	}
+	if resp, err := c.Get(ctx, &GetRequest{Key: []byte("x")}); err != nil || resp.GetFound() {
+		t.Errorf("Get(x) = %v, %v, want not found", resp, err)
+	}
+
+	for _, test := range []struct {
+		req  *ScanRequest
+		want []string
+	}{
+		{req: &ScanRequest{}, want: []string{"a=1", "b=20", "c=3"}},
+		{req: &ScanRequest{From: []byte("b")}, want: []string{"b=20", "c=3"}},
+		{req: &ScanRequest{To: []byte("b")}, want: []string{"a=1"}},
+		{req: &ScanRequest{Limit: 2}, want: []string{"a=1", "b=20"}},
+	} {
+		if got := scan(t, c, test.req); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Scan(%v) = %v, want %v", test.req, got, test.want)
+		}
+	}
+
+	if resp, err := c.Delete(ctx, &DeleteRequest{Key: []byte("a")}); err != nil || !resp.GetDeleted() {
+		t.Errorf("Delete(a) = %v, %v, want deleted", resp, err)
+	}
+	if resp, err := c.Delete(ctx, &DeleteRequest{Key: []byte("a")}); err != nil || resp.GetDeleted() {
+		t.Errorf("Delete(a) again = %v, %v, want not deleted", resp, err)
+	}
+}
+
+func TestScanInBatches(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t)
+	n := scanBatch*2 + 10
+	for i := 0; i < n; i++ {
+		if _, err := c.Put(ctx, &PutRequest{Key: []byte(fmt.Sprintf("%05d", i))}); err != nil {
+			t.Fatalf("Put() = %v", err)
+		}
+	}
+	if got := len(scan(t, c, &ScanRequest{})); got != n {
+		t.Errorf("Scan() = %v entries, want %v", got, n)
+	}
+	if got, want := len(scan(t, c, &ScanRequest{Limit: scanBatch + 1})), scanBatch+1; got != want {
+		t.Errorf("Scan(limit) = %v entries, want %v", got, want)
+	}
+}
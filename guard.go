@@ -0,0 +1,63 @@
+package btree
+
+import "sync/atomic"
+
+// The state of the concurrent use check: the number of ongoing reads, or `writing`.
+const writing = -1
+
+// beginRead starts the read operation `op`, and returns the function that ends it. When
+// `CheckConcurrentUse` is set, it registers the read, and panics when the tree is being written.
+// When `ProfileContext` is set, the operation is profiled (see `profiled`), and after
+// `EnableMetrics()` it is counted and timed.
+//
+// Like the check of Go maps, this doesn't catch every misuse, since reads and writes that don't
+// overlap in time go unnoticed; it is a cheap way to find the common ones. The race detector
+// (`go test -race`) is thorough, but slow.
+func (b *BTree) beginRead(op string) func() {
+	end := b.checkRead()
+	if b.ProfileContext != nil {
+		end = b.profiled(op, end)
+	}
+	return b.metrics.timed(op, end)
+}
+
+// checkRead is the concurrent use check of `beginRead()`.
+func (b *BTree) checkRead() func() {
+	if !b.CheckConcurrentUse {
+		return func() {}
+	}
+	if atomic.AddInt32(&b.uses, 1) <= 0 {
+		atomic.AddInt32(&b.uses, -1)
+		panic("btree: read during a write of the tree; it must not be used concurrently, " +
+			"consider btree.Synchronized()")
+	}
+	return func() { atomic.AddInt32(&b.uses, -1) }
+}
+
+// beginWrite starts the write operation `op`, like `beginRead()`. The concurrent use check panics
+// when the tree is being read or written. This includes writes from within the callback of a
+// walk, which the tree doesn't support either. When `ShadowKey` is set, the shadow map is rebuilt
+// when the operation ends, see `shadowWrite()`.
+func (b *BTree) beginWrite(op string) func() {
+	end := b.shadowWrite(op, b.checkWrite())
+	if b.ProfileContext != nil {
+		end = b.profiled(op, end)
+	}
+	return b.metrics.timed(op, end)
+}
+
+// checkWrite is the concurrent use check of `beginWrite()`.
+func (b *BTree) checkWrite() func() {
+	if !b.CheckConcurrentUse {
+		return func() {}
+	}
+	if !atomic.CompareAndSwapInt32(&b.uses, 0, writing) {
+		if atomic.LoadInt32(&b.uses) == writing {
+			panic("btree: concurrent writes of the tree; it must not be used concurrently, " +
+				"consider btree.Synchronized()")
+		}
+		panic("btree: write during a read of the tree (e.g. from a walk callback, or from " +
+			"another goroutine); consider btree.Synchronized()")
+	}
+	return func() { atomic.StoreInt32(&b.uses, 0) }
+}
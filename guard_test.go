@@ -0,0 +1,67 @@
+package btree
+
+import (
+	"strings"
+	"testing"
+)
+
+// panicOf returns the panic message of `fn`, or "" when it doesn't panic.
+func panicOf(fn func()) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = r.(string)
+		}
+	}()
+	fn()
+	return ""
+}
+
+func TestCheckConcurrentUse(t *testing.T) {
+	b := newIntTree(2, 1, 3)
+	b.CheckConcurrentUse = true
+
+	// Reads within reads and consecutive writes are fine.
+	if msg := panicOf(func() {
+		b.DepthFirstInOrder(func(n *Node) { b.Find(n) })
+		b.Upsert(&Node{Payload: 4})
+		b.Delete(&Node{Payload: 4})
+	}); msg != "" {
+		t.Errorf("correct use panics: %v", msg)
+	}
+
+	// A write from within a walk.
+	msg := panicOf(func() {
+		b.DepthFirstInOrder(func(n *Node) { b.Upsert(&Node{Payload: 5}) })
+	})
+	if !strings.Contains(msg, "write during a read") {
+		t.Errorf("Upsert() during a walk panics with %q, want write during a read", msg)
+	}
+
+	// A write from another goroutine while a read is ongoing.
+	result := make(chan string)
+	b.AscendRange(nil, nil, func(*Node) bool {
+		go func() { result <- panicOf(func() { b.Delete(&Node{Payload: 1}) }) }()
+		msg = <-result
+		return false
+	})
+	if !strings.Contains(msg, "write during a read") {
+		t.Errorf("concurrent Delete() panics with %q, want write during a read", msg)
+	}
+
+	// A read from another goroutine while a write is ongoing.
+	b.Less = func(x, y *Node) bool {
+		if msg == "" {
+			go func() { result <- panicOf(func() { b.Min() }) }()
+			msg = <-result
+		}
+		return intLess(x, y)
+	}
+	msg = ""
+	b.Upsert(&Node{Payload: 6})
+	if !strings.Contains(msg, "read during a write") {
+		t.Errorf("concurrent Min() panics with %q, want read during a write", msg)
+	}
+	if got := inOrderInts(b); len(got) != 4 {
+		t.Errorf("after the checks = %v, want 4 nodes", got)
+	}
+}
@@ -0,0 +1,22 @@
+package btree
+
+// Hash returns a digest of the in-order contents of the tree, using `h` for the hash of each
+// node. Like `Equal()`, it doesn't depend on the shape of the tree, but unlike a sum of hashes, it
+// does depend on the order of the nodes. Trees that are `Equal()` under an `EqualFunc` that agrees
+// with `h` have the same digest, so different digests show that trees differ without walking
+// them side by side; e.g. a digest can be kept with a copy of the tree, or sent instead of the
+// tree. Equal digests only make equality likely, which `Equal()` confirms.
+func (b *BTree) Hash(h HashFunc) uint64 {
+	defer b.beginRead("Hash")()
+	// An FNV-1a style fold over the mixed node hashes, seeded with the FNV offset basis.
+	var digest uint64 = 0xcbf29ce484222325
+	var it inorderIter
+	for it.init(b); ; {
+		n := it.next()
+		if n == nil {
+			break
+		}
+		digest = (digest ^ mix(h(n))) * 0x100000001b3
+	}
+	return digest
+}
@@ -0,0 +1,40 @@
+package btree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	a := newIntTree(4, 2, 6, 1, 3, 5, 7)
+	b := newIntTree(1, 2, 3, 4, 5, 6, 7)
+	if a.Hash(intHash) != b.Hash(intHash) {
+		t.Errorf("Hash() of trees with the same contents but different shapes differ")
+	}
+	if New(intLess).Hash(intHash) == newIntTree(0).Hash(intHash) {
+		t.Errorf("Hash() of an empty tree and of a tree of 0 are the same")
+	}
+	b.Delete(&Node{Payload: 7})
+	if a.Hash(intHash) == b.Hash(intHash) {
+		t.Errorf("Hash() of trees with different contents are the same")
+	}
+	// The order matters: the same payloads under a reversed LessFunc give another digest.
+	c := newIntTree(1, 2, 3, 4, 5, 6)
+	c.Invert()
+	if b.Hash(intHash) == c.Hash(intHash) {
+		t.Errorf("Hash() of a tree and of its inversion are the same")
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		x, y := New(intLess), New(intLess)
+		for j := rng.Intn(6); j > 0; j-- {
+			x.Upsert(&Node{Payload: rng.Intn(6)})
+			y.Upsert(&Node{Payload: rng.Intn(6)})
+		}
+		if equal, same := x.Equal(y, intEqual), x.Hash(intHash) == y.Hash(intHash); equal != same {
+			t.Fatalf("%v and %v: Equal() = %v, but equal Hash() = %v", inOrderInts(x),
+				inOrderInts(y), equal, same)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package btree
+
+// added records that `n` entered the tree: it is added to the tree's `Bloom` and checksum, if it
+// has them, and passed to `OnInsert`.
+func (b *BTree) added(n *Node) {
+	if b.Bloom != nil {
+		b.Bloom.Add(n)
+	}
+	if b.checksum != nil {
+		b.checksum.sum += mix(b.checksum.hash(n))
+	}
+	if b.OnInsert != nil {
+		b.OnInsert(n)
+	}
+}
+
+// removed records that `n` left the tree, for the checksum and `OnDelete`.
+func (b *BTree) removed(n *Node) {
+	if b.checksum != nil {
+		b.checksum.sum -= mix(b.checksum.hash(n))
+	}
+	if b.OnDelete != nil {
+		b.OnDelete(n)
+	}
+}
@@ -0,0 +1,99 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestHooksTrackContents(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		clock := &fakeClock{t: time.Unix(1000, 0)}
+		b := New(intLess)
+		b.Now = clock.now
+		b.LazyDelete = lazy
+		// index mirrors the tree via the hooks.
+		index := map[int]bool{}
+		b.OnInsert = func(n *Node) {
+			if index[n.Payload.(int)] {
+				t.Fatalf("LazyDelete=%v: OnInsert(%v), which was inserted already", lazy, n.Payload)
+			}
+			index[n.Payload.(int)] = true
+		}
+		b.OnDelete = func(n *Node) {
+			if !index[n.Payload.(int)] {
+				t.Fatalf("LazyDelete=%v: OnDelete(%v), which wasn't inserted", lazy, n.Payload)
+			}
+			delete(index, n.Payload.(int))
+		}
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 3000; i++ {
+			v := rng.Intn(60)
+			switch rng.Intn(12) {
+			case 0, 1, 2:
+				b.Upsert(&Node{Payload: v})
+			case 3:
+				b.UpsertWithExpiry(&Node{Payload: v}, clock.t.Add(time.Duration(rng.Intn(5))*time.Second))
+			case 4, 5, 6:
+				b.Delete(&Node{Payload: v})
+			case 7:
+				b.BulkUpsert([]*Node{{Payload: v}, {Payload: v + 1}, {Payload: v + 3}})
+			case 8:
+				b.UpsertBatch([]*Node{{Payload: v}, {Payload: v / 2}})
+			case 9:
+				clock.t = clock.t.Add(time.Second)
+				if rng.Intn(3) == 0 {
+					b.ExpireBefore(clock.t)
+				}
+			case 10:
+				b.Compact()
+			default:
+				if rng.Intn(20) == 0 {
+					b.Clear()
+				}
+			}
+			// The index holds the live nodes, plus those that expired but are still linked in,
+			// which traversals skip.
+			want := []int{}
+			for v := range index {
+				want = append(want, v)
+			}
+			sort.Ints(want)
+			got := []int{}
+			var walk func(n *Node)
+			walk = func(n *Node) {
+				if n == nil {
+					return
+				}
+				walk(n.Left)
+				if !b.dead(n) {
+					got = append(got, n.Payload.(int))
+				}
+				walk(n.Right)
+			}
+			walk(b.Root)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("LazyDelete=%v: op %v: tree = %v, index = %v", lazy, i, got, want)
+			}
+		}
+	}
+}
+
+func TestOnRotate(t *testing.T) {
+	b := ChainInts(100)
+	b.EnableStats()
+	rotations := 0
+	b.OnRotate = func(up, down *Node) {
+		rotations++
+		if up.Left != down && up.Right != down {
+			t.Fatalf("OnRotate(%v, %v): %v is not a sub-node of %v", up.Payload, down.Payload,
+				down.Payload, up.Payload)
+		}
+	}
+	b.Rebalance()
+	if want := int(b.Stats().Rotations); rotations != want || rotations == 0 {
+		t.Errorf("OnRotate was called %v times, want %v", rotations, want)
+	}
+}
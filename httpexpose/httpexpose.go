@@ -0,0 +1,220 @@
+// Package httpexpose offers an `http.Handler` that exposes the contents of a `btree.BTree`, so
+// that a tree in a running service can be inspected and edited with a couple of lines:
+//
+//	http.Handle("/tree/", http.StripPrefix("/tree", httpexpose.New(bt, codec)))
+//
+// The handler understands the following requests, relative to where it is mounted:
+//
+//   - `GET /` lists the nodes in order, as `{"items":[{"key":...,"value":...},...],"next":...}`.
+//     The query parameters `from` and `to` limit the listing to keys `from <= key < to`. At most
+//     `limit` items are returned (default 100). When there are more, `next` holds the key to pass
+//     as `from` to get the next page.
+//   - `GET /KEY` returns the payload of the node with that key as JSON.
+//   - `PUT /KEY` stores the JSON body as the payload of the node with that key, either adding the
+//     node or replacing its payload.
+//   - `DELETE /KEY` removes the node with that key.
+//
+// The handler serializes its own access to the tree. When the tree is also used elsewhere, that
+// must be coordinated by the caller.
+package httpexpose
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/KarelKubat/btree"
+)
+
+// DefaultLimit is the number of items that a listing returns when there is no `limit` parameter.
+const DefaultLimit = 100
+
+// maxBody limits the size of a `PUT` request body.
+const maxBody = 1 << 20
+
+// Codec converts between the keys in URLs and the nodes of the tree.
+type Codec interface {
+	// Key returns the key of a node, as shown in listings.
+	Key(n *btree.Node) string
+	// Lookup returns a node that is filled in as far as the tree's `LessFunc` needs to find the
+	// node with the given key.
+	Lookup(key string) (*btree.Node, error)
+	// Payload returns the payload to store for the key, given the JSON body of a `PUT` request.
+	Payload(key string, body []byte) (interface{}, error)
+}
+
+// Handler is the `http.Handler` that exposes a tree.
+type Handler struct {
+	mu    sync.RWMutex
+	tree  *btree.BTree
+	codec Codec
+}
+
+// New returns a `Handler` that exposes `tree`, using `codec` for the keys.
+func New(tree *btree.BTree, codec Codec) *Handler {
+	return &Handler{tree: tree, codec: codec}
+}
+
+// item is one entry of a listing.
+type item struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// listing is the response to `GET /`.
+type listing struct {
+	Items []item  `json:"items"`
+	Next  *string `json:"next,omitempty"`
+}
+
+// ServeHTTP implements `http.Handler`.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		h.list(w, r)
+	case key == "":
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	case r.Method == http.MethodGet:
+		h.get(w, key)
+	case r.Method == http.MethodPut:
+		h.put(w, r, key)
+	case r.Method == http.MethodDelete:
+		h.delete(w, key)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// bound returns the node for the query parameter `param`, or `nil` when it isn't given.
+func (h *Handler) bound(r *http.Request, param string) (*btree.Node, error) {
+	key := r.URL.Query().Get(param)
+	if key == "" {
+		return nil, nil
+	}
+	return h.codec.Lookup(key)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	limit := DefaultLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		l, err := strconv.Atoi(s)
+		if err != nil || l <= 0 {
+			http.Error(w, "bad limit: "+s, http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+	from, err := h.bound(r, "from")
+	if err != nil {
+		http.Error(w, "bad from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := h.bound(r, "to")
+	if err != nil {
+		http.Error(w, "bad to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	out := listing{Items: []item{}}
+	h.mu.RLock()
+	h.tree.AscendRange(from, to, func(n *btree.Node) bool {
+		if len(out.Items) == limit {
+			next := h.codec.Key(n)
+			out.Next = &next
+			return false
+		}
+		value, merr := json.Marshal(n.Payload)
+		if merr != nil {
+			err = merr
+			return false
+		}
+		out.Items = append(out.Items, item{Key: h.codec.Key(n), Value: value})
+		return true
+	})
+	h.mu.RUnlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) get(w http.ResponseWriter, key string) {
+	n, err := h.codec.Lookup(key)
+	if err != nil {
+		http.Error(w, "bad key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.mu.RLock()
+	intree, found := h.tree.Find(n)
+	var value []byte
+	if found {
+		value, err = json.Marshal(intree.Payload)
+	}
+	h.mu.RUnlock()
+	switch {
+	case !found:
+		http.Error(w, "not found", http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, json.RawMessage(value))
+	}
+}
+
+func (h *Handler) put(w http.ResponseWriter, r *http.Request, key string) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	payload, err := h.codec.Payload(key, body)
+	if err != nil {
+		http.Error(w, "bad payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.mu.Lock()
+	intree, inserted := h.tree.Upsert(&btree.Node{Payload: payload})
+	if !inserted {
+		intree.Payload = payload
+	}
+	h.mu.Unlock()
+	if inserted {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) delete(w http.ResponseWriter, key string) {
+	n, err := h.codec.Lookup(key)
+	if err != nil {
+		http.Error(w, "bad key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.mu.Lock()
+	_, deleted := h.tree.Delete(n)
+	h.mu.Unlock()
+	if !deleted {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
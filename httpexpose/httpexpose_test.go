@@ -0,0 +1,110 @@
+package httpexpose
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KarelKubat/btree"
+)
+
+type entry struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+}
+
+type entryCodec struct{}
+
+func (entryCodec) Key(n *btree.Node) string {
+	return n.Payload.(*entry).Name
+}
+
+func (entryCodec) Lookup(key string) (*btree.Node, error) {
+	return &btree.Node{Payload: &entry{Name: key}}, nil
+}
+
+func (entryCodec) Payload(key string, body []byte) (interface{}, error) {
+	e := &entry{}
+	if err := json.Unmarshal(body, e); err != nil {
+		return nil, err
+	}
+	if e.Name != key {
+		return nil, errors.New("name doesn't match the key")
+	}
+	return e, nil
+}
+
+func newServer() *httptest.Server {
+	bt := btree.New(func(a, b *btree.Node) bool {
+		return a.Payload.(*entry).Name < b.Payload.(*entry).Name
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/tree/", http.StripPrefix("/tree", New(bt, entryCodec{})))
+	return httptest.NewServer(mux)
+}
+
+func do(t *testing.T, method, url, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("http.NewRequest(%s %s) = %v", method, url, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s = %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, strings.TrimSpace(string(out))
+}
+
+func TestHandler(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	base := srv.URL + "/tree/"
+
+	for _, test := range []struct {
+		method, path, body string
+		wantStatus         int
+		wantBody           string
+	}{
+		{method: "PUT", path: "bob", body: `{"name":"bob","phone":"1"}`, wantStatus: http.StatusCreated},
+		{method: "PUT", path: "alice", body: `{"name":"alice","phone":"2"}`, wantStatus: http.StatusCreated},
+		{method: "PUT", path: "carol", body: `{"name":"carol","phone":"3"}`, wantStatus: http.StatusCreated},
+		{method: "PUT", path: "bob", body: `{"name":"bob","phone":"4"}`, wantStatus: http.StatusNoContent},
+		{method: "PUT", path: "dave", body: `{"name":"eve"}`, wantStatus: http.StatusBadRequest},
+		{method: "GET", path: "bob", wantStatus: http.StatusOK, wantBody: `{"name":"bob","phone":"4"}`},
+		{method: "GET", path: "dave", wantStatus: http.StatusNotFound},
+		{
+			method: "GET", path: "", wantStatus: http.StatusOK,
+			wantBody: `{"items":[{"key":"alice","value":{"name":"alice","phone":"2"}},` +
+				`{"key":"bob","value":{"name":"bob","phone":"4"}},{"key":"carol","value":{"name":"carol","phone":"3"}}]}`,
+		},
+		{
+			method: "GET", path: "?limit=1&from=b", wantStatus: http.StatusOK,
+			wantBody: `{"items":[{"key":"bob","value":{"name":"bob","phone":"4"}}],"next":"carol"}`,
+		},
+		{
+			method: "GET", path: "?from=a&to=bob", wantStatus: http.StatusOK,
+			wantBody: `{"items":[{"key":"alice","value":{"name":"alice","phone":"2"}}]}`,
+		},
+		{method: "GET", path: "?limit=zero", wantStatus: http.StatusBadRequest},
+		{method: "DELETE", path: "bob", wantStatus: http.StatusNoContent},
+		{method: "DELETE", path: "bob", wantStatus: http.StatusNotFound},
+		{method: "POST", path: "bob", wantStatus: http.StatusMethodNotAllowed},
+		{method: "DELETE", path: "", wantStatus: http.StatusMethodNotAllowed},
+		{method: "GET", path: "?to=b", wantStatus: http.StatusOK, wantBody: `{"items":[{"key":"alice","value":{"name":"alice","phone":"2"}}]}`},
+	} {
+		status, body := do(t, test.method, base+test.path, test.body)
+		if status != test.wantStatus {
+			t.Errorf("%s /%s = %v, want %v (%s)", test.method, test.path, status, test.wantStatus, body)
+		}
+		if test.wantBody != "" && body != test.wantBody {
+			t.Errorf("%s /%s = %s, want %s", test.method, test.path, body, test.wantBody)
+		}
+	}
+}
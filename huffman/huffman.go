@@ -0,0 +1,161 @@
+// Package huffman builds Huffman coding trees out of `btree.Node`s. The leaves of a tree hold the
+// symbols, and the path from the root to a leaf is the symbol's code: "0" for each step to the
+// `Left`, "1" for each step to the `Right`. Frequent symbols are close to the root, so they get
+// short codes.
+package huffman
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/KarelKubat/btree"
+)
+
+// Leaf is the payload of a leaf: a symbol and its frequency.
+type Leaf struct {
+	Symbol string
+	Weight int
+}
+
+// Weight is the payload of an inner node: the sum of the frequencies below it.
+type Weight int
+
+// Table maps symbols to their codes, e.g. "e" to "010".
+type Table map[string]string
+
+// weight returns the frequency of the subtree under `n`.
+func weight(n *btree.Node) int {
+	if l, ok := n.Payload.(Leaf); ok {
+		return l.Weight
+	}
+	return int(n.Payload.(Weight))
+}
+
+// queue is a min-heap of subtrees by weight. Ties are broken by the order in which the subtrees
+// were made, so that the tree doesn't depend on the iteration order of a map.
+type queue []queued
+
+type queued struct {
+	n   *btree.Node
+	seq int
+}
+
+func (q queue) Len() int { return len(q) }
+func (q queue) Less(i, j int) bool {
+	if wi, wj := weight(q[i].n), weight(q[j].n); wi != wj {
+		return wi < wj
+	}
+	return q[i].seq < q[j].seq
+}
+func (q queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x interface{}) { *q = append(*q, x.(queued)) }
+func (q *queue) Pop() interface{} {
+	last := (*q)[len(*q)-1]
+	*q = (*q)[:len(*q)-1]
+	return last
+}
+
+// Build returns the Huffman tree of the symbols in `freqs`, or `nil` when there are none. Symbols
+// with a frequency of zero or less are left out.
+func Build(freqs map[string]int) *btree.Node {
+	symbols := make([]string, 0, len(freqs))
+	for s, f := range freqs {
+		if f > 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	sort.Strings(symbols)
+	q := queue{}
+	for _, s := range symbols {
+		q = append(q, queued{n: &btree.Node{Payload: Leaf{Symbol: s, Weight: freqs[s]}}, seq: len(q)})
+	}
+	if len(q) == 0 {
+		return nil
+	}
+	heap.Init(&q)
+	for seq := len(q); q.Len() > 1; seq++ {
+		l, r := heap.Pop(&q).(queued).n, heap.Pop(&q).(queued).n
+		heap.Push(&q, queued{n: &btree.Node{Payload: Weight(weight(l) + weight(r)), Left: l, Right: r}, seq: seq})
+	}
+	return q[0].n
+}
+
+// Codes returns the code table of the tree under `root`. A tree of a single symbol gives it the
+// code "0".
+func Codes(root *btree.Node) Table {
+	t := Table{}
+	if root == nil {
+		return t
+	}
+	var walk func(n *btree.Node, code string)
+	walk = func(n *btree.Node, code string) {
+		if l, ok := n.Payload.(Leaf); ok {
+			if code == "" {
+				code = "0"
+			}
+			t[l.Symbol] = code
+			return
+		}
+		walk(n.Left, code+"0")
+		walk(n.Right, code+"1")
+	}
+	walk(root, "")
+	return t
+}
+
+// Encode returns the concatenated codes of `symbols`.
+func (t Table) Encode(symbols []string) (string, error) {
+	var sb strings.Builder
+	for _, s := range symbols {
+		code, ok := t[s]
+		if !ok {
+			return "", fmt.Errorf("huffman: no code for symbol %q", s)
+		}
+		sb.WriteString(code)
+	}
+	return sb.String(), nil
+}
+
+// Decode returns the symbols of the concatenated codes in `bits`, following the tree under
+// `root`.
+func Decode(root *btree.Node, bits string) ([]string, error) {
+	if root == nil {
+		if bits != "" {
+			return nil, fmt.Errorf("huffman: can't decode using an empty tree")
+		}
+		return nil, nil
+	}
+	if l, ok := root.Payload.(Leaf); ok {
+		// A single symbol, coded as "0".
+		out := make([]string, 0, len(bits))
+		for i, b := range bits {
+			if b != '0' {
+				return nil, fmt.Errorf("huffman: unexpected %q at offset %d", b, i)
+			}
+			out = append(out, l.Symbol)
+		}
+		return out, nil
+	}
+	var out []string
+	n := root
+	for i, b := range bits {
+		switch b {
+		case '0':
+			n = n.Left
+		case '1':
+			n = n.Right
+		default:
+			return nil, fmt.Errorf("huffman: unexpected %q at offset %d", b, i)
+		}
+		if l, ok := n.Payload.(Leaf); ok {
+			out = append(out, l.Symbol)
+			n = root
+		}
+	}
+	if n != root {
+		return nil, fmt.Errorf("huffman: truncated code at the end")
+	}
+	return out, nil
+}
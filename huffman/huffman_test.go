@@ -0,0 +1,94 @@
+package huffman
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCodes(t *testing.T) {
+	// The textbook example: f gets 1 bit, c d b get 3, e a 4.
+	freqs := map[string]int{"a": 5, "b": 9, "c": 12, "d": 13, "e": 16, "f": 45}
+	codes := Codes(Build(freqs))
+	want := Table{"f": "0", "c": "100", "d": "101", "a": "1100", "b": "1101", "e": "111"}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("Codes() = %v, want %v", codes, want)
+	}
+	// No code is a prefix of another.
+	for s1, c1 := range codes {
+		for s2, c2 := range codes {
+			if s1 != s2 && strings.HasPrefix(c2, c1) {
+				t.Errorf("code %v of %v is a prefix of code %v of %v", c1, s1, c2, s2)
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, text := range []string{"abracadabra", "aaaa", "the quick brown fox jumps over the lazy dog"} {
+		symbols := strings.Split(text, "")
+		freqs := map[string]int{}
+		for _, s := range symbols {
+			freqs[s]++
+		}
+		root := Build(freqs)
+		bits, err := Codes(root).Encode(symbols)
+		if err != nil {
+			t.Fatalf("Encode(%q) = %v", text, err)
+		}
+		back, err := Decode(root, bits)
+		if err != nil || strings.Join(back, "") != text {
+			t.Errorf("Decode(Encode(%q)) = %q, %v", text, strings.Join(back, ""), err)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if root := Build(map[string]int{"x": 0}); root != nil {
+		t.Errorf("Build() of zero frequencies = %v, want nil", root)
+	}
+	root := Build(map[string]int{"a": 1, "b": 2, "c": 3})
+	if _, err := Codes(root).Encode([]string{"z"}); err == nil {
+		t.Errorf("Encode(z) = nil error, want one")
+	}
+	for _, bits := range []string{"2", "1"} {
+		if _, err := Decode(root, bits); err == nil {
+			t.Errorf("Decode(%q) = nil error, want one", bits)
+		}
+	}
+}
+
+func Example() {
+	freqs := map[string]int{}
+	for _, c := range "mississippi river" {
+		freqs[string(c)]++
+	}
+	codes := Codes(Build(freqs))
+	var symbols []string
+	for s := range codes {
+		symbols = append(symbols, s)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if len(codes[symbols[i]]) != len(codes[symbols[j]]) {
+			return len(codes[symbols[i]]) < len(codes[symbols[j]])
+		}
+		return symbols[i] < symbols[j]
+	})
+	for _, s := range symbols {
+		fmt.Printf("%q %d %s\n", s, freqs[s], codes[s])
+	}
+	bits, _ := codes.Encode(strings.Split("mississippi river", ""))
+	fmt.Println(len(bits), "bits instead of", 8*len("mississippi river"))
+	// Output:
+	// "i" 5 11
+	// "s" 4 00
+	// "p" 2 010
+	// "r" 2 011
+	// " " 1 1000
+	// "e" 1 1001
+	// "m" 1 1010
+	// "v" 1 1011
+	// 46 bits instead of 136
+}
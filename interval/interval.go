@@ -0,0 +1,149 @@
+// Package interval implements an interval tree: a binary tree of half-open intervals
+// `[Start, End)` that finds the intervals overlapping a point or a range in O(log n) plus the
+// number of matches, e.g. for scheduling or for time-range lookups. The tree is a
+// `btree.Augmented` ordered by `Start` (then `End`), in which each node also records the largest
+// `End` in its subtree, so that subtrees that can't overlap a query are skipped. It stays balanced
+// as intervals are added and removed.
+package interval
+
+import (
+	"fmt"
+
+	"github.com/KarelKubat/btree"
+)
+
+// Interval is the half-open range `[Start, End)`. `End` must be larger than `Start`.
+type Interval struct {
+	Start, End int64
+}
+
+// Overlaps returns `true` when `iv` and `other` have a point in common.
+func (iv Interval) Overlaps(other Interval) bool {
+	return iv.Start < other.End && other.Start < iv.End
+}
+
+// String returns `iv` as e.g. "[10, 20)".
+func (iv Interval) String() string {
+	return fmt.Sprintf("[%d, %d)", iv.Start, iv.End)
+}
+
+// Entry is the payload of the nodes of the tree: an interval and its caller-supplied value.
+type Entry struct {
+	Interval
+	Value interface{}
+	max   int64 // the largest `End` in the subtree
+}
+
+// VisitFunc is called for each interval that a query finds, and returns `false` to stop the
+// query.
+type VisitFunc func(e *Entry) bool
+
+// Tree is an interval tree. It is not safe for concurrent use.
+type Tree struct {
+	a *btree.Augmented
+}
+
+// New returns an empty `Tree`.
+func New() *Tree {
+	return &Tree{a: btree.NewAugmented(less, fix)}
+}
+
+func less(a, b *btree.Node) bool {
+	x, y := a.Payload.(*Entry), b.Payload.(*Entry)
+	if x.Start != y.Start {
+		return x.Start < y.Start
+	}
+	return x.End < y.End
+}
+
+// entry returns the payload of `n`.
+func entry(n *btree.Node) *Entry {
+	return n.Payload.(*Entry)
+}
+
+// Len returns the number of intervals.
+func (t *Tree) Len() int {
+	return t.a.Len()
+}
+
+// Tree returns the underlying tree, whose payloads are `*Entry`s, e.g. to walk the intervals in
+// order. It must not be changed.
+func (t *Tree) Tree() *btree.BTree {
+	return t.a.Tree()
+}
+
+// Upsert adds `iv` with `value`, unless the tree already holds `iv`; then the tree is unchanged.
+// The return value is the entry in the tree, and `inserted` is `true` when it was added. Upsert
+// panics on an empty interval.
+func (t *Tree) Upsert(iv Interval, value interface{}) (e *Entry, inserted bool) {
+	if iv.End <= iv.Start {
+		panic(fmt.Sprintf("interval: empty interval %v", iv))
+	}
+	intree, inserted := t.a.Upsert(&btree.Node{Payload: &Entry{Interval: iv, Value: value}})
+	return entry(intree), inserted
+}
+
+// Find returns the entry of `iv`, when the tree holds it.
+func (t *Tree) Find(iv Interval) (e *Entry, found bool) {
+	intree, found := t.a.Find(&btree.Node{Payload: &Entry{Interval: iv}})
+	if !found {
+		return nil, false
+	}
+	return entry(intree), true
+}
+
+// Delete removes `iv`, and returns its entry when the tree held it.
+func (t *Tree) Delete(iv Interval) (e *Entry, deleted bool) {
+	removed, deleted := t.a.Delete(&btree.Node{Payload: &Entry{Interval: iv}})
+	if !deleted {
+		return nil, false
+	}
+	return entry(removed), true
+}
+
+// fix recomputes the largest `End` of the subtree under `n` from those of its sub-nodes.
+func fix(n *btree.Node) {
+	e := entry(n)
+	e.max = e.End
+	if n.Left != nil {
+		e.max = max(e.max, entry(n.Left).max)
+	}
+	if n.Right != nil {
+		e.max = max(e.max, entry(n.Right).max)
+	}
+}
+
+// Rebalance rebalances the underlying tree, see `btree.Augmented.Rebalance()`. The tree stays
+// balanced without it.
+func (t *Tree) Rebalance() {
+	t.a.Rebalance()
+}
+
+// Overlapping calls `visit` for the intervals that overlap `iv`, in order, until `visit` returns
+// `false`.
+func (t *Tree) Overlapping(iv Interval, visit VisitFunc) {
+	overlapping(t.a.Root(), iv, visit)
+}
+
+func overlapping(n *btree.Node, iv Interval, visit VisitFunc) bool {
+	if n == nil || entry(n).max <= iv.Start {
+		return true // nothing in this subtree ends after `iv` starts
+	}
+	if !overlapping(n.Left, iv, visit) {
+		return false
+	}
+	e := entry(n)
+	if e.Start >= iv.End {
+		return true // this node and its right subtree start after `iv` ends
+	}
+	if e.Overlaps(iv) && !visit(e) {
+		return false
+	}
+	return overlapping(n.Right, iv, visit)
+}
+
+// Containing calls `visit` for the intervals that contain `point`, in order, until `visit`
+// returns `false`.
+func (t *Tree) Containing(point int64, visit VisitFunc) {
+	t.Overlapping(Interval{Start: point, End: point + 1}, visit)
+}
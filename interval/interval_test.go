@@ -0,0 +1,129 @@
+package interval
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func collect(t *Tree, iv Interval) []Interval {
+	out := []Interval{}
+	t.Overlapping(iv, func(e *Entry) bool {
+		out = append(out, e.Interval)
+		return true
+	})
+	return out
+}
+
+func TestOverlapping(t *testing.T) {
+	tr := New()
+	for i, iv := range []Interval{{10, 20}, {5, 8}, {15, 30}, {1, 100}, {40, 50}, {8, 10}} {
+		tr.Upsert(iv, i)
+	}
+	for _, test := range []struct {
+		query Interval
+		want  []Interval
+	}{
+		{query: Interval{0, 1}, want: []Interval{}},
+		{query: Interval{8, 9}, want: []Interval{{1, 100}, {8, 10}}},
+		{query: Interval{18, 42}, want: []Interval{{1, 100}, {10, 20}, {15, 30}, {40, 50}}},
+		{query: Interval{100, 200}, want: []Interval{}},
+	} {
+		if got := collect(tr, test.query); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Overlapping(%v) = %v, want %v", test.query, got, test.want)
+		}
+	}
+	var got []Interval
+	tr.Containing(20, func(e *Entry) bool { got = append(got, e.Interval); return true })
+	if want := []Interval{{1, 100}, {15, 30}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Containing(20) = %v, want %v", got, want)
+	}
+}
+
+func TestUpsertFindDelete(t *testing.T) {
+	tr := New()
+	if _, inserted := tr.Upsert(Interval{1, 5}, "a"); !inserted {
+		t.Errorf("Upsert([1, 5)) = false, want true")
+	}
+	if e, inserted := tr.Upsert(Interval{1, 5}, "b"); inserted || e.Value != "a" {
+		t.Errorf("second Upsert([1, 5)) = %v, %v, want the first entry", e.Value, inserted)
+	}
+	if e, found := tr.Find(Interval{1, 5}); !found || e.Value != "a" {
+		t.Errorf("Find([1, 5)) = %v, %v, want a", e, found)
+	}
+	if _, deleted := tr.Delete(Interval{1, 5}); !deleted || tr.Len() != 0 {
+		t.Errorf("Delete([1, 5)) = %v, Len() = %v, want true and 0", deleted, tr.Len())
+	}
+	if _, deleted := tr.Delete(Interval{1, 5}); deleted {
+		t.Errorf("second Delete([1, 5)) = true, want false")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Upsert() of an empty interval doesn't panic")
+		}
+	}()
+	tr.Upsert(Interval{3, 3}, nil)
+}
+
+func TestAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tr := New()
+	all := map[Interval]bool{}
+	random := func() Interval {
+		start := r.Int63n(1000)
+		return Interval{start, start + 1 + r.Int63n(100)}
+	}
+	for i := 0; i < 5000; i++ {
+		iv := random()
+		switch op := r.Intn(10); {
+		case op < 5:
+			tr.Upsert(iv, nil)
+			all[iv] = true
+		case op < 7:
+			// Delete an existing interval, if there is one like it.
+			for other := range all {
+				iv = other
+				break
+			}
+			tr.Delete(iv)
+			delete(all, iv)
+		case op < 8:
+			tr.Rebalance()
+		default:
+			got := map[Interval]bool{}
+			for _, found := range collect(tr, iv) {
+				got[found] = true
+			}
+			want := map[Interval]bool{}
+			for other := range all {
+				if other.Overlaps(iv) {
+					want[other] = true
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("op %v: Overlapping(%v) = %v, want %v", i, iv, got, want)
+			}
+		}
+		if tr.Len() != len(all) {
+			t.Fatalf("op %v: Len() = %v, want %v", i, tr.Len(), len(all))
+		}
+	}
+}
+
+func TestBalanced(t *testing.T) {
+	tr := New()
+	// Intervals that are added in order, like bookings, would make an unbalanced tree a chain.
+	for start := int64(0); start < 4096; start++ {
+		tr.Upsert(Interval{start, start + 10}, nil)
+	}
+	for start := int64(0); start < 4096; start += 2 {
+		tr.Delete(Interval{start, start + 10})
+	}
+	if h := tr.Tree().ShapeStats().Height; h > 2*12+2 {
+		t.Errorf("height = %v for 2048 intervals, want at most %v", h, 2*12+2)
+	}
+	want := []Interval{{991, 1001}, {993, 1003}, {995, 1005}, {997, 1007}, {999, 1009}}
+	if got := collect(tr, Interval{1000, 1001}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Overlapping([1000, 1001)) = %v, want %v", got, want)
+	}
+}
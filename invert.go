@@ -0,0 +1,63 @@
+package btree
+
+// inverse returns the `LessFunc` of the opposite order than `less`.
+func inverse(less LessFunc) LessFunc {
+	return func(a, b *Node) bool {
+		return less(b, a)
+	}
+}
+
+// Invert mirrors the tree in place: the `Left` and `Right` of every node are swapped. The `Less`
+// of the tree is inverted as well, so that the tree stays a valid binary search tree in the
+// opposite order, which all lookups and insertions then use. Hence, `DepthFirstInOrder()` of an
+// inverted tree walks the nodes from the largest to the smallest of the original order, and
+// `DepthFirstReverse()` from the smallest to the largest. Inverting twice restores the tree.
+func (b *BTree) Invert() {
+	defer b.beginWrite("Invert")()
+	b.ResetFinger()
+	if b.Less != nil {
+		b.Less = inverse(b.Less)
+	}
+	stack := []*Node{}
+	if b.Root != nil {
+		stack = append(stack, b.Root)
+	}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		n.Left, n.Right = n.Right, n.Left
+		if n.Left != nil {
+			stack = append(stack, n.Left)
+		}
+		if n.Right != nil {
+			stack = append(stack, n.Right)
+		}
+	}
+}
+
+// Mirror returns the mirror image of the tree: a copy as made by `Clone()`, in which the `Left`
+// and `Right` of every node are swapped, and with the inverse `LessFunc`, see `Invert()`.
+func (b *BTree) Mirror(copyPayload CopyFunc) *BTree {
+	m := b.Clone(copyPayload)
+	m.Invert()
+	return m
+}
+
+// IsSymmetric returns `true` when the tree is its own mirror image: the left subtree of the root
+// has the same shape as the mirrored right subtree. When `eq` is not `nil`, then the nodes at
+// mirrored positions must furthermore be equal according to `eq`.
+func (b *BTree) IsSymmetric(eq EqualFunc) bool {
+	defer b.beginRead("IsSymmetric")()
+	return b.Root == nil || mirroredFrom(b.Root.Left, b.Root.Right, eq)
+}
+
+func mirroredFrom(a, b *Node, eq EqualFunc) bool {
+	switch {
+	case a == nil || b == nil:
+		return a == nil && b == nil
+	case eq != nil && !eq(a, b):
+		return false
+	default:
+		return mirroredFrom(a.Left, b.Right, eq) && mirroredFrom(a.Right, b.Left, eq)
+	}
+}
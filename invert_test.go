@@ -0,0 +1,86 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInvert(t *testing.T) {
+	b := newIntTree(50, 20, 80, 10, 30, 70)
+	b.Invert()
+	if got, want := inOrderInts(b), []int{80, 70, 50, 30, 20, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order of the inverted tree = %v, want %v", got, want)
+	}
+	reverse := []int{}
+	b.DepthFirstReverse(func(n *Node) { reverse = append(reverse, n.Payload.(int)) })
+	if want := []int{10, 20, 30, 50, 70, 80}; !reflect.DeepEqual(reverse, want) {
+		t.Errorf("reverse order of the inverted tree = %v, want %v", reverse, want)
+	}
+	if got := b.Root.Left.Payload; got != 80 {
+		t.Errorf("left of the root of the inverted tree = %v, want 80", got)
+	}
+
+	// The inverted tree is a valid tree of the inverted order.
+	b.Upsert(&Node{Payload: 60})
+	b.Delete(&Node{Payload: 20})
+	if _, found := b.Find(&Node{Payload: 60}); !found {
+		t.Errorf("Find(60) in the inverted tree = false, want true")
+	}
+	if got, want := inOrderInts(b), []int{80, 70, 60, 50, 30, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order after changes = %v, want %v", got, want)
+	}
+	if got, want := b.Min().Payload, 80; got != want {
+		t.Errorf("Min() of the inverted tree = %v, want %v", got, want)
+	}
+
+	b.Invert()
+	if got, want := inOrderInts(b), []int{10, 30, 50, 60, 70, 80}; !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order after inverting twice = %v, want %v", got, want)
+	}
+
+	twice := newIntTree(50, 20, 80, 10, 30, 70)
+	twice.Invert()
+	twice.Invert()
+	if !twice.StructurallyEqual(newIntTree(50, 20, 80, 10, 30, 70), intEqual) {
+		t.Errorf("inverting twice doesn't restore the shape")
+	}
+}
+
+func TestMirror(t *testing.T) {
+	b := newIntTree(2, 1, 3, 4)
+	m := b.Mirror(nil)
+	if got, want := inOrderInts(m), []int{4, 3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order of the mirror = %v, want %v", got, want)
+	}
+	if got, want := inOrderInts(b), []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("in-order of the original = %v, want %v", got, want)
+	}
+	if got, want := m.SExpr(intLabel), "(2 (3 (4)) (1))"; got != want {
+		t.Errorf("mirror = %v, want %v", got, want)
+	}
+}
+
+func TestIsSymmetric(t *testing.T) {
+	mirrored := &BTree{Root: &Node{
+		Payload: 1,
+		Left:    &Node{Payload: 2, Left: &Node{Payload: 3}},
+		Right:   &Node{Payload: 2, Right: &Node{Payload: 3}},
+	}}
+	for _, test := range []struct {
+		desc string
+		b    *BTree
+		eq   EqualFunc
+		want bool
+	}{
+		{desc: "empty", b: New(intLess), want: true},
+		{desc: "root only", b: newIntTree(1), want: true},
+		{desc: "full", b: newIntTree(2, 1, 3), want: true},
+		{desc: "full, payloads differ", b: newIntTree(2, 1, 3), eq: intEqual, want: false},
+		{desc: "lopsided", b: newIntTree(2, 1, 3, 4), want: false},
+		{desc: "mirrored payloads", b: mirrored, eq: intEqual, want: true},
+	} {
+		if got := test.b.IsSymmetric(test.eq); got != test.want {
+			t.Errorf("%v: IsSymmetric() = %v, want %v", test.desc, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package btree
+
+import "time"
+
+// iterDepth is the depth of trees that `inorderIter` walks without allocating.
+const iterDepth = 48
+
+// inorderIter yields the nodes of a (sub)tree one by one, in the same order as
+// `DepthFirstInOrder()`. It keeps an explicit stack so that two trees can be walked side by side.
+// The stack is a fixed array, so that an iterator that is a local variable doesn't allocate;
+// only when the tree is deeper than `iterDepth`, the stack continues in `deeper`. Nodes that are
+// marked deleted (see `BTree.LazyDelete`) are skipped, and so are nodes that have expired, unless
+// the walk was started with `initLinked()`.
+type inorderIter struct {
+	stack  [iterDepth]*Node
+	depth  int
+	deeper []*Node
+	dead   map[*Node]struct{}
+	expiry map[*Node]time.Time
+	now    time.Time
+}
+
+// init starts the walk of `b`. Whether a node has expired is judged by the time at the start.
+func (it *inorderIter) init(b *BTree) {
+	it.initLinked(b)
+	if len(b.expiry) > 0 {
+		it.expiry, it.now = b.expiry, b.now()
+	}
+}
+
+// initLinked starts the walk of `b` that also yields the nodes that have expired, but are still
+// linked into the tree.
+func (it *inorderIter) initLinked(b *BTree) {
+	*it = inorderIter{dead: b.tombstones}
+	it.pushLeft(b.Root)
+}
+
+func (it *inorderIter) pushLeft(n *Node) {
+	for ; n != nil; n = n.Left {
+		if it.depth < iterDepth {
+			it.stack[it.depth] = n
+			it.depth++
+		} else {
+			it.deeper = append(it.deeper, n)
+		}
+	}
+}
+
+// next returns the next node, or `nil` when the walk is done.
+func (it *inorderIter) next() *Node {
+	for {
+		n := it.pop()
+		if n == nil {
+			return nil
+		}
+		if _, dead := it.dead[n]; dead {
+			continue
+		}
+		if at, ok := it.expiry[n]; ok && at.Before(it.now) {
+			continue
+		}
+		return n
+	}
+}
+
+func (it *inorderIter) pop() *Node {
+	var n *Node
+	switch {
+	case len(it.deeper) > 0:
+		n = it.deeper[len(it.deeper)-1]
+		it.deeper = it.deeper[:len(it.deeper)-1]
+	case it.depth > 0:
+		it.depth--
+		n = it.stack[it.depth]
+	default:
+		return nil
+	}
+	it.pushLeft(n.Right)
+	return n
+}
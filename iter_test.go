@@ -0,0 +1,71 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestInorderIterDeep(t *testing.T) {
+	// A chain is far deeper than the fixed stack of the iterator.
+	vals := make([]int, 3*iterDepth)
+	for i := range vals {
+		vals[i] = len(vals) - i
+	}
+	b := newIntTree(vals...)
+	var got []int
+	var it inorderIter
+	it.init(b)
+	for n := it.next(); n != nil; n = it.next() {
+		got = append(got, n.Payload.(int))
+	}
+	if want := inOrderInts(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("inorderIter = %v, want %v", got, want)
+	}
+}
+
+// TestZeroAllocIteration guards that walking a tree doesn't allocate.
+func TestZeroAllocIteration(t *testing.T) {
+	b := BuildParallel(intLess, intNodes(rand.New(rand.NewSource(1)).Perm(1000)...))
+	other := b.Clone(nil)
+	walk := func(*Node) {}
+	visit := func(*Node) bool { return true }
+	for _, test := range []struct {
+		desc string
+		fn   func()
+	}{
+		{desc: "DepthFirstInOrder", fn: func() { b.DepthFirstInOrder(walk) }},
+		{desc: "DepthFirstReverse", fn: func() { b.DepthFirstReverse(walk) }},
+		{desc: "AscendRange", fn: func() { b.AscendRange(nil, nil, visit) }},
+		{desc: "DescendRange", fn: func() { b.DescendRange(nil, nil, visit) }},
+		{desc: "Equal", fn: func() { b.Equal(other, intEqual) }},
+		{desc: "IsSubsetOf", fn: func() { b.IsSubsetOf(other) }},
+	} {
+		if allocs := testing.AllocsPerRun(10, test.fn); allocs != 0 {
+			t.Errorf("%s allocates %v times, want 0", test.desc, allocs)
+		}
+	}
+}
+
+func BenchmarkIteration(b *testing.B) {
+	t := BuildParallel(intLess, intNodes(rand.New(rand.NewSource(1)).Perm(100000)...))
+	other := t.Clone(nil)
+	b.Run("DepthFirstInOrder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			t.DepthFirstInOrder(func(*Node) {})
+		}
+	})
+	b.Run("AscendRange", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			t.AscendRange(nil, nil, func(*Node) bool { return true })
+		}
+	})
+	b.Run("Equal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			t.Equal(other, intEqual)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package btree
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonNode is how a `Node` is represented in JSON.
+type jsonNode struct {
+	Payload json.RawMessage `json:"payload"`
+	Left    *jsonNode       `json:"left,omitempty"`
+	Right   *jsonNode       `json:"right,omitempty"`
+}
+
+// jsonTree is how a `BTree` is represented in JSON.
+type jsonTree struct {
+	Root *jsonNode `json:"root"`
+}
+
+// MarshalJSON implements `json.Marshaler`. The shape of the tree is preserved: each node is an
+// object with a `payload` and optional `left` and `right` sub-nodes. Payloads are encoded using
+// `encoding/json`, so they should have exported fields (or implement `json.Marshaler`).
+func (b *BTree) MarshalJSON() ([]byte, error) {
+	root, err := marshalJSONFrom(b.Root)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonTree{Root: root})
+}
+
+func marshalJSONFrom(n *Node) (*jsonNode, error) {
+	if n == nil {
+		return nil, nil
+	}
+	payload, err := json.Marshal(n.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("btree: cannot marshal payload %v: %v", n.Payload, err)
+	}
+	left, err := marshalJSONFrom(n.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := marshalJSONFrom(n.Right)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonNode{Payload: payload, Left: left, Right: right}, nil
+}
+
+// UnmarshalJSON implements `json.Unmarshaler`. It replaces the nodes of the tree with the ones in
+// `data`, in the same shape. The `LessFunc` is kept, so the tree should be instantiated using
+// `New()` before unmarshaling into it. Payloads are decoded using the hook `UnmarshalPayload`,
+// when set.
+func (b *BTree) UnmarshalJSON(data []byte) error {
+	var t jsonTree
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	root, err := b.unmarshalJSONFrom(t.Root)
+	if err != nil {
+		return err
+	}
+	b.load(root)
+	return nil
+}
+
+func (b *BTree) unmarshalJSONFrom(j *jsonNode) (*Node, error) {
+	if j == nil {
+		return nil, nil
+	}
+	n := &Node{}
+	if b.UnmarshalPayload != nil {
+		p, err := b.UnmarshalPayload(j.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("btree: cannot unmarshal payload %s: %v", j.Payload, err)
+		}
+		n.Payload = p
+	} else if err := json.Unmarshal(j.Payload, &n.Payload); err != nil {
+		return nil, fmt.Errorf("btree: cannot unmarshal payload %s: %v", j.Payload, err)
+	}
+	var err error
+	if n.Left, err = b.unmarshalJSONFrom(j.Left); err != nil {
+		return nil, err
+	}
+	if n.Right, err = b.unmarshalJSONFrom(j.Right); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
@@ -0,0 +1,158 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type person struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func personLess(a, b *Node) bool {
+	return a.Payload.(*person).Name < b.Payload.(*person).Name
+}
+
+func personEqual(a, b *Node) bool {
+	return *a.Payload.(*person) == *b.Payload.(*person)
+}
+
+func newPersonTree(names ...string) *BTree {
+	b := New(personLess)
+	for i, name := range names {
+		b.Upsert(&Node{Payload: &person{Name: name, Count: i}})
+	}
+	return b
+}
+
+func unmarshalPerson(data []byte) (interface{}, error) {
+	p := &person{}
+	err := json.Unmarshal(data, p)
+	return p, err
+}
+
+func TestJSON(t *testing.T) {
+	b := newPersonTree("mary", "john", "zoe", "adam")
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("json.Marshal() = %v", err)
+	}
+
+	back := New(personLess)
+	back.UnmarshalPayload = unmarshalPerson
+	if err := json.Unmarshal(data, back); err != nil {
+		t.Fatalf("json.Unmarshal(%s) = %v", data, err)
+	}
+	if !b.StructurallyEqual(back, personEqual) {
+		t.Errorf("json.Unmarshal(%s) didn't restore the tree", data)
+	}
+	if _, found := back.Find(&Node{Payload: &person{Name: "zoe"}}); !found {
+		t.Errorf("Find() fails on unmarshaled tree")
+	}
+}
+
+func TestJSONUntypedPayloads(t *testing.T) {
+	data := []byte(`{"root":{"payload":2,"left":{"payload":1},"right":{"payload":3}}}`)
+	b := New(func(a, b *Node) bool { return a.Payload.(float64) < b.Payload.(float64) })
+	if err := json.Unmarshal(data, b); err != nil {
+		t.Fatalf("json.Unmarshal(%s) = %v", data, err)
+	}
+	if got, want := b.Root.Left.Payload, 1.0; got != want {
+		t.Errorf("json.Unmarshal(%s): left payload = %v, want %v", data, got, want)
+	}
+
+	out, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("json.Marshal() = %v", err)
+	}
+	if string(out) != string(data) {
+		t.Errorf("json.Marshal() = %s, want %s", out, data)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	b := New(personLess)
+	b.UnmarshalPayload = unmarshalPerson
+	if err := json.Unmarshal([]byte(`{"root":{"payload":"not a person"}}`), b); err == nil {
+		t.Errorf("json.Unmarshal() of a bad payload succeeds, want error")
+	}
+	b = New(intLess)
+	b.Upsert(&Node{Payload: func() {}})
+	if _, err := json.Marshal(b); err == nil {
+		t.Errorf("json.Marshal() of a bad payload succeeds, want error")
+	}
+}
+
+func TestLoadResetsDerivedState(t *testing.T) {
+	src := newIntTree(2, 1, 3, 5)
+	unmarshalInt := func(data []byte) (interface{}, error) {
+		var v int
+		err := json.Unmarshal(data, &v)
+		return v, err
+	}
+	for _, test := range []struct {
+		name string
+		load func(b *BTree) error
+	}{
+		{"UnmarshalJSON", func(b *BTree) error {
+			data, err := json.Marshal(src)
+			if err != nil {
+				return err
+			}
+			b.UnmarshalPayload = unmarshalInt
+			return b.UnmarshalJSON(data)
+		}},
+		{"ReadBinary", func(b *BTree) error {
+			var buf bytes.Buffer
+			if err := src.WriteBinary(&buf, intCodec{}); err != nil {
+				return err
+			}
+			return b.ReadBinary(&buf, intCodec{})
+		}},
+		{"ReadLevelOrder", func(b *BTree) error {
+			return b.ReadLevelOrder(src.LevelOrder(intLabel), parseInt)
+		}},
+		{"ReadSExpr", func(b *BTree) error {
+			return b.ReadSExpr(src.SExpr(intLabel), parseInt)
+		}},
+	} {
+		clock := &fakeClock{t: time.Unix(1000, 0)}
+		hash := func(n *Node) uint64 { return uint64(n.Payload.(int)) }
+		b := New(intLess)
+		b.Now = clock.now
+		b.LazyDelete = true
+		b.Bloom = NewBloom(100, 0.01, hash)
+		b.EnableChecksum(hash)
+		for _, v := range []int{1, 2, 3} {
+			b.Upsert(&Node{Payload: v})
+		}
+		b.Delete(&Node{Payload: 2})
+		b.UpsertWithExpiry(&Node{Payload: 4}, clock.t.Add(time.Second))
+		clock.t = clock.t.Add(time.Minute)
+
+		if err := test.load(b); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got, want := inOrderInts(b), []int{1, 2, 3, 5}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: in-order after reloading = %v, want %v", test.name, got, want)
+		}
+		for _, v := range []int{2, 5} {
+			if _, found := b.Find(&Node{Payload: v}); !found {
+				t.Errorf("%s: Find(%v) after reloading = false, want true", test.name, v)
+			}
+		}
+		if got := b.Tombstones(); got != 0 {
+			t.Errorf("%s: Tombstones() after reloading = %v, want 0", test.name, got)
+		}
+		if got := b.ExpireBefore(clock.t); got != 0 {
+			t.Errorf("%s: ExpireBefore() after reloading = %v, want 0", test.name, got)
+		}
+		if err := b.CheckIntegrity(); err != nil {
+			t.Errorf("%s: CheckIntegrity() after reloading = %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+// Package kd implements a k-d tree: a binary tree of points in k dimensions, which finds the
+// nearest point to a given one (`Nearest()`) and the points within a rectangle (`InRange()`)
+// without visiting most of the tree. Each level of the tree splits the points along one
+// dimension, in turn: the root by the first coordinate, its sub-nodes by the second, and so on.
+//
+// The tree is made of the caller's `*btree.Node`s, whose `Left` and `Right` pointers it sets, like
+// a `btree.BTree`. The coordinates of a node's point are returned by a `PointFunc`, e.g. from
+// fields of the payload.
+package kd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/KarelKubat/btree"
+)
+
+// PointFunc returns the coordinates of the point of a node. There must be as many as the tree
+// has dimensions, and they must not change while the node is in the tree.
+type PointFunc func(n *btree.Node) []float64
+
+// Tree is a k-d tree. It is not safe for concurrent use.
+type Tree struct {
+	// Root is the tree's root.
+	Root *btree.Node
+
+	dims  int
+	point PointFunc
+	len   int
+}
+
+// New returns an empty `Tree` of points in `dims` dimensions, as returned by `point`.
+func New(dims int, point PointFunc) *Tree {
+	return &Tree{dims: dims, point: point}
+}
+
+// Len returns the number of nodes.
+func (t *Tree) Len() int {
+	return t.len
+}
+
+// pointOf returns the point of `n`, and panics when it has the wrong number of dimensions.
+func (t *Tree) pointOf(n *btree.Node) []float64 {
+	p := t.point(n)
+	if len(p) != t.dims {
+		panic(fmt.Sprintf("kd: point has %d dimensions, want %d", len(p), t.dims))
+	}
+	return p
+}
+
+func equal(a, b []float64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Upsert adds `n`, unless a node with the same point is present. The return value `intree` is
+// the node in the tree, and `inserted` is `true` when `n` was added. Points that are equal along
+// the dimension that splits a level go to the right.
+func (t *Tree) Upsert(n *btree.Node) (intree *btree.Node, inserted bool) {
+	p := t.pointOf(n)
+	slot := &t.Root
+	for depth := 0; *slot != nil; depth++ {
+		at := t.pointOf(*slot)
+		if equal(p, at) {
+			return *slot, false
+		}
+		if axis := depth % t.dims; p[axis] < at[axis] {
+			slot = &(*slot).Left
+		} else {
+			slot = &(*slot).Right
+		}
+	}
+	n.Left, n.Right = nil, nil
+	*slot = n
+	t.len++
+	return n, true
+}
+
+// Find returns the node with the given point. The return value `found` is `false` when there is
+// no such node.
+func (t *Tree) Find(p []float64) (intree *btree.Node, found bool) {
+	for depth, at := 0, t.Root; at != nil; depth++ {
+		ap := t.pointOf(at)
+		if equal(p, ap) {
+			return at, true
+		}
+		if axis := depth % t.dims; p[axis] < ap[axis] {
+			at = at.Left
+		} else {
+			at = at.Right
+		}
+	}
+	return nil, false
+}
+
+// Delete removes the node with the given point. The return value `removed` is the removed node,
+// and `deleted` is `true` when there was such a node.
+func (t *Tree) Delete(p []float64) (removed *btree.Node, deleted bool) {
+	t.Root, removed = t.deleteFrom(t.Root, p, 0)
+	if removed == nil {
+		return nil, false
+	}
+	t.len--
+	return removed, true
+}
+
+// deleteFrom removes the node with point `p` from the subtree under `from`, which is at the
+// given depth, and returns the new top of the subtree plus the removed node (or `nil`). The
+// removed node is replaced by the node of its right subtree that is smallest along the splitting
+// dimension, or when there is none, by that of its left subtree, which then becomes the right
+// one.
+func (t *Tree) deleteFrom(from *btree.Node, p []float64, depth int) (top, removed *btree.Node) {
+	if from == nil {
+		return nil, nil
+	}
+	axis := depth % t.dims
+	fp := t.pointOf(from)
+	if !equal(p, fp) {
+		if p[axis] < fp[axis] {
+			from.Left, removed = t.deleteFrom(from.Left, p, depth+1)
+		} else {
+			from.Right, removed = t.deleteFrom(from.Right, p, depth+1)
+		}
+		return from, removed
+	}
+	switch {
+	case from.Right != nil:
+		top = t.minimum(from.Right, axis, depth+1)
+		right, _ := t.deleteFrom(from.Right, t.pointOf(top), depth+1)
+		top.Left, top.Right = from.Left, right
+	case from.Left != nil:
+		top = t.minimum(from.Left, axis, depth+1)
+		right, _ := t.deleteFrom(from.Left, t.pointOf(top), depth+1)
+		top.Left, top.Right = nil, right
+	}
+	from.Left, from.Right = nil, nil
+	return top, from
+}
+
+// minimum returns the node of the subtree under `n`, which is at the given depth, that is
+// smallest along `axis`.
+func (t *Tree) minimum(n *btree.Node, axis, depth int) *btree.Node {
+	if n == nil {
+		return nil
+	}
+	if depth%t.dims == axis {
+		if n.Left == nil {
+			return n
+		}
+		return t.minimum(n.Left, axis, depth+1)
+	}
+	min := n
+	for _, sub := range []*btree.Node{n.Left, n.Right} {
+		sub = t.minimum(sub, axis, depth+1)
+		if sub != nil && t.pointOf(sub)[axis] < t.pointOf(min)[axis] {
+			min = sub
+		}
+	}
+	return min
+}
+
+// Build replaces the contents of the tree with `nodes`, as a balanced tree: each level splits its
+// points at their median. The points of the nodes must be distinct.
+func (t *Tree) Build(nodes []*btree.Node) {
+	nodes = append([]*btree.Node(nil), nodes...)
+	t.Root = t.build(nodes, 0)
+	t.len = len(nodes)
+}
+
+func (t *Tree) build(nodes []*btree.Node, depth int) *btree.Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	axis := depth % t.dims
+	sort.Slice(nodes, func(i, j int) bool {
+		return t.pointOf(nodes[i])[axis] < t.pointOf(nodes[j])[axis]
+	})
+	// Points that are equal to the median along the axis must go to the right.
+	m := len(nodes) / 2
+	for m > 0 && t.pointOf(nodes[m-1])[axis] == t.pointOf(nodes[m])[axis] {
+		m--
+	}
+	n := nodes[m]
+	n.Left, n.Right = t.build(nodes[:m], depth+1), t.build(nodes[m+1:], depth+1)
+	return n
+}
+
+// Rebalance rebuilds the tree from its nodes, see `Build()`.
+func (t *Tree) Rebalance() {
+	nodes := []*btree.Node{}
+	var collect func(n *btree.Node)
+	collect = func(n *btree.Node) {
+		if n == nil {
+			return
+		}
+		collect(n.Left)
+		nodes = append(nodes, n)
+		collect(n.Right)
+	}
+	collect(t.Root)
+	t.Build(nodes)
+}
+
+// Nearest returns the node whose point is nearest to `p` by Euclidean distance, and that
+// distance. It returns `nil` when the tree is empty.
+func (t *Tree) Nearest(p []float64) (nearest *btree.Node, dist float64) {
+	best := math.Inf(1)
+	var search func(n *btree.Node, depth int)
+	search = func(n *btree.Node, depth int) {
+		if n == nil {
+			return
+		}
+		np := t.pointOf(n)
+		if d := dist2(p, np); d < best {
+			nearest, best = n, d
+		}
+		axis := depth % t.dims
+		near, far := n.Left, n.Right
+		if p[axis] >= np[axis] {
+			near, far = far, near
+		}
+		search(near, depth+1)
+		// The far side can only hold a nearer point when the splitting plane is nearer.
+		if d := p[axis] - np[axis]; d*d < best {
+			search(far, depth+1)
+		}
+	}
+	search(t.Root, 0)
+	if nearest == nil {
+		return nil, 0
+	}
+	return nearest, math.Sqrt(best)
+}
+
+// dist2 returns the square of the Euclidean distance between `a` and `b`.
+func dist2(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+// InRange calls `walk` for each node whose point lies within the rectangle from `lo` to `hi`,
+// bounds included: for each dimension `i`, `lo[i] <= p[i] <= hi[i]`.
+func (t *Tree) InRange(lo, hi []float64, walk btree.WalkFunc) {
+	var search func(n *btree.Node, depth int)
+	search = func(n *btree.Node, depth int) {
+		if n == nil {
+			return
+		}
+		np := t.pointOf(n)
+		axis := depth % t.dims
+		if lo[axis] < np[axis] {
+			search(n.Left, depth+1)
+		}
+		inside := true
+		for i := range np {
+			if np[i] < lo[i] || np[i] > hi[i] {
+				inside = false
+				break
+			}
+		}
+		if inside {
+			walk(n)
+		}
+		if hi[axis] >= np[axis] {
+			search(n.Right, depth+1)
+		}
+	}
+	search(t.Root, 0)
+}
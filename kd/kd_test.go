@@ -0,0 +1,131 @@
+package kd
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/KarelKubat/btree"
+)
+
+// pt is a payload with a point.
+type pt struct {
+	x, y float64
+}
+
+func point(n *btree.Node) []float64 {
+	p := n.Payload.(pt)
+	return []float64{p.x, p.y}
+}
+
+func node(x, y float64) *btree.Node {
+	return &btree.Node{Payload: pt{x, y}}
+}
+
+// sorted returns the payloads of `nodes` in a fixed order, for comparisons.
+func sorted(nodes []*btree.Node) []pt {
+	out := []pt{}
+	for _, n := range nodes {
+		out = append(out, n.Payload.(pt))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].x != out[j].x {
+			return out[i].x < out[j].x
+		}
+		return out[i].y < out[j].y
+	})
+	return out
+}
+
+func TestNearestAndInRange(t *testing.T) {
+	tr := New(2, point)
+	for _, p := range []pt{{2, 3}, {5, 4}, {9, 6}, {4, 7}, {8, 1}, {7, 2}} {
+		tr.Upsert(node(p.x, p.y))
+	}
+	if _, inserted := tr.Upsert(node(9, 6)); inserted {
+		t.Errorf("Upsert() of a present point = true, want false")
+	}
+	if n, d := tr.Nearest([]float64{9, 2}); n.Payload != (pt{8, 1}) || math.Abs(d-math.Sqrt2) > 1e-9 {
+		t.Errorf("Nearest(9, 2) = %v, %v, want {8 1}, %v", n.Payload, d, math.Sqrt2)
+	}
+	got := []*btree.Node{}
+	tr.InRange([]float64{4, 1}, []float64{8, 4}, func(n *btree.Node) { got = append(got, n) })
+	if want := []pt{{5, 4}, {7, 2}, {8, 1}}; !reflect.DeepEqual(sorted(got), want) {
+		t.Errorf("InRange(4..8, 1..4) = %v, want %v", sorted(got), want)
+	}
+	if n, _ := New(2, point).Nearest([]float64{0, 0}); n != nil {
+		t.Errorf("Nearest() of an empty tree = %v, want nil", n)
+	}
+}
+
+func TestWrongDimensions(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "kd: point has 2 dimensions, want 3" {
+			t.Errorf("Upsert() with a 2-d point in a 3-d tree panicked with %v", r)
+		}
+	}()
+	New(3, point).Upsert(node(1, 2))
+}
+
+func TestAgainstBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tr := New(2, point)
+	present := map[pt]*btree.Node{}
+	for i := 0; i < 3000; i++ {
+		// Small integer coordinates make for many equal coordinates.
+		p := pt{float64(rng.Intn(30)), float64(rng.Intn(30))}
+		switch op := rng.Intn(10); {
+		case op < 6:
+			n := node(p.x, p.y)
+			if _, inserted := tr.Upsert(n); inserted != (present[p] == nil) {
+				t.Fatalf("op %v: Upsert(%v) = %v", i, p, inserted)
+			}
+			if present[p] == nil {
+				present[p] = n
+			}
+		case op < 9:
+			if _, deleted := tr.Delete([]float64{p.x, p.y}); deleted != (present[p] != nil) {
+				t.Fatalf("op %v: Delete(%v) = %v", i, p, deleted)
+			}
+			delete(present, p)
+		default:
+			tr.Rebalance()
+		}
+		if tr.Len() != len(present) {
+			t.Fatalf("op %v: Len() = %v, want %v", i, tr.Len(), len(present))
+		}
+		if i%50 != 0 {
+			continue
+		}
+
+		q := []float64{rng.Float64() * 30, rng.Float64() * 30}
+		best := math.Inf(1)
+		for p := range present {
+			best = math.Min(best, math.Hypot(p.x-q[0], p.y-q[1]))
+		}
+		if n, d := tr.Nearest(q); len(present) > 0 && (n == nil || math.Abs(d-best) > 1e-9) {
+			t.Fatalf("op %v: Nearest(%v) = %v, %v, want distance %v", i, q, n, d, best)
+		}
+
+		lo := []float64{float64(rng.Intn(30)), float64(rng.Intn(30))}
+		hi := []float64{lo[0] + float64(rng.Intn(10)), lo[1] + float64(rng.Intn(10))}
+		want := []*btree.Node{}
+		for p, n := range present {
+			if p.x >= lo[0] && p.x <= hi[0] && p.y >= lo[1] && p.y <= hi[1] {
+				want = append(want, n)
+			}
+		}
+		got := []*btree.Node{}
+		tr.InRange(lo, hi, func(n *btree.Node) { got = append(got, n) })
+		if !reflect.DeepEqual(sorted(got), sorted(want)) {
+			t.Fatalf("op %v: InRange(%v, %v) = %v, want %v", i, lo, hi, sorted(got), sorted(want))
+		}
+	}
+	for p, n := range present {
+		if got, found := tr.Find([]float64{p.x, p.y}); !found || got != n {
+			t.Errorf("Find(%v) = %v, %v, want %v, true", p, got, found, n)
+		}
+	}
+}
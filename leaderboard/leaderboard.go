@@ -0,0 +1,130 @@
+// Package leaderboard ranks keys by score. It combines an order-statistics tree of the scores
+// (see package `github.com/KarelKubat/btree/orderstat`) with an index of the keys, so that a key's
+// score is updated, its rank is found, and its neighbors on the board are listed in O(log n),
+// without walking the entries that rank above it.
+package leaderboard
+
+import (
+	"github.com/KarelKubat/btree"
+	"github.com/KarelKubat/btree/orderstat"
+)
+
+// Entry is a key with its score.
+type Entry struct {
+	Key   string
+	Score int64
+}
+
+// less orders entries from the highest score down; equal scores are ordered by key.
+func less(a, b *btree.Node) bool {
+	ea, eb := a.Payload.(Entry), b.Payload.(Entry)
+	if ea.Score != eb.Score {
+		return ea.Score > eb.Score
+	}
+	return ea.Key < eb.Key
+}
+
+// Board is a leaderboard. It is not safe for concurrent use.
+type Board struct {
+	scores *orderstat.Tree
+	keys   map[string]*btree.Node
+}
+
+// New returns an empty `Board`.
+func New() *Board {
+	return &Board{
+		scores: orderstat.New(less),
+		keys:   map[string]*btree.Node{},
+	}
+}
+
+// Len returns the number of keys.
+func (b *Board) Len() int {
+	return len(b.keys)
+}
+
+// UpdateScore sets the score of `key`, which is added when it isn't on the board.
+func (b *Board) UpdateScore(key string, score int64) {
+	if n, ok := b.keys[key]; ok {
+		b.scores.Delete(n)
+	}
+	n := &btree.Node{Payload: Entry{Key: key, Score: score}}
+	b.scores.Upsert(n)
+	b.keys[key] = n
+}
+
+// Score returns the score of `key`. The return value `ok` is `false` when `key` isn't on the
+// board.
+func (b *Board) Score(key string) (score int64, ok bool) {
+	n, ok := b.keys[key]
+	if !ok {
+		return 0, false
+	}
+	return n.Payload.(Entry).Score, true
+}
+
+// Remove takes `key` off the board. The return value is `false` when it wasn't on it.
+func (b *Board) Remove(key string) bool {
+	n, ok := b.keys[key]
+	if !ok {
+		return false
+	}
+	b.scores.Delete(n)
+	delete(b.keys, key)
+	return true
+}
+
+// RankOf returns the rank of `key`: 0 for the highest score, 1 for the next, and so on. Keys with
+// equal scores are ranked by key. The return value `ok` is `false` when `key` isn't on the board.
+func (b *Board) RankOf(key string) (rank int, ok bool) {
+	n, ok := b.keys[key]
+	if !ok {
+		return 0, false
+	}
+	return b.scores.Rank(n), true
+}
+
+// At returns the entry at the given rank. The return value `ok` is `false` when the rank is out
+// of range.
+func (b *Board) At(rank int) (e Entry, ok bool) {
+	n := b.scores.Select(rank)
+	if n == nil {
+		return Entry{}, false
+	}
+	return n.Payload.(Entry), true
+}
+
+// TopN returns the entries of the `n` highest ranks, from the top down. There are fewer when the
+// board holds fewer keys.
+func (b *Board) TopN(n int) []Entry {
+	return b.ranks(0, n)
+}
+
+// Neighbors returns the entries that rank at most `k` places above or below `key`, from the top
+// down, including that of `key` itself. The return value `ok` is `false` when `key` isn't on the
+// board.
+func (b *Board) Neighbors(key string, k int) (entries []Entry, ok bool) {
+	rank, ok := b.RankOf(key)
+	if !ok {
+		return nil, false
+	}
+	from := max(rank-k, 0)
+	return b.ranks(from, rank+k+1), true
+}
+
+// ranks returns the entries of the ranks from `from` up to, but not including, `to`.
+func (b *Board) ranks(from, to int) []Entry {
+	to = min(to, b.Len())
+	entries := []Entry{}
+	for rank := from; rank < to; rank++ {
+		e, _ := b.At(rank)
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Rebalance rebalances the tree of scores, see `orderstat.Tree.Rebalance()`. The tree stays
+// balanced without it.
+func (b *Board) Rebalance() {
+	b.scores.Rebalance()
+}
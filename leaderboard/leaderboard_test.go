@@ -0,0 +1,117 @@
+package leaderboard
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func newBoard() *Board {
+	b := New()
+	for key, score := range map[string]int64{"ann": 30, "bob": 50, "cid": 10, "dee": 50, "eve": 20} {
+		b.UpdateScore(key, score)
+	}
+	return b
+}
+
+func TestRankOf(t *testing.T) {
+	b := newBoard()
+	for _, test := range []struct {
+		key  string
+		rank int
+		ok   bool
+	}{
+		{key: "bob", rank: 0, ok: true},
+		{key: "dee", rank: 1, ok: true},
+		{key: "ann", rank: 2, ok: true},
+		{key: "eve", rank: 3, ok: true},
+		{key: "cid", rank: 4, ok: true},
+		{key: "zed", ok: false},
+	} {
+		if rank, ok := b.RankOf(test.key); rank != test.rank || ok != test.ok {
+			t.Errorf("RankOf(%q) = %v, %v, want %v, %v", test.key, rank, ok, test.rank, test.ok)
+		}
+	}
+
+	b.UpdateScore("cid", 60)
+	if rank, _ := b.RankOf("cid"); rank != 0 {
+		t.Errorf("RankOf(cid) after UpdateScore() = %v, want 0", rank)
+	}
+	if score, _ := b.Score("cid"); score != 60 {
+		t.Errorf("Score(cid) = %v, want 60", score)
+	}
+	if !b.Remove("bob") || b.Remove("bob") {
+		t.Errorf("Remove(bob) twice didn't succeed exactly once")
+	}
+	if rank, _ := b.RankOf("dee"); rank != 1 || b.Len() != 4 {
+		t.Errorf("RankOf(dee) after Remove() = %v with Len() %v, want 1 with 4", rank, b.Len())
+	}
+}
+
+func TestTopNAndNeighbors(t *testing.T) {
+	b := newBoard()
+	if got, want := b.TopN(2), []Entry{{"bob", 50}, {"dee", 50}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TopN(2) = %v, want %v", got, want)
+	}
+	if got := len(b.TopN(10)); got != 5 {
+		t.Errorf("len(TopN(10)) = %v, want 5", got)
+	}
+	for _, test := range []struct {
+		key  string
+		k    int
+		want []Entry
+	}{
+		{key: "ann", k: 1, want: []Entry{{"dee", 50}, {"ann", 30}, {"eve", 20}}},
+		{key: "bob", k: 1, want: []Entry{{"bob", 50}, {"dee", 50}}},
+		{key: "cid", k: 2, want: []Entry{{"ann", 30}, {"eve", 20}, {"cid", 10}}},
+		{key: "eve", k: 0, want: []Entry{{"eve", 20}}},
+	} {
+		got, ok := b.Neighbors(test.key, test.k)
+		if !ok || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Neighbors(%q, %v) = %v, %v, want %v, true", test.key, test.k, got, ok, test.want)
+		}
+	}
+	if _, ok := b.Neighbors("zed", 1); ok {
+		t.Errorf("Neighbors(zed) = true, want false")
+	}
+}
+
+func TestAgainstSortedSlice(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	b := New()
+	scores := map[string]int64{}
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprint("k", rng.Intn(100))
+		if rng.Intn(5) == 0 {
+			b.Remove(key)
+			delete(scores, key)
+		} else {
+			score := rng.Int63n(50)
+			b.UpdateScore(key, score)
+			scores[key] = score
+		}
+		if i%500 == 0 {
+			b.Rebalance()
+		}
+	}
+	want := []Entry{}
+	for key, score := range scores {
+		want = append(want, Entry{key, score})
+	}
+	sort.Slice(want, func(i, j int) bool {
+		if want[i].Score != want[j].Score {
+			return want[i].Score > want[j].Score
+		}
+		return want[i].Key < want[j].Key
+	})
+	if got := b.TopN(len(want)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("TopN() = %v, want %v", got, want)
+	}
+	for rank, e := range want {
+		if got, _ := b.RankOf(e.Key); got != rank {
+			t.Errorf("RankOf(%q) = %v, want %v", e.Key, got, rank)
+		}
+	}
+}
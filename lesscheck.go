@@ -0,0 +1,70 @@
+package btree
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// lessSampleSize is the number of earlier payloads that `CheckLess` compares new ones against.
+const lessSampleSize = 8
+
+// lessSample is the state of `CheckLess`: a uniform sample of the payloads that were upserted.
+type lessSample struct {
+	nodes []*Node
+	seen  int
+}
+
+// checkLess checks the `LessFunc` on `n` and the sample of earlier payloads, panics when it is
+// inconsistent, and adds `n` to the sample.
+func (b *BTree) checkLess(n *Node) {
+	if b.lessSample == nil {
+		b.lessSample = &lessSample{}
+	}
+	s := b.lessSample
+	n = &Node{Payload: n.Payload} // the payload, even when `n` is reused later
+	if b.Less(n, n) {
+		lessFailed("Less(%v, %v) is true, but a node must not be less than itself", n.Payload,
+			n.Payload)
+	}
+	for _, other := range s.nodes {
+		if b.Less(n, other) && b.Less(other, n) {
+			lessFailed("Less(%v, %v) and Less(%v, %v) are both true", n.Payload, other.Payload,
+				other.Payload, n.Payload)
+		}
+	}
+	if len(s.nodes) >= 2 {
+		i := rand.IntN(len(s.nodes))
+		j := (i + 1 + rand.IntN(len(s.nodes)-1)) % len(s.nodes)
+		b.checkTransitive(n, s.nodes[i], s.nodes[j])
+	}
+	// Reservoir sampling, so that all payloads so far are equally likely to be in the sample.
+	s.seen++
+	switch {
+	case len(s.nodes) < lessSampleSize:
+		s.nodes = append(s.nodes, n)
+	case rand.IntN(s.seen) < lessSampleSize:
+		s.nodes[rand.IntN(lessSampleSize)] = n
+	}
+}
+
+// checkTransitive panics when the `LessFunc` isn't transitive on the nodes `x`, `y` and `z`, or
+// when equality, i.e. neither node being less, isn't.
+func (b *BTree) checkTransitive(x, y, z *Node) {
+	equal := func(a, c *Node) bool { return !b.Less(a, c) && !b.Less(c, a) }
+	for _, t := range [][3]*Node{{x, y, z}, {x, z, y}, {y, x, z}, {y, z, x}, {z, x, y}, {z, y, x}} {
+		a, m, c := t[0], t[1], t[2]
+		if b.Less(a, m) && b.Less(m, c) && !b.Less(a, c) {
+			lessFailed("Less(%v, %v) and Less(%v, %v) are true, but Less(%v, %v) is false",
+				a.Payload, m.Payload, m.Payload, c.Payload, a.Payload, c.Payload)
+		}
+		if equal(a, m) && equal(m, c) && !equal(a, c) {
+			lessFailed("%v and %v are equal, and %v and %v, but %v and %v are not", a.Payload,
+				m.Payload, m.Payload, c.Payload, a.Payload, c.Payload)
+		}
+	}
+}
+
+func lessFailed(format string, args ...interface{}) {
+	panic("btree: inconsistent LessFunc: " + fmt.Sprintf(format, args...) +
+		"; it must be a strict weak ordering")
+}
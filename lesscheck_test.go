@@ -0,0 +1,56 @@
+package btree
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckLess(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		less    LessFunc
+		wantErr string
+	}{
+		{
+			desc:    "consistent",
+			less:    intLess,
+			wantErr: "",
+		},
+		{
+			desc:    "not irreflexive",
+			less:    func(a, b *Node) bool { return a.Payload.(int) <= b.Payload.(int) },
+			wantErr: "Less(0, 0) is true",
+		},
+		{
+			desc:    "not antisymmetric",
+			less:    func(a, b *Node) bool { return a.Payload.(int) != b.Payload.(int) },
+			wantErr: "are both true",
+		},
+		{
+			desc: "not transitive",
+			less: func(a, b *Node) bool { // rock, paper, scissors
+				return (b.Payload.(int)-a.Payload.(int)+3)%3 == 1
+			},
+			wantErr: "are true, but Less(",
+		},
+		{
+			desc:    "equality not transitive",
+			less:    func(a, b *Node) bool { return a.Payload.(int)+1 < b.Payload.(int) },
+			wantErr: "are not; it must be a strict weak ordering",
+		},
+	} {
+		b := New(test.less)
+		b.CheckLess = true
+		msg := panicOf(func() {
+			for i := 0; i < 100; i++ {
+				b.Upsert(&Node{Payload: i % 3})
+			}
+		})
+		if test.wantErr == "" && msg != "" || !strings.Contains(msg, test.wantErr) {
+			t.Errorf("%v: Upsert() panic = %q, want one with %q", test.desc, msg, test.wantErr)
+		}
+		if test.wantErr != "" && !strings.HasPrefix(msg, "btree: inconsistent LessFunc: ") {
+			t.Errorf("%v: Upsert() panic = %q, want an inconsistent LessFunc", test.desc, msg)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package btree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseFunc is supplied by the caller of text parsing functions such as `ReadLevelOrder()`. It
+// converts the text of one node into its payload.
+type ParseFunc func(text string) (interface{}, error)
+
+// levelOrderNull marks a missing node in the level-order format.
+const levelOrderNull = "null"
+
+// LevelOrder returns the tree in the level-order format that is common in tooling and test
+// fixtures, e.g. `[2,1,3,null,null,4]`. Nodes are listed level by level, and missing sub-nodes of
+// the listed nodes are shown as `null`; trailing `null`s are left out. The text of each node is
+// determined by `label`, which should not return text containing commas or brackets.
+func (b *BTree) LevelOrder(label LabelFunc) string {
+	var parts []string
+	queue := []*Node{b.Root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n == nil {
+			parts = append(parts, levelOrderNull)
+			continue
+		}
+		parts = append(parts, label(n))
+		queue = append(queue, n.Left, n.Right)
+	}
+	for len(parts) > 0 && parts[len(parts)-1] == levelOrderNull {
+		parts = parts[:len(parts)-1]
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// ReadLevelOrder replaces the nodes of the tree with the ones in `s`, which must be in the
+// level-order format of `LevelOrder()`. The text of each node is converted into a payload by
+// `parse`. The tree is built exactly as described by `s`, so `s` should list the nodes in a valid
+// order for the `LessFunc` of the tree, which is kept.
+func (b *BTree) ReadLevelOrder(s string, parse ParseFunc) error {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return fmt.Errorf("btree: level-order %q is not enclosed in [ and ]", s)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+	if s == "" || s == levelOrderNull {
+		b.load(nil)
+		return nil
+	}
+
+	var nodes []*Node
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == levelOrderNull {
+			nodes = append(nodes, nil)
+			continue
+		}
+		payload, err := parse(part)
+		if err != nil {
+			return fmt.Errorf("btree: cannot parse level-order node %q: %v", part, err)
+		}
+		nodes = append(nodes, &Node{Payload: payload})
+	}
+	if nodes[0] == nil {
+		return fmt.Errorf("btree: level-order %q has more nodes after a null root", s)
+	}
+
+	// Each listed node gets the next two entries as its sub-nodes.
+	next := 1
+	for i := 0; i < next && next < len(nodes); i++ {
+		n := nodes[i]
+		if n == nil {
+			continue
+		}
+		n.Left = nodes[next]
+		if next+1 < len(nodes) {
+			n.Right = nodes[next+1]
+		}
+		next += 2
+	}
+	if next < len(nodes) {
+		return fmt.Errorf("btree: level-order %q has nodes without a parent", s)
+	}
+	b.load(nodes[0])
+	return nil
+}
@@ -0,0 +1,56 @@
+package btree
+
+import (
+	"strconv"
+	"testing"
+)
+
+func parseInt(s string) (interface{}, error) {
+	return strconv.Atoi(s)
+}
+
+func TestLevelOrder(t *testing.T) {
+	for _, test := range []struct {
+		vals []int
+		want string
+	}{
+		{vals: nil, want: "[]"},
+		{vals: []int{1}, want: "[1]"},
+		{vals: []int{2, 1, 3}, want: "[2,1,3]"},
+		{vals: []int{2, 1, 4, 3}, want: "[2,1,4,null,null,3]"},
+		{vals: []int{1, 2, 3}, want: "[1,null,2,null,3]"},
+	} {
+		b := newIntTree(test.vals...)
+		got := b.LevelOrder(intLabel)
+		if got != test.want {
+			t.Errorf("LevelOrder(%v) = %q, want %q", test.vals, got, test.want)
+		}
+		back := New(intLess)
+		if err := back.ReadLevelOrder(got, parseInt); err != nil {
+			t.Fatalf("ReadLevelOrder(%q) = %v", got, err)
+		}
+		if !b.StructurallyEqual(back, intEqual) {
+			t.Errorf("ReadLevelOrder(%q) = %q, want the original tree", got, back.LevelOrder(intLabel))
+		}
+	}
+}
+
+func TestReadLevelOrderErrors(t *testing.T) {
+	for _, s := range []string{
+		"1,2,3",
+		"[null,1]",
+		"[1,x]",
+		"[1,null,null,2]",
+	} {
+		if err := New(intLess).ReadLevelOrder(s, parseInt); err == nil {
+			t.Errorf("ReadLevelOrder(%q) succeeds, want error", s)
+		}
+	}
+	b := New(intLess)
+	if err := b.ReadLevelOrder(" [ 2 , 1 , null ] ", parseInt); err != nil {
+		t.Fatalf("ReadLevelOrder() with spaces = %v", err)
+	}
+	if got, want := b.LevelOrder(intLabel), "[2,1]"; got != want {
+		t.Errorf("ReadLevelOrder() with spaces = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,192 @@
+package btree
+
+import "sync"
+
+// lcNode is a node of a `LockCouplingTree`. The embedded `Node` carries the payload; its `Left`
+// and `Right` are not used, since the sub-nodes need their own locks.
+type lcNode struct {
+	mu          sync.RWMutex
+	node        Node
+	left, right *lcNode
+}
+
+// LockCouplingTree is a binary tree for write-heavy concurrent workloads. Instead of one lock for
+// the whole tree, each node has its own lock. Operations descend hand-over-hand: they lock a
+// sub-node before releasing its parent. Goroutines working in different subtrees therefore don't
+// block each other, except near the root.
+//
+// The nodes returned by e.g. `Upsert()` or `Find()` carry the payload, but have no `Left` or
+// `Right`. Changing their payloads is only safe within `Update()`.
+type LockCouplingTree struct {
+	// head is a sentinel whose left sub-node is the root, so that the root pointer is guarded
+	// just like any other sub-node pointer.
+	head lcNode
+	less LessFunc
+}
+
+// NewLockCoupling returns an empty `LockCouplingTree` that orders its nodes using `less`.
+func NewLockCoupling(less LessFunc) *LockCouplingTree {
+	return &LockCouplingTree{less: less}
+}
+
+func (t *LockCouplingTree) lessNode(a *Node, b *lcNode) bool {
+	return t.less(a, &b.node)
+}
+
+func (t *LockCouplingTree) greaterNode(a *Node, b *lcNode) bool {
+	return t.less(&b.node, a)
+}
+
+// Upsert is the concurrent version of `BTree.Upsert()`.
+func (t *LockCouplingTree) Upsert(n *Node) (intree *Node, inserted bool) {
+	var lc *lcNode
+	lc, inserted = t.upsertLocked(n)
+	lc.mu.Unlock()
+	return &lc.node, inserted
+}
+
+// Update upserts `n` and calls `fn` with the outcome while holding the lock of the node in the
+// tree, so that its payload can be updated safely.
+func (t *LockCouplingTree) Update(n *Node, fn func(intree *Node, inserted bool)) {
+	lc, inserted := t.upsertLocked(n)
+	defer lc.mu.Unlock()
+	fn(&lc.node, inserted)
+}
+
+// upsertLocked returns the node in the tree that matches `n`, inserting it when needed. On return
+// that node is locked.
+func (t *LockCouplingTree) upsertLocked(n *Node) (lc *lcNode, inserted bool) {
+	parent := &t.head
+	parent.mu.Lock()
+	slot := &parent.left
+	for {
+		cur := *slot
+		if cur == nil {
+			cur = &lcNode{node: Node{Payload: n.Payload}}
+			cur.mu.Lock()
+			*slot = cur
+			parent.mu.Unlock()
+			return cur, true
+		}
+		cur.mu.Lock()
+		parent.mu.Unlock()
+		switch {
+		case t.lessNode(n, cur):
+			slot = &cur.left
+		case t.greaterNode(n, cur):
+			slot = &cur.right
+		default:
+			return cur, false
+		}
+		parent = cur
+	}
+}
+
+// Find is the concurrent version of `BTree.Find()`.
+func (t *LockCouplingTree) Find(n *Node) (intree *Node, found bool) {
+	parent := &t.head
+	parent.mu.RLock()
+	cur := parent.left
+	for cur != nil {
+		cur.mu.RLock()
+		parent.mu.RUnlock()
+		switch {
+		case t.lessNode(n, cur):
+			parent, cur = cur, cur.left
+		case t.greaterNode(n, cur):
+			parent, cur = cur, cur.right
+		default:
+			cur.mu.RUnlock()
+			return &cur.node, true
+		}
+	}
+	parent.mu.RUnlock()
+	return nil, false
+}
+
+// Delete is the concurrent version of `BTree.Delete()`.
+func (t *LockCouplingTree) Delete(n *Node) (removed *Node, deleted bool) {
+	// Descend, keeping the parent of the current node locked, since its sub-node pointer may need
+	// to change.
+	parent := &t.head
+	parent.mu.Lock()
+	slot := &parent.left
+	for {
+		cur := *slot
+		if cur == nil {
+			parent.mu.Unlock()
+			return nil, false
+		}
+		cur.mu.Lock()
+		switch {
+		case t.lessNode(n, cur):
+			parent.mu.Unlock()
+			parent, slot = cur, &cur.left
+			continue
+		case t.greaterNode(n, cur):
+			parent.mu.Unlock()
+			parent, slot = cur, &cur.right
+			continue
+		}
+
+		// `cur` is to be removed; `parent` and `cur` are locked.
+		switch {
+		case cur.left == nil:
+			*slot = cur.right
+		case cur.right == nil:
+			*slot = cur.left
+		default:
+			*slot = t.unlinkSuccessor(cur)
+		}
+		cur.mu.Unlock()
+		parent.mu.Unlock()
+		return &cur.node, true
+	}
+}
+
+// unlinkSuccessor takes the in-order successor of the locked `cur`, which has two sub-nodes, out
+// of its place and links it up with the sub-nodes of `cur`. The successor is returned, so that it
+// can take the place of `cur`.
+func (t *LockCouplingTree) unlinkSuccessor(cur *lcNode) *lcNode {
+	succParent, succ := cur, cur.right
+	succ.mu.Lock()
+	for succ.left != nil {
+		next := succ.left
+		next.mu.Lock()
+		if succParent != cur {
+			succParent.mu.Unlock()
+		}
+		succParent, succ = succ, next
+	}
+	if succParent != cur {
+		succParent.left = succ.right
+		succ.right = cur.right
+		succParent.mu.Unlock()
+	}
+	succ.left = cur.left
+	succ.mu.Unlock()
+	return succ
+}
+
+// DepthFirstInOrder walks the tree in order, see `BTree.DepthFirstInOrder()`. Each node is locked
+// only while it is examined, so that the walk doesn't block other goroutines for long. The walk
+// is therefore not a consistent snapshot: concurrent changes may or may not be seen. The nodes
+// passed to `walk` have no `Left` or `Right`, and their payloads must not be changed.
+func (t *LockCouplingTree) DepthFirstInOrder(walk WalkFunc) {
+	t.head.mu.RLock()
+	root := t.head.left
+	t.head.mu.RUnlock()
+	t.walkFrom(root, walk)
+}
+
+func (t *LockCouplingTree) walkFrom(n *lcNode, walk WalkFunc) {
+	if n == nil {
+		return
+	}
+	n.mu.RLock()
+	left, right, payload := n.left, n.right, n.node.Payload
+	n.mu.RUnlock()
+	t.walkFrom(left, walk)
+	walk(&Node{Payload: payload})
+	t.walkFrom(right, walk)
+}
@@ -0,0 +1,85 @@
+package btree
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func lcInts(t *LockCouplingTree) []int {
+	out := []int{}
+	t.DepthFirstInOrder(func(n *Node) { out = append(out, n.Payload.(int)) })
+	return out
+}
+
+func TestLockCouplingTree(t *testing.T) {
+	vals := []int{50, 30, 70, 20, 40, 60, 80, 35, 45, 65}
+	for _, del := range append(vals, 99) {
+		lc := NewLockCoupling(intLess)
+		for _, v := range vals {
+			if _, inserted := lc.Upsert(&Node{Payload: v}); !inserted {
+				t.Fatalf("Upsert(%v) = not inserted", v)
+			}
+		}
+		if _, inserted := lc.Upsert(&Node{Payload: vals[0]}); inserted {
+			t.Errorf("Upsert(%v) again = inserted", vals[0])
+		}
+		_, deleted := lc.Delete(&Node{Payload: del})
+		if want := del != 99; deleted != want {
+			t.Errorf("Delete(%v) = %v, want %v", del, deleted, want)
+		}
+		want := []int{}
+		for _, v := range inOrderInts(newIntTree(vals...)) {
+			if v != del {
+				want = append(want, v)
+			}
+		}
+		if got := lcInts(lc); !reflect.DeepEqual(got, want) {
+			t.Errorf("after Delete(%v) = %v, want %v", del, got, want)
+		}
+		for _, v := range want {
+			if _, found := lc.Find(&Node{Payload: v}); !found {
+				t.Errorf("after Delete(%v): Find(%v) = not found", del, v)
+			}
+		}
+	}
+}
+
+func TestLockCouplingTreeConcurrent(t *testing.T) {
+	type counter struct{ key, count int }
+	lc := NewLockCoupling(func(a, b *Node) bool { return a.Payload.(*counter).key < b.Payload.(*counter).key })
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				key := (i*7919 + g) % 500
+				lc.Update(&Node{Payload: &counter{key: key}}, func(intree *Node, _ bool) {
+					intree.Payload.(*counter).count++
+				})
+				lc.Find(&Node{Payload: &counter{key: i % 100}})
+				if i%10 == 0 {
+					lc.Delete(&Node{Payload: &counter{key: 1000 + g}})
+					lc.Upsert(&Node{Payload: &counter{key: 1000 + g}})
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	total := 0
+	prev := -1
+	lc.DepthFirstInOrder(func(n *Node) {
+		c := n.Payload.(*counter)
+		if c.key <= prev {
+			t.Errorf("out of order: %v after %v", c.key, prev)
+		}
+		prev = c.key
+		total += c.count
+	})
+	if want := 8 * 2000; total != want {
+		t.Errorf("total count = %v, want %v", total, want)
+	}
+}
@@ -0,0 +1,57 @@
+package btree
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger makes the tree log debug events to `l`, and returns the tree. The events are:
+//
+//   - "btree.Upsert" and "btree.Delete" with the `payload`, whether it was `inserted` or
+//     `deleted`, and the `path`: the number of nodes that were visited, which grows when the tree
+//     is out of shape.
+//   - "btree.Rebalance" and "btree.Maintain" with the number of `rotations` that were done.
+//   - "btree.Compact" and "btree.ExpireBefore" with the number of nodes that were `removed`.
+//
+// The events are only built when `l` is enabled for `slog.LevelDebug`, so that a logger at a
+// higher level costs a check per operation. `nil` stops the logging.
+func (b *BTree) WithLogger(l *slog.Logger) *BTree {
+	b.logger = l
+	return b
+}
+
+// logging returns `true` when debug events are logged.
+func (b *BTree) logging() bool {
+	return b.logger != nil && b.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+func (b *BTree) logUpsert(n *Node, inserted bool) {
+	if b.logging() {
+		b.logger.Debug("btree.Upsert", "payload", n.Payload, "inserted", inserted, "path", b.path)
+	}
+}
+
+func (b *BTree) logDelete(n *Node, deleted bool) {
+	if b.logging() {
+		b.logger.Debug("btree.Delete", "payload", n.Payload, "deleted", deleted, "path", b.path)
+	}
+}
+
+func (b *BTree) logRotations(op string, rotations int) {
+	if b.logging() {
+		b.logger.Debug("btree."+op, "rotations", rotations)
+	}
+}
+
+func (b *BTree) logRemoved(op string, removed int) {
+	if b.logging() {
+		b.logger.Debug("btree."+op, "removed", removed)
+	}
+}
+
+// descended records an insertion or deletion that visited `nodes` nodes, in the `Stats` and as
+// the `path` of its event.
+func (b *BTree) descended(nodes int) {
+	b.path = nodes
+	b.stats.descent(nodes)
+}
@@ -0,0 +1,49 @@
+package btree
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == slog.LevelKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	b := New(intLess).WithLogger(slog.New(h))
+	for _, v := range []int{1, 2, 3, 2} {
+		b.Upsert(&Node{Payload: v})
+	}
+	b.Delete(&Node{Payload: 3})
+	b.Delete(&Node{Payload: 4})
+	b.Rebalance()
+	want := []string{
+		"msg=btree.Upsert payload=1 inserted=true path=0",
+		"msg=btree.Upsert payload=2 inserted=true path=1",
+		"msg=btree.Upsert payload=3 inserted=true path=2",
+		"msg=btree.Upsert payload=2 inserted=false path=2",
+		"msg=btree.Delete payload=3 deleted=true path=3",
+		"msg=btree.Delete payload=4 deleted=false path=2",
+		"msg=btree.Rebalance rotations=1",
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(got, "\n") !=
+		strings.Join(want, "\n") {
+		t.Errorf("logged:\n%v\nwant:\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Above the debug level, nothing is logged.
+	buf.Reset()
+	b.WithLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	b.Upsert(&Node{Payload: 5})
+	if buf.Len() != 0 {
+		t.Errorf("logged %q at the info level, want nothing", buf.String())
+	}
+}
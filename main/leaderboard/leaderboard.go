@@ -0,0 +1,137 @@
+// Command leaderboard serves a leaderboard over HTTP. Players submit scores, and the board, which
+// keeps the best score of each player, answers who is on top and where a player ranks:
+//
+//   - `POST /scores` with a body like `{"player":"alice","score":1200}` submits a score, and
+//     returns the player's standing after it.
+//   - `GET /top?n=N` returns the standings of the `N` best players (default 10).
+//   - `GET /rank/PLAYER` returns the standing of `PLAYER`.
+//
+// A standing is `{"player":...,"score":...,"rank":...}`, where rank 1 is the best. The board is
+// shared by the goroutines that serve the requests, so it is guarded by a lock.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/KarelKubat/btree/leaderboard"
+)
+
+// defaultTop is the number of standings that `GET /top` returns when there is no `n`.
+const defaultTop = 10
+
+// maxTop limits the `n` of `GET /top`.
+const maxTop = 1000
+
+// score is the body of `POST /scores`.
+type score struct {
+	Player string `json:"player"`
+	Score  int64  `json:"score"`
+}
+
+// standing is a player's position on the board.
+type standing struct {
+	Player string `json:"player"`
+	Score  int64  `json:"score"`
+	Rank   int    `json:"rank"`
+}
+
+// server guards the board: submissions lock it exclusively, lookups share the lock.
+type server struct {
+	mu    sync.RWMutex
+	board *leaderboard.Board
+}
+
+func newServer() *server {
+	return &server{board: leaderboard.New()}
+}
+
+// handler returns the `http.Handler` of the endpoints.
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scores", s.submit)
+	mux.HandleFunc("GET /top", s.top)
+	mux.HandleFunc("GET /rank/{player}", s.rank)
+	return mux
+}
+
+// standingOf returns the standing of `player`, with the lock held.
+func (s *server) standingOf(player string) (st standing, ok bool) {
+	rank, ok := s.board.RankOf(player)
+	if !ok {
+		return standing{}, false
+	}
+	sc, _ := s.board.Score(player)
+	return standing{Player: player, Score: sc, Rank: rank + 1}, true
+}
+
+func (s *server) submit(w http.ResponseWriter, r *http.Request) {
+	var sc score
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&sc); err != nil {
+		http.Error(w, "bad score: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if sc.Player == "" {
+		http.Error(w, "bad score: no player", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	if best, ok := s.board.Score(sc.Player); !ok || sc.Score > best {
+		s.board.UpdateScore(sc.Player, sc.Score)
+	}
+	st, _ := s.standingOf(sc.Player)
+	s.mu.Unlock()
+	writeJSON(w, st)
+}
+
+func (s *server) top(w http.ResponseWriter, r *http.Request) {
+	n := defaultTop
+	if param := r.URL.Query().Get("n"); param != "" {
+		var err error
+		if n, err = strconv.Atoi(param); err != nil || n < 0 || n > maxTop {
+			http.Error(w, fmt.Sprintf("bad n %q, want 0 to %v", param, maxTop), http.StatusBadRequest)
+			return
+		}
+	}
+	s.mu.RLock()
+	entries := s.board.TopN(n)
+	s.mu.RUnlock()
+	standings := []standing{}
+	for i, e := range entries {
+		standings = append(standings, standing{Player: e.Key, Score: e.Score, Rank: i + 1})
+	}
+	writeJSON(w, standings)
+}
+
+func (s *server) rank(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	st, ok := s.standingOf(r.PathValue("player"))
+	s.mu.RUnlock()
+	if !ok {
+		http.Error(w, "no such player", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, st)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "the `ADDRESS` to listen on")
+	flag.Parse()
+	log.Printf("serving the leaderboard on http://%v/", *addr)
+	log.Fatalln(http.ListenAndServe(*addr, newServer().handler()))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// request sends a request to `h`, and returns the status and the body of the response.
+func request(h http.Handler, method, url, body string) (int, string) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, url, strings.NewReader(body)))
+	data, _ := io.ReadAll(rec.Result().Body)
+	return rec.Code, string(data)
+}
+
+func TestEndpoints(t *testing.T) {
+	h := newServer().handler()
+	for _, test := range []struct {
+		method, url, body string
+		status            int
+		want              string
+	}{
+		{"POST", "/scores", `{"player":"alice","score":1200}`, 200,
+			`{"player":"alice","score":1200,"rank":1}`},
+		{"POST", "/scores", `{"player":"bob","score":950}`, 200, `{"player":"bob","score":950,"rank":2}`},
+		{"POST", "/scores", `{"player":"carol","score":1500}`, 200,
+			`{"player":"carol","score":1500,"rank":1}`},
+		// A lower score doesn't replace the best one.
+		{"POST", "/scores", `{"player":"carol","score":100}`, 200,
+			`{"player":"carol","score":1500,"rank":1}`},
+		{"GET", "/rank/bob", "", 200, `{"player":"bob","score":950,"rank":3}`},
+		{"GET", "/top?n=2", "", 200,
+			`[{"player":"carol","score":1500,"rank":1},{"player":"alice","score":1200,"rank":2}]`},
+		{"GET", "/top?n=0", "", 200, `[]`},
+		{"GET", "/rank/dave", "", 404, "no such player"},
+		{"GET", "/top?n=-1", "", 400, `bad n "-1", want 0 to 1000`},
+		{"POST", "/scores", `{"score":1}`, 400, "bad score: no player"},
+		{"POST", "/scores", `{"player":`, 400, "bad score: unexpected EOF"},
+		{"DELETE", "/scores", "", 405, "Method Not Allowed"},
+	} {
+		status, body := request(h, test.method, test.url, test.body)
+		if status != test.status || strings.TrimSpace(body) != test.want {
+			t.Errorf("%v %v = %v %q, want %v %q", test.method, test.url, status, body, test.status,
+				test.want)
+		}
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	s := newServer()
+	h := s.handler()
+	var wg sync.WaitGroup
+	for p := 0; p < 8; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				request(h, "POST", "/scores", fmt.Sprintf(`{"player":"p%v","score":%v}`, p, p*1000+i))
+				request(h, "GET", "/top?n=3", "")
+				request(h, "GET", fmt.Sprintf("/rank/p%v", p), "")
+			}
+		}()
+	}
+	wg.Wait()
+	want := `[{"player":"p7","score":7199,"rank":1},{"player":"p6","score":6199,"rank":2}]`
+	if _, got := request(h, "GET", "/top?n=2", ""); strings.TrimSpace(got) != want {
+		t.Errorf("GET /top?n=2 = %q, want %q", got, want)
+	}
+	if got := s.board.Len(); got != 8 {
+		t.Errorf("board has %v players, want 8", got)
+	}
+}
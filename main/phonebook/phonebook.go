@@ -0,0 +1,188 @@
+// Command phonebook is an interactive phone book: a key-value tree of names and numbers, which is
+// saved to a file after each change and loaded again on the next run. Type "help" for the
+// commands.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/KarelKubat/btree"
+)
+
+// The payload of a node: a name and a phone number. The fields are exported so that
+// `btree.JSONCodec` can encode them.
+type entry struct {
+	Name   string `json:"name"`
+	Number string `json:"number"`
+}
+
+// Node comparison: `a` is "less" if its name is alphabetically less.
+func lessFunc(a, b *btree.Node) bool {
+	return a.Payload.(*entry).Name < b.Payload.(*entry).Name
+}
+
+// codec stores the entries as JSON, and decodes them back into `*entry` payloads.
+var codec = btree.JSONCodec{Unmarshal: func(data []byte) (interface{}, error) {
+	e := &entry{}
+	err := json.Unmarshal(data, e)
+	return e, err
+}}
+
+// keyOf returns a node to look up `name` in the tree.
+func keyOf(name string) *btree.Node {
+	return &btree.Node{Payload: &entry{Name: name}}
+}
+
+const help = `Commands:
+  add NAME NUMBER   add NAME, or change its NUMBER (NAME may contain spaces)
+  find NAME         show the number of NAME
+  delete NAME       remove NAME
+  list              show all entries
+  range FROM [TO]   show the entries from FROM up to, but not including, TO
+  help              show this text
+  quit              leave (so does end of input)
+`
+
+// phonebook is the tree of entries, and the file where it is kept.
+type phonebook struct {
+	t    *btree.BTree
+	file string
+}
+
+// open returns the phone book that is kept in `file`; it is empty when `file` doesn't exist yet.
+func open(file string) (*phonebook, error) {
+	p := &phonebook{t: btree.New(lessFunc), file: file}
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := p.t.ReadBinary(f, codec); err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	return p, nil
+}
+
+// save writes the phone book to its file. It writes a temporary file first, which then replaces
+// the old one, so that a failure halfway doesn't lose the entries.
+func (p *phonebook) save() error {
+	tmp, err := os.CreateTemp(filepath.Dir(p.file), filepath.Base(p.file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := p.t.WriteBinary(tmp, codec); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.file)
+}
+
+// show prints the entry of `n` to `w`.
+func show(w io.Writer, n *btree.Node) {
+	fmt.Fprintf(w, "%v: %v\n", n.Payload.(*entry).Name, n.Payload.(*entry).Number)
+}
+
+// exec runs the command `line`, writing its output to `w`. It returns `true` when the command is
+// to quit.
+func (p *phonebook) exec(w io.Writer, line string) (quit bool, err error) {
+	cmd, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+	args = strings.TrimSpace(args)
+	switch cmd {
+	case "":
+	case "add":
+		i := strings.LastIndex(args, " ")
+		if i < 0 {
+			return false, errors.New("usage: add NAME NUMBER")
+		}
+		name, number := strings.TrimSpace(args[:i]), args[i+1:]
+		intree, inserted := p.t.Upsert(&btree.Node{Payload: &entry{Name: name, Number: number}})
+		if !inserted {
+			intree.Payload.(*entry).Number = number
+		}
+		return false, p.save()
+	case "find":
+		intree, found := p.t.Find(keyOf(args))
+		if !found {
+			return false, fmt.Errorf("%v: not found", args)
+		}
+		show(w, intree)
+	case "delete":
+		if _, deleted := p.t.Delete(keyOf(args)); !deleted {
+			return false, fmt.Errorf("%v: not found", args)
+		}
+		return false, p.save()
+	case "list":
+		p.t.DepthFirstInOrder(func(n *btree.Node) { show(w, n) })
+	case "range":
+		// The bounds are single words, so that one can be told from the other.
+		bounds := strings.Fields(args)
+		if len(bounds) < 1 || len(bounds) > 2 {
+			return false, errors.New("usage: range FROM [TO]")
+		}
+		var to *btree.Node
+		if len(bounds) == 2 {
+			to = keyOf(bounds[1])
+		}
+		p.t.AscendRange(keyOf(bounds[0]), to, func(n *btree.Node) bool {
+			show(w, n)
+			return true
+		})
+	case "help":
+		fmt.Fprint(w, help)
+	case "quit":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown command %q, try help", cmd)
+	}
+	return false, nil
+}
+
+// repl reads commands from `r` until "quit" or the end of input. Output goes to `w`. Each command
+// is preceded by `prompt`, if any. Failing commands are reported, but don't stop the loop.
+func (p *phonebook) repl(r io.Reader, w io.Writer, prompt string) error {
+	sc := bufio.NewScanner(r)
+	for {
+		fmt.Fprint(w, prompt)
+		if !sc.Scan() {
+			return sc.Err()
+		}
+		quit, err := p.exec(w, sc.Text())
+		if err != nil {
+			fmt.Fprintln(w, "error:", err)
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+func main() {
+	file := flag.String("file", "phonebook.db", "the `FILE` where the phone book is kept")
+	flag.Parse()
+	if flag.NArg() != 0 {
+		log.Fatalln("usage: phonebook [-file FILE], then type help")
+	}
+	p, err := open(*file)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := p.repl(os.Stdin, os.Stdout, "> "); err != nil {
+		log.Fatalln(err)
+	}
+}
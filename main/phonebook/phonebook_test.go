@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestREPL(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "phonebook.db")
+	p, err := open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := strings.Join([]string{
+		"add John Doe 555-1234",
+		"add Mary 555-0000",
+		"add Zoe 555-9999",
+		"add Mary 555-4321", // changes the number
+		"find Mary",
+		"find Bob",
+		"range K",
+		"range A N",
+		"delete Zoe",
+		"delete Zoe",
+		"list",
+		"add nonumber",
+		"dance",
+		"quit",
+		"list", // not reached
+	}, "\n")
+	var out strings.Builder
+	if err := p.repl(strings.NewReader(in), &out, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"Mary: 555-4321",
+		"error: Bob: not found",
+		"Mary: 555-4321",
+		"Zoe: 555-9999",
+		"John Doe: 555-1234",
+		"Mary: 555-4321",
+		"error: Zoe: not found",
+		"John Doe: 555-1234",
+		"Mary: 555-4321",
+		"error: usage: add NAME NUMBER",
+		`error: unknown command "dance", try help`,
+	}, "\n") + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("repl() output:\n%v\nwant:\n%v", got, want)
+	}
+
+	// The next run finds the entries again.
+	p, err = open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := p.repl(strings.NewReader("list"), &out, "> "); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "> John Doe: 555-1234\nMary: 555-4321\n> "; got != want {
+		t.Errorf("repl() after reopening = %q, want %q", got, want)
+	}
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "phonebook.db")
+	if err := os.WriteFile(file, []byte("not a tree"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := open(file); err == nil {
+		t.Errorf("open() of a corrupt file = nil error, want one")
+	}
+}
@@ -0,0 +1,171 @@
+// Command scheduler books time slots of a day in an interval tree, refusing bookings that
+// conflict with earlier ones, and answers what occupies a given time or range. It reads commands
+// from the files on its command line, or from stdin; type "help" for the commands.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/KarelKubat/btree"
+	"github.com/KarelKubat/btree/interval"
+)
+
+const help = `Commands (times are HH:MM; a slot runs up to, but not including, its END):
+  book START END WHAT   book the slot START-END for WHAT, unless it conflicts
+  cancel START END      cancel the booking of the slot START-END
+  at TIME               show what occupies TIME
+  between START END     show the bookings that overlap START-END
+  list                  show all bookings
+  help                  show this text
+`
+
+// minutes parses "HH:MM" into the minutes since midnight.
+func minutes(s string) (int64, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q, want HH:MM", s)
+	}
+	return int64(t.Hour()*60 + t.Minute()), nil
+}
+
+// clock formats minutes since midnight as "HH:MM".
+func clock(m int64) string {
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+// slot parses the arguments "START END" into an interval of minutes.
+func slot(start, end string) (interval.Interval, error) {
+	var iv interval.Interval
+	var err error
+	if iv.Start, err = minutes(start); err != nil {
+		return iv, err
+	}
+	if iv.End, err = minutes(end); err != nil {
+		return iv, err
+	}
+	if iv.End <= iv.Start {
+		return iv, fmt.Errorf("slot %v-%v ends before it starts", start, end)
+	}
+	return iv, nil
+}
+
+// booking returns `e` as e.g. "09:00-10:30 standup".
+func booking(e *interval.Entry) string {
+	return fmt.Sprintf("%v-%v %v", clock(e.Start), clock(e.End), e.Value)
+}
+
+// schedule is the interval tree of bookings; the value of each interval is what it is booked for.
+type schedule struct {
+	t *interval.Tree
+}
+
+// exec runs the command `line`, writing its output to `w`.
+func (s *schedule) exec(w io.Writer, line string) error {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return nil
+	}
+	cmd, args := args[0], args[1:]
+	show := func(e *interval.Entry) bool {
+		fmt.Fprintln(w, booking(e))
+		return true
+	}
+	switch {
+	case cmd == "book" && len(args) >= 3:
+		iv, err := slot(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		var conflicts []string
+		s.t.Overlapping(iv, func(e *interval.Entry) bool {
+			conflicts = append(conflicts, booking(e))
+			return true
+		})
+		if len(conflicts) > 0 {
+			return fmt.Errorf("%v-%v conflicts with %v", args[0], args[1], strings.Join(conflicts, ", "))
+		}
+		s.t.Upsert(iv, strings.Join(args[2:], " "))
+	case cmd == "cancel" && len(args) == 2:
+		iv, err := slot(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if _, deleted := s.t.Delete(iv); !deleted {
+			return fmt.Errorf("%v-%v isn't booked", args[0], args[1])
+		}
+	case cmd == "at" && len(args) == 1:
+		t, err := minutes(args[0])
+		if err != nil {
+			return err
+		}
+		found := false
+		s.t.Containing(t, func(e *interval.Entry) bool {
+			found = true
+			return show(e)
+		})
+		if !found {
+			fmt.Fprintln(w, args[0], "is free")
+		}
+	case cmd == "between" && len(args) == 2:
+		iv, err := slot(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		s.t.Overlapping(iv, show)
+	case cmd == "list" && len(args) == 0:
+		s.t.Tree().DepthFirstInOrder(func(n *btree.Node) { show(n.Payload.(*interval.Entry)) })
+	case cmd == "help":
+		fmt.Fprint(w, help)
+	default:
+		return fmt.Errorf("bad command %q, try help", line)
+	}
+	return nil
+}
+
+// run executes the commands from `r`, named `name` in error messages.
+func (s *schedule) run(r io.Reader, name string, w io.Writer) error {
+	sc := bufio.NewScanner(r)
+	var errs []error
+	for i := 1; sc.Scan(); i++ {
+		if err := s.exec(w, sc.Text()); err != nil {
+			fmt.Fprintf(w, "%v:%v: %v\n", name, i, err)
+			errs = append(errs, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v: %v failed commands", name, len(errs))
+	}
+	return nil
+}
+
+func main() {
+	s := &schedule{t: interval.New()}
+	if len(os.Args) == 1 {
+		if err := s.run(os.Stdin, "stdin", os.Stdout); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	var errs []error
+	for _, file := range os.Args[1:] {
+		f, err := os.Open(file)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		errs = append(errs, s.run(f, file, os.Stdout))
+		f.Close()
+	}
+	if err := errors.Join(errs...); err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KarelKubat/btree/interval"
+)
+
+func TestRun(t *testing.T) {
+	s := &schedule{t: interval.New()}
+	in := strings.Join([]string{
+		"book 09:00 10:30 standup",
+		"book 12:00 13:00 lunch with Mary",
+		"book 10:30 11:00 coffee", // adjacent, no conflict
+		"book 10:00 12:30 review", // conflicts with two
+		"at 10:45",
+		"at 11:15",
+		"between 10:00 12:01",
+		"cancel 10:30 11:00",
+		"cancel 10:30 11:00",
+		"book 11:00 10:00 backwards",
+		"at 25:00",
+		"list",
+		"dance",
+	}, "\n")
+	var out strings.Builder
+	if err := s.run(strings.NewReader(in), "test", &out); err == nil {
+		t.Errorf("run() = nil error, want one for the failed commands")
+	}
+	want := strings.Join([]string{
+		"test:4: 10:00-12:30 conflicts with 09:00-10:30 standup, 10:30-11:00 coffee, " +
+			"12:00-13:00 lunch with Mary",
+		"10:30-11:00 coffee",
+		"11:15 is free",
+		"09:00-10:30 standup",
+		"10:30-11:00 coffee",
+		"12:00-13:00 lunch with Mary",
+		"test:9: 10:30-11:00 isn't booked",
+		"test:10: slot 11:00-10:00 ends before it starts",
+		`test:11: bad time "25:00", want HH:MM`,
+		"09:00-10:30 standup",
+		"12:00-13:00 lunch with Mary",
+		`test:13: bad command "dance", try help`,
+	}, "\n") + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("run() output:\n%v\nwant:\n%v", got, want)
+	}
+	if got := s.t.Len(); got != 2 {
+		t.Errorf("run() leaves %v bookings, want 2", got)
+	}
+}
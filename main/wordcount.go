@@ -2,9 +2,20 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/KarelKubat/btree"
 )
@@ -13,11 +24,36 @@ import (
 type stringcount struct {
 	str   string
 	count int64
+	// words holds the words of an n-gram, which `str` joins by spaces, or is `nil` for a token.
+	words []string
 }
 
-// Visitor function: prints the number of times a word was seen, and the word itself.
-func nodeWalk(n *btree.Node) {
-	fmt.Println(n.Payload.(*stringcount).count, n.Payload.(*stringcount).str)
+// MarshalJSON implements `json.Marshaler`, for `-format json`.
+func (sc *stringcount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Token string `json:"token"`
+		Count int64  `json:"count"`
+	}{sc.str, sc.count})
+}
+
+// stringcountRows is the `btree.RowCodec` for `-format csv` and `-format tsv`.
+type stringcountRows struct{}
+
+func (stringcountRows) Header() []string {
+	return []string{"token", "count"}
+}
+
+func (stringcountRows) EncodeRow(payload interface{}) ([]string, error) {
+	sc := payload.(*stringcount)
+	return []string{sc.str, strconv.FormatInt(sc.count, 10)}, nil
+}
+
+func (stringcountRows) DecodeRow(row []string) (interface{}, error) {
+	if len(row) != 2 {
+		return nil, fmt.Errorf("want 2 columns, got %v", len(row))
+	}
+	count, err := strconv.ParseInt(row[1], 10, 64)
+	return &stringcount{str: row[0], count: count}, err
 }
 
 // Node comparison :`a` is "less" if its string is alphabetically less.
@@ -25,25 +61,164 @@ func lessFunc(a, b *btree.Node) bool {
 	return a.Payload.(*stringcount).str < b.Payload.(*stringcount).str
 }
 
-func main() {
-	// Check cmdline, open input file or set input to stdin
-	if len(os.Args) != 1 {
-		log.Fatalln("Usage: wordcount (reads from stdin, shows words and their frequencies)")
+// Node comparison: `a` is "less" if it was seen less often.
+func lessCount(a, b *btree.Node) bool {
+	return a.Payload.(*stringcount).count < b.Payload.(*stringcount).count
+}
+
+// Node comparison for `-sort freq` and the top-N, on the composite key (count, string): the most
+// frequent words come first, and equally frequent words are ordered alphabetically.
+var lessByFreq = btree.Lexicographic(btree.Reverse(lessCount), lessFunc)
+
+// ngramLess returns the node comparison for n-grams of `n` words, on the composite key of the
+// words: the first words are compared, then the second ones, and so on.
+func ngramLess(n int) btree.LessFunc {
+	fields := []btree.LessFunc{}
+	for i := 0; i < n; i++ {
+		fields = append(fields, func(a, b *btree.Node) bool {
+			return a.Payload.(*stringcount).words[i] < b.Payload.(*stringcount).words[i]
+		})
 	}
+	return btree.Lexicographic(fields...)
+}
 
-	// Instantiate a binary tree.
-	bt := btree.New(lessFunc)
+// orders maps the values of `-sort` to the `LessFunc` of the output.
+var orders = map[string]btree.LessFunc{
+	"alpha": lessFunc,
+	"freq":  lessByFreq,
+}
+
+// splitFunc returns the `bufio.SplitFunc` that cuts the input into the tokens to count, given the
+// `-split` mode: "words" (separated by white space), "lines", "runes", or "regex", in which case
+// the tokens are the matches of `pattern`.
+func splitFunc(mode, pattern string) (bufio.SplitFunc, error) {
+	switch mode {
+	case "words":
+		return bufio.ScanWords, nil
+	case "lines":
+		return bufio.ScanLines, nil
+	case "runes":
+		return bufio.ScanRunes, nil
+	case "regex":
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return scanMatches(re), nil
+	}
+	return nil, fmt.Errorf("unknown -split mode %q, want words, lines, runes or regex", mode)
+}
 
-	// Start a scanner that splits by spaces.
-	sc := bufio.NewScanner(os.Stdin)
+// scanMatches returns a `bufio.SplitFunc` whose tokens are the non-empty matches of `re`. Matches
+// don't span lines, so that only one line at a time needs to be buffered.
+func scanMatches(re *regexp.Regexp) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		line := len(data)
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = i
+		} else if !atEOF {
+			// Request a full line.
+			return 0, nil, nil
+		}
+		for start := 0; start < line; {
+			loc := re.FindIndex(data[start:line])
+			if loc == nil {
+				break
+			}
+			if loc[1] > loc[0] {
+				return start + loc[1], data[start+loc[0] : start+loc[1]], nil
+			}
+			// An empty match: retry after the next rune.
+			_, size := utf8.DecodeRune(data[start+loc[1] : line])
+			start += loc[1] + max(size, 1)
+		}
+		// No more matches on this line; skip it, including the newline.
+		return min(line+1, len(data)), nil, nil
+	}
+}
+
+// counter counts the tokens of inputs.
+type counter struct {
+	// split cuts the input into tokens.
+	split bufio.SplitFunc
+	// ngram is the number of consecutive tokens to count as one; 1 counts single tokens.
+	ngram int
+	// maxEntries, when > 0, is the maximum number of distinct tokens to keep counting, see
+	// `countBounded()`.
+	maxEntries int
+	// stopwords holds the tokens to skip, as `stringcount` payloads ordered by `lessFunc()`, or is
+	// `nil` to count all tokens.
+	stopwords *btree.BTree
+}
+
+// loadStopwords returns a tree of the words in the file named `file`, for `counter.stopwords`.
+func loadStopwords(file string) (*btree.BTree, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stopwords := btree.New(lessFunc)
+	sc := bufio.NewScanner(f)
 	sc.Split(bufio.ScanWords)
 	for sc.Scan() {
+		stopwords.Upsert(&btree.Node{Payload: &stringcount{str: sc.Text()}})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	return stopwords, nil
+}
+
+// count reads the tokens from `r` and returns a tree of them, ordered alphabetically, or a tree of
+// n-grams, ordered by `ngramLess()`.
+func (c *counter) count(r io.Reader) (*btree.BTree, error) {
+	// Instantiate a binary tree.
+	less := lessFunc
+	if c.ngram > 1 {
+		less = ngramLess(c.ngram)
+	}
+	bt := btree.New(less)
+	// The last `c.ngram` tokens.
+	var window []string
+	// The counted tokens, ordered by frequency, when their number is bounded.
+	var bounded *btree.BoundedTree
+	if c.maxEntries > 0 {
+		bounded = btree.New(lessByFreq).WithMaxSize(c.maxEntries, btree.EvictMax)
+	}
+
+	// Start a scanner that splits into tokens.
+	sc := bufio.NewScanner(r)
+	sc.Split(c.split)
+	for sc.Scan() {
+		// Skip stopwords. The lookup tree has the same kind of payload, so the node to find is the
+		// same as the one to insert.
+		n := &btree.Node{Payload: &stringcount{str: sc.Text()}}
+		if c.stopwords != nil {
+			if _, found := c.stopwords.Find(n); found {
+				continue
+			}
+		}
+		if c.ngram > 1 {
+			window = append(window, sc.Text())
+			if len(window) < c.ngram {
+				continue
+			}
+			window = window[len(window)-c.ngram:]
+			words := append([]string(nil), window...)
+			n = &btree.Node{Payload: &stringcount{str: strings.Join(words, " "), words: words}}
+		}
+		if bounded != nil {
+			countBounded(bt, bounded, n)
+			continue
+		}
+
 		// Insert or find node having a `stringcount` payload with the word. If the node is inserted
 		// as fresh, then its count will be zero. If the node was found already in the tree, then its
 		// count will be something else. In any case we increment the count.
 		// The second return value from `bt.Upsert()` is a boolean indicating whether the node was
 		// added to the tree. In this situation we don't care.
-		intree, _ := bt.Upsert(&btree.Node{Payload: &stringcount{str: sc.Text()}})
+		intree, _ := bt.Upsert(n)
 		intree.Payload.(*stringcount).count++
 
 		// Alternatively, one might:
@@ -52,6 +227,196 @@ func main() {
 		//	 intree.Payload.(*stringcount).count++
 		//}
 	}
-	bt.DepthFirstInOrder(nodeWalk)
-	// In reverse order you might use: bt.DepthFirstReverse(nodeWalk)
+	return bt, sc.Err()
+}
+
+// countBounded counts `n` in `bt`, which holds no more tokens than `bounded`, the tree of the same
+// payloads that is ordered by frequency and capped with eviction. When a token is counted, its node
+// in `bounded` is re-inserted, since its key changes. When a new token doesn't fit, the least
+// frequent one is evicted from both trees. Memory use is then fixed, irrespective of the size of
+// the input, but the counts are approximate: a token that was evicted starts from zero when it
+// comes back. Tokens that are frequent throughout the input stay, and are counted exactly.
+func countBounded(bt *btree.BTree, bounded *btree.BoundedTree, n *btree.Node) {
+	intree, inserted := bt.Upsert(n)
+	if !inserted {
+		// The key in `bounded` is the current count.
+		bounded.Delete(intree)
+	}
+	intree.Payload.(*stringcount).count++
+	if _, _, evicted := bounded.Upsert(&btree.Node{Payload: intree.Payload}); evicted != nil {
+		bt.Delete(evicted)
+	}
+}
+
+// sumCounts is the `btree.MergeFunc` for the counts of several inputs: a fresh payload holding the
+// sum.
+func sumCounts(a, b *btree.Node) *btree.Node {
+	ca, cb := a.Payload.(*stringcount), b.Payload.(*stringcount)
+	return &btree.Node{Payload: &stringcount{str: ca.str, count: ca.count + cb.count, words: ca.words}}
+}
+
+// countFiles counts the tokens of each file into a tree of its own, and returns the merge of these
+// trees, holding the combined totals. A file named "-" is stdin. The files are counted
+// concurrently, up to one per CPU. Each goroutine has its own tree, so none of them needs locking;
+// they only share the `stopwords`, which they only look up in.
+func (c *counter) countFiles(files []string) (*btree.BTree, error) {
+	trees := make([]*btree.BTree, len(files))
+	errs := make([]error, len(files))
+	cpus := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cpus <- struct{}{}
+			defer func() { <-cpus }()
+			trees[i], errs[i] = c.countFile(file)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	merged := btree.Merge(sumCounts, trees...)
+	if c.maxEntries > 0 && len(trees) > 1 {
+		// Each file was bounded on its own.
+		merged = resort(top(merged, c.maxEntries), merged.Less)
+	}
+	return merged, nil
+}
+
+// countFile is `count()` of the file named `file`, or of stdin when it is "-".
+func (c *counter) countFile(file string) (*btree.BTree, error) {
+	if file == "-" {
+		return c.count(os.Stdin)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	bt, err := c.count(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	return bt, nil
+}
+
+// top returns a tree of the `n` most frequent words of `bt`, ordered by `lessByFreq()`. It feeds
+// the words into a second tree that is capped at `n` nodes: each time it overflows, its largest
+// node, the least frequent word, is evicted.
+func top(bt *btree.BTree, n int) *btree.BTree {
+	capped := btree.New(lessByFreq).WithMaxSize(n, btree.EvictMax)
+	bt.DepthFirstInOrder(func(nd *btree.Node) {
+		capped.Upsert(&btree.Node{Payload: nd.Payload})
+	})
+	return capped.Tree()
+}
+
+// resort returns a tree of the payloads of `bt`, ordered by `less`. The payloads are shared, only
+// the nodes are new. They are sorted first, so that `BulkUpsert()` links them into a balanced tree.
+func resort(bt *btree.BTree, less btree.LessFunc) *btree.BTree {
+	nodes := []*btree.Node{}
+	bt.DepthFirstInOrder(func(n *btree.Node) {
+		nodes = append(nodes, &btree.Node{Payload: n.Payload})
+	})
+	sort.Slice(nodes, func(i, j int) bool { return less(nodes[i], nodes[j]) })
+	sorted := btree.New(less)
+	sorted.BulkUpsert(nodes)
+	return sorted
+}
+
+// write writes the tokens of `bt` and their counts to `w`, in order, as "text" (a count and a
+// token per line), "json" (an array of `{"token":...,"count":...}` objects), "csv" or "tsv".
+func write(w io.Writer, bt *btree.BTree, format string) error {
+	switch format {
+	case "text":
+		var err error
+		bt.DepthFirstInOrder(func(n *btree.Node) {
+			if err == nil {
+				_, err = fmt.Fprintln(w, n.Payload.(*stringcount).count, n.Payload.(*stringcount).str)
+			}
+		})
+		return err
+	case "json":
+		entries := []*stringcount{}
+		bt.DepthFirstInOrder(func(n *btree.Node) {
+			entries = append(entries, n.Payload.(*stringcount))
+		})
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case "csv":
+		return bt.WriteCSV(w, stringcountRows{})
+	case "tsv":
+		return bt.WriteDelimited(w, stringcountRows{}, '\t')
+	}
+	return fmt.Errorf("unknown -format %q, want text, json, csv or tsv", format)
+}
+
+func main() {
+	mode := flag.String("split", "words", "how to split the input into tokens: words, lines, runes "+
+		"or regex")
+	pattern := flag.String("regex", `[\p{L}\p{N}']+`, "with -split regex, the `pattern` of a token")
+	format := flag.String("format", "text", "output format: text, json, csv or tsv")
+	order := flag.String("sort", "", "output order: alpha or freq (most frequent first); by default "+
+		"alpha, or freq with -top")
+	stopwords := flag.String("stopwords", "", "when set, a `FILE` of words (separated by white "+
+		"space) not to count")
+	ngram := flag.Int("ngram", 1, "count sequences of `N` consecutive tokens, e.g. 2 for bigrams")
+	maxEntries := flag.Int("max-entries", 0, "when > 0, keep counting at most `K` distinct tokens, "+
+		"evicting the least frequent, which bounds memory but makes the counts approximate")
+	topN := flag.Int("top", 0, "when > 0, only show the `N` most frequent tokens")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(),
+			"Usage: wordcount [flags] [FILE...] (reads the files or stdin, shows tokens and their "+
+				"frequencies)")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	// The input is the files on the cmdline, or stdin when there are none.
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	if *order == "" {
+		*order = "alpha"
+		if *topN > 0 {
+			*order = "freq"
+		}
+	}
+	less, ok := orders[*order]
+	if !ok {
+		log.Fatalf("unknown -sort %q, want alpha or freq", *order)
+	}
+	split, err := splitFunc(*mode, *pattern)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *ngram < 1 {
+		log.Fatalf("-ngram %v must be at least 1", *ngram)
+	}
+	c := &counter{split: split, ngram: *ngram, maxEntries: *maxEntries}
+	if *stopwords != "" {
+		if c.stopwords, err = loadStopwords(*stopwords); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	bt, err := c.countFiles(files)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *topN > 0 {
+		bt = top(bt, *topN)
+	}
+	bt = resort(bt, less)
+	if err := write(os.Stdout, bt, *format); err != nil {
+		log.Fatalln(err)
+	}
 }
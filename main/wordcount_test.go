@@ -1,8 +1,257 @@
 package main
 
-import "testing"
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KarelKubat/btree"
+)
+
+// words counts the words of its input.
+var words = &counter{split: bufio.ScanWords}
+
+// counts returns the payloads of `bt` in order, as "word=count".
+func counts(bt *btree.BTree) []string {
+	out := []string{}
+	bt.DepthFirstInOrder(func(n *btree.Node) {
+		sc := n.Payload.(*stringcount)
+		out = append(out, fmt.Sprintf("%v=%v", sc.str, sc.count))
+	})
+	return out
+}
 
 func TestAll(t *testing.T) {
 	// Testframe for demo program.
 	// Probably to remain empty.
 }
+
+func TestCount(t *testing.T) {
+	bt, err := words.count(strings.NewReader("the cat saw\nthe dog  the end"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"cat=1", "dog=1", "end=1", "saw=1", "the=3"}
+	if got := counts(bt); !reflect.DeepEqual(got, want) {
+		t.Errorf("count() = %v, want %v", got, want)
+	}
+}
+
+func TestTop(t *testing.T) {
+	bt, err := words.count(strings.NewReader("b a c b a b d"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"b=3", "a=2"}
+	if got := counts(top(bt, 2)); !reflect.DeepEqual(got, want) {
+		t.Errorf("top(2) = %v, want %v", got, want)
+	}
+	want = []string{"b=3", "a=2", "c=1", "d=1"}
+	if got := counts(top(bt, 10)); !reflect.DeepEqual(got, want) {
+		t.Errorf("top(10) = %v, want %v", got, want)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	const input = "It's a cat, a\nDOG... and a cat!\n"
+	for _, test := range []struct {
+		mode, pattern string
+		want          []string
+	}{
+		{"words", "", []string{"DOG...=1", "It's=1", "a=3", "and=1", "cat!=1", "cat,=1"}},
+		{"lines", "", []string{"DOG... and a cat!=1", "It's a cat, a=1"}},
+		{"runes", "", []string{"\n=2", " =6", "!=1", "'=1", ",=1", ".=3", "D=1", "G=1", "I=1", "O=1",
+			"a=6", "c=2", "d=1", "n=1", "s=1", "t=3"}},
+		{"regex", `[\p{L}']+`, []string{"DOG=1", "It's=1", "a=3", "and=1", "cat=2"}},
+		// Empty matches are skipped.
+		{"regex", `\w*`, []string{"DOG=1", "It=1", "a=3", "and=1", "cat=2", "s=1"}},
+	} {
+		split, err := splitFunc(test.mode, test.pattern)
+		if err != nil {
+			t.Fatalf("splitFunc(%q, %q): %v", test.mode, test.pattern, err)
+		}
+		bt, err := (&counter{split: split}).count(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := counts(bt); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("count() with -split %v %v = %q, want %q", test.mode, test.pattern, got, test.want)
+		}
+	}
+	for _, test := range []struct{ mode, pattern string }{{"bytes", ""}, {"regex", "("}} {
+		if _, err := splitFunc(test.mode, test.pattern); err == nil {
+			t.Errorf("splitFunc(%q, %q) = nil error, want one", test.mode, test.pattern)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	bt, err := words.count(strings.NewReader("b a, b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct{ format, want string }{
+		{"text", "1 a,\n2 b\n"},
+		{"json", `[{"token":"a,","count":1},{"token":"b","count":2}]` + "\n"},
+		{"csv", "token,count\n\"a,\",1\nb,2\n"},
+		{"tsv", "token\tcount\na,\t1\nb\t2\n"},
+	} {
+		var buf strings.Builder
+		if err := write(&buf, bt, test.format); err != nil {
+			t.Fatalf("write(%q): %v", test.format, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("write(%q) = %q, want %q", test.format, got, test.want)
+		}
+	}
+	if err := write(&strings.Builder{}, bt, "xml"); err == nil {
+		t.Errorf("write(\"xml\") = nil error, want one")
+	}
+	// The CSV output can be read back.
+	var buf strings.Builder
+	if err := write(&buf, bt, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	back := btree.New(lessFunc)
+	if err := back.ReadCSV(strings.NewReader(buf.String()), stringcountRows{}); err != nil {
+		t.Fatalf("ReadCSV(): %v", err)
+	}
+	if got, want := counts(back), counts(bt); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadCSV() = %v, want %v", got, want)
+	}
+}
+
+func TestResort(t *testing.T) {
+	bt, err := words.count(strings.NewReader("b a c b a b d"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	byFreq := resort(bt, lessByFreq)
+	want := []string{"b=3", "a=2", "c=1", "d=1"}
+	if got := counts(byFreq); !reflect.DeepEqual(got, want) {
+		t.Errorf("resort(lessByFreq) = %v, want %v", got, want)
+	}
+	if err := byFreq.Verify(); err != nil {
+		t.Errorf("resort(lessByFreq).Verify() = %v", err)
+	}
+	want = []string{"a=2", "b=3"}
+	if got := counts(resort(top(bt, 2), lessFunc)); !reflect.DeepEqual(got, want) {
+		t.Errorf("resort(top(2), lessFunc) = %v, want %v", got, want)
+	}
+}
+
+func TestCountFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{}
+	for i, text := range []string{"a b c", "b c d", "c d e", "the a", "of e"} {
+		file := filepath.Join(dir, fmt.Sprintf("%v.txt", i))
+		if err := os.WriteFile(file, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	stopwords := btree.New(lessFunc)
+	for _, word := range []string{"of", "the"} {
+		stopwords.Upsert(&btree.Node{Payload: &stringcount{str: word}})
+	}
+	// The files are counted concurrently, sharing the stopwords.
+	bt, err := (&counter{split: bufio.ScanWords, stopwords: stopwords}).countFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a=2", "b=2", "c=3", "d=2", "e=2"}
+	if got := counts(bt); !reflect.DeepEqual(got, want) {
+		t.Errorf("countFiles() = %v, want %v", got, want)
+	}
+	if _, err := words.countFiles([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Errorf("countFiles() of a missing file = nil error, want one")
+	}
+}
+
+func TestStopwords(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "stopwords")
+	if err := os.WriteFile(file, []byte("a the\nof\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stopwords, err := loadStopwords(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &counter{split: bufio.ScanWords, stopwords: stopwords}
+	bt, err := c.count(strings.NewReader("the end of a tale of the tree"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"end=1", "tale=1", "tree=1"}
+	if got := counts(bt); !reflect.DeepEqual(got, want) {
+		t.Errorf("count() with stopwords = %v, want %v", got, want)
+	}
+	if _, err := loadStopwords(file + ".missing"); err == nil {
+		t.Errorf("loadStopwords() of a missing file = nil error, want one")
+	}
+}
+
+func TestNgram(t *testing.T) {
+	stopwords := btree.New(lessFunc)
+	stopwords.Upsert(&btree.Node{Payload: &stringcount{str: "the"}})
+	for _, test := range []struct {
+		n    int
+		want []string
+	}{
+		{1, []string{"a=3", "b=2", "c=1"}},
+		{2, []string{"a b=2", "b a=1", "b c=1", "c a=1"}},
+		{3, []string{"a b a=1", "a b c=1", "b a b=1", "b c a=1"}},
+		{7, []string{}},
+	} {
+		c := &counter{split: bufio.ScanWords, ngram: test.n, stopwords: stopwords}
+		bt, err := c.count(strings.NewReader("a b a the b c\na"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := counts(bt); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("count() of %v-grams = %v, want %v", test.n, got, test.want)
+		}
+		if err := bt.Verify(); err != nil {
+			t.Errorf("count() of %v-grams: Verify() = %v", test.n, err)
+		}
+	}
+}
+
+func TestMaxEntries(t *testing.T) {
+	c := &counter{split: bufio.ScanWords, maxEntries: 2}
+	bt, err := c.count(strings.NewReader("a b a c a d a e b b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// c, d and e are evicted as soon as they are seen.
+	want := []string{"a=4", "b=3"}
+	if got := counts(bt); !reflect.DeepEqual(got, want) {
+		t.Errorf("count() with maxEntries = %v, want %v", got, want)
+	}
+	if err := bt.Verify(); err != nil {
+		t.Errorf("count() with maxEntries: Verify() = %v", err)
+	}
+
+	// Merging files keeps the bound.
+	dir := t.TempDir()
+	files := []string{}
+	for i, text := range []string{"a a b c", "c c d d d"} {
+		file := filepath.Join(dir, fmt.Sprintf("%v.txt", i))
+		if err := os.WriteFile(file, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	if bt, err = c.countFiles(files); err != nil {
+		t.Fatal(err)
+	}
+	// The files keep {a=2, b=1} and {c=2, d=3}; of equally frequent a and c, a goes first.
+	want = []string{"a=2", "d=3"}
+	if got := counts(bt); !reflect.DeepEqual(got, want) {
+		t.Errorf("countFiles() with maxEntries = %v, want %v", got, want)
+	}
+}
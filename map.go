@@ -0,0 +1,29 @@
+package btree
+
+import "sort"
+
+// TransformFunc is supplied by the caller of `Map()`. It receives a node of the original tree and
+// returns the node to store in the new tree, or `nil` to leave it out.
+type TransformFunc func(n *Node) *Node
+
+// Map returns a new tree holding the nodes returned by `transform`, one call per node of the
+// original tree. The new tree is ordered using `less`; when `less` is `nil`, then the `LessFunc`
+// of the original tree is used. The nodes returned by `transform` should be fresh ones: their
+// `Left` and `Right` pointers are overwritten. When two transformed nodes compare as equal, only
+// the first one is kept, just as with `Upsert()`. The transformed nodes are sorted, and the new
+// tree is built balanced.
+func (b *BTree) Map(transform TransformFunc, less LessFunc) *BTree {
+	if less == nil {
+		less = b.Less
+	}
+	var nodes []*Node
+	b.DepthFirstInOrder(func(n *Node) {
+		if t := transform(n); t != nil {
+			nodes = append(nodes, t)
+		}
+	})
+	m := New(less)
+	sort.SliceStable(nodes, func(i, j int) bool { return less(nodes[i], nodes[j]) })
+	m.Root = buildBalanced(m.dedup(nodes))
+	return m
+}
@@ -0,0 +1,68 @@
+package btree
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	b := newIntTree(3, 1, 4, 5, 9, 2, 6)
+	strLess := func(a, b *Node) bool { return a.Payload.(string) < b.Payload.(string) }
+
+	m := b.Map(func(n *Node) *Node {
+		if n.Payload.(int) == 9 {
+			return nil
+		}
+		return &Node{Payload: strconv.Itoa(n.Payload.(int) * 10)}
+	}, strLess)
+
+	got := []string{}
+	m.DepthFirstInOrder(func(n *Node) { got = append(got, n.Payload.(string)) })
+	sort.Strings(got)
+	want := []string{"10", "20", "30", "40", "50", "60"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Map() = %v, want %v", got, want)
+	}
+	if _, inserted := m.Upsert(&Node{Payload: "40"}); inserted {
+		t.Errorf("Upsert(%q) on mapped tree = inserted, want already present", "40")
+	}
+}
+
+func TestMapKeepsLessFunc(t *testing.T) {
+	b := newIntTree(1, 2, 3)
+	m := b.Map(func(n *Node) *Node { return &Node{Payload: n.Payload.(int) + 1} }, nil)
+	if !m.Equal(newIntTree(2, 3, 4), intEqual) {
+		t.Errorf("Map(+1) = %v, want the nodes 2, 3 and 4", inOrderInts(m))
+	}
+}
+
+func TestMapIsBalanced(t *testing.T) {
+	vals := make([]int, 10000)
+	for i := range vals {
+		vals[i] = i
+	}
+	b := New(intLess)
+	b.BulkUpsert(intNodes(vals...))
+	m := b.Map(func(n *Node) *Node { return &Node{Payload: n.Payload.(int) / 2} }, nil)
+	if got := len(inOrderInts(m)); got != 5000 {
+		t.Errorf("Map(/2) holds %v nodes, want 5000", got)
+	}
+	if h := m.ShapeStats().Height; h > 13 {
+		t.Errorf("height of Map(/2) of 5000 nodes = %v, want at most 13", h)
+	}
+}
+
+func TestMapKeepsFirstOfEqual(t *testing.T) {
+	b := newIntTree(1, 2, 3, 4)
+	type pair struct{ key, val int }
+	m := b.Map(func(n *Node) *Node {
+		return &Node{Payload: pair{key: n.Payload.(int) / 2, val: n.Payload.(int)}}
+	}, func(x, y *Node) bool { return x.Payload.(pair).key < y.Payload.(pair).key })
+	got := []pair{}
+	m.DepthFirstInOrder(func(n *Node) { got = append(got, n.Payload.(pair)) })
+	if want := []pair{{0, 1}, {1, 2}, {2, 4}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Map(/2) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package btree
+
+import (
+	"time"
+	"unsafe"
+)
+
+// Sizes for `MemoryUsage()`: a `Node`, an entry of the set of nodes that are marked deleted, and
+// an entry of the expiry times.
+const (
+	nodeBytes      = int64(unsafe.Sizeof(Node{}))
+	tombstoneBytes = int64(2 * unsafe.Sizeof(uintptr(0)))
+	expiryBytes    = int64(unsafe.Sizeof(uintptr(0)) + unsafe.Sizeof(time.Time{}))
+)
+
+// MemoryUsage estimates the number of bytes that the tree holds, e.g. for capacity planning or to
+// decide whether more data may be admitted. It counts the tree itself and its nodes, including
+// the nodes that `LazyDelete` marked deleted; when the tree has an `Arena`, all nodes of its
+// blocks count. `payloadSize`, when not `nil`, returns the number of bytes that a payload refers
+// to beyond the `Node` itself, e.g. the bytes of a string. Allocator overhead is not included, so
+// the estimate is a lower bound.
+func (b *BTree) MemoryUsage(payloadSize func(n *Node) int) int64 {
+	defer b.beginRead("MemoryUsage")()
+	var nodes, payloads int64
+	stack := []*Node{}
+	if b.Root != nil {
+		stack = append(stack, b.Root)
+	}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		nodes++
+		if payloadSize != nil {
+			payloads += int64(payloadSize(n))
+		}
+		if n.Left != nil {
+			stack = append(stack, n.Left)
+		}
+		if n.Right != nil {
+			stack = append(stack, n.Right)
+		}
+	}
+	if b.Arena != nil {
+		nodes = max(nodes, int64(b.Arena.blocks*b.Arena.blockSize))
+	}
+	return int64(unsafe.Sizeof(*b)) + nodes*nodeBytes + payloads +
+		int64(len(b.tombstones))*tombstoneBytes + int64(len(b.expiry))*expiryBytes
+}
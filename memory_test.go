@@ -0,0 +1,41 @@
+package btree
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestMemoryUsage(t *testing.T) {
+	empty := int64(unsafe.Sizeof(BTree{}))
+	b := New(kvLess)
+	if got := b.MemoryUsage(nil); got != empty {
+		t.Errorf("MemoryUsage() of an empty tree = %v, want %v", got, empty)
+	}
+	for _, k := range []string{"a", "bb", "ccc"} {
+		b.Upsert(&Node{Payload: kv{k, ""}})
+	}
+	if got, want := b.MemoryUsage(nil), empty+3*nodeBytes; got != want {
+		t.Errorf("MemoryUsage(nil) = %v, want %v", got, want)
+	}
+	keyBytes := func(n *Node) int { return len(n.Payload.(kv).key) }
+	if got, want := b.MemoryUsage(keyBytes), empty+3*nodeBytes+6; got != want {
+		t.Errorf("MemoryUsage(keyBytes) = %v, want %v", got, want)
+	}
+
+	b.LazyDelete = true
+	b.Delete(&Node{Payload: kv{"a", ""}})
+	if got, want := b.MemoryUsage(nil), empty+3*nodeBytes+tombstoneBytes; got != want {
+		t.Errorf("MemoryUsage() with a tombstone = %v, want %v", got, want)
+	}
+}
+
+func TestMemoryUsageArena(t *testing.T) {
+	b := New(intLess)
+	b.Arena = NewArena(100)
+	for i := 0; i < 150; i++ {
+		b.Upsert(b.NewNode(i))
+	}
+	if got, want := b.MemoryUsage(nil), int64(unsafe.Sizeof(*b))+200*nodeBytes; got != want {
+		t.Errorf("MemoryUsage() = %v, want %v for two blocks", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package btree
+
+// MergeFunc is supplied to `Merge()` to combine two nodes that are equal according to the
+// `LessFunc`; `a` comes from an earlier tree than `b`. It returns the node whose payload ends up in
+// the merged tree, which may be a new one, e.g. holding the sum of two counts. The payloads of `a`
+// and `b` belong to the trees that are merged, so they shouldn't be changed.
+type MergeFunc func(a, b *Node) *Node
+
+// Merge returns the union of `trees`, which must all be ordered by the same `LessFunc`. Nodes that
+// occur in several trees are combined using `combine`, in the order of the trees. When `combine`
+// is `nil`, the node of the first tree that holds it is kept, just as `Upsert()` keeps the node
+// that is already present.
+//
+// The trees are merged in one in-order pass, using a `Tournament` of their nodes. The merged tree
+// uses the `LessFunc` of the first tree, and is built balanced, from fresh nodes that share their
+// payloads with the input trees (or with the nodes returned by `combine`).
+func Merge(combine MergeFunc, trees ...*BTree) *BTree {
+	if len(trees) == 0 {
+		return New(nil)
+	}
+	less := trees[0].Less
+	iters := make([]inorderIter, len(trees))
+	heads := make([]*Node, len(trees))
+	for i, t := range trees {
+		iters[i].init(t)
+		heads[i] = iters[i].next()
+	}
+	tournament := NewTournament(less, heads)
+
+	var merged []*Node
+	for n, stream := tournament.Winner(); n != nil; n, stream = tournament.Winner() {
+		tournament.Replay(stream, iters[stream].next())
+		// Ties are won by the lower stream, so equal nodes arrive in the order of the trees.
+		if last := len(merged) - 1; last >= 0 && !less(merged[last], n) {
+			if combine != nil {
+				merged[last].Payload = combine(merged[last], n).Payload
+			}
+			continue
+		}
+		merged = append(merged, &Node{Payload: n.Payload})
+	}
+	return &BTree{
+		Root: buildBalanced(merged),
+		Less: less,
+	}
+}
@@ -0,0 +1,99 @@
+package btree
+
+import "reflect"
+
+// Conflict describes a node that was changed differently in two trees that derive from a common
+// base. A field is `nil` when the node is absent in that tree.
+type Conflict struct {
+	Base, Mine, Theirs *Node
+}
+
+// ConflictFunc is supplied by the caller of `Merge3()` to resolve conflicts. It returns the node
+// whose payload should end up in the merged tree (`nil` to leave the node out) and `true`, or
+// `false` when the conflict can't be resolved.
+type ConflictFunc func(c Conflict) (n *Node, resolved bool)
+
+// Merge3 performs a three-way merge of the trees `mine` and `theirs`, which both derive from
+// `base`. A node that is changed, added or removed in only one of the two trees is taken over
+// from that tree. When both trees changed a node in a different way, `resolve` is called. When
+// `resolve` is `nil` or can't resolve the conflict, then the version of `mine` is kept and the
+// conflict is returned.
+//
+// The merged tree uses the `LessFunc` of `mine`, with which all three trees must be ordered.
+// Payloads are compared using `reflect.DeepEqual()`. The merged tree is built balanced, from fresh
+// nodes that share their payloads with the input trees.
+func Merge3(base, mine, theirs *BTree, resolve ConflictFunc) (*BTree, []Conflict) {
+	less := mine.Less
+	var ib, im, it inorderIter
+	ib.init(base)
+	im.init(mine)
+	it.init(theirs)
+	b, m, t := ib.next(), im.next(), it.next()
+
+	var merged []*Node
+	var conflicts []Conflict
+	for b != nil || m != nil || t != nil {
+		// Find the smallest of the current nodes, and take all of the current nodes that match it.
+		min := b
+		for _, n := range []*Node{m, t} {
+			if n != nil && (min == nil || less(n, min)) {
+				min = n
+			}
+		}
+		c := Conflict{
+			Base:   matching(less, b, min),
+			Mine:   matching(less, m, min),
+			Theirs: matching(less, t, min),
+		}
+		if c.Base != nil {
+			b = ib.next()
+		}
+		if c.Mine != nil {
+			m = im.next()
+		}
+		if c.Theirs != nil {
+			t = it.next()
+		}
+
+		var keep *Node
+		switch {
+		case samePayload(c.Mine, c.Theirs), samePayload(c.Base, c.Theirs):
+			keep = c.Mine
+		case samePayload(c.Base, c.Mine):
+			keep = c.Theirs
+		default:
+			resolved := false
+			if resolve != nil {
+				keep, resolved = resolve(c)
+			}
+			if !resolved {
+				keep = c.Mine
+				conflicts = append(conflicts, c)
+			}
+		}
+		if keep != nil {
+			merged = append(merged, &Node{Payload: keep.Payload})
+		}
+	}
+	return &BTree{
+		Root: buildBalanced(merged),
+		Less: less,
+	}, conflicts
+}
+
+// matching returns `n` when it is equal to `to`, or `nil` otherwise.
+func matching(less LessFunc, n, to *Node) *Node {
+	if n == nil || less(n, to) || less(to, n) {
+		return nil
+	}
+	return n
+}
+
+// samePayload returns `true` when both nodes are absent, or both are present with deeply equal
+// payloads.
+func samePayload(a, b *Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return reflect.DeepEqual(a.Payload, b.Payload)
+}
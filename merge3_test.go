@@ -0,0 +1,55 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func kvPairs(b *BTree) []string {
+	out := []string{}
+	b.DepthFirstInOrder(func(n *Node) {
+		out = append(out, n.Payload.(kv).key, n.Payload.(kv).val)
+	})
+	return out
+}
+
+func TestMerge3(t *testing.T) {
+	base := newKVTree("a", "1", "b", "2", "c", "3", "d", "4")
+	mine := newKVTree("a", "1", "b", "20", "d", "4", "e", "5")   // b changed, c removed, e added
+	theirs := newKVTree("a", "10", "b", "2", "c", "3", "f", "6") // a changed, d removed, f added
+
+	merged, conflicts := Merge3(base, mine, theirs, nil)
+	if len(conflicts) != 0 {
+		t.Errorf("Merge3() conflicts = %+v, want none", conflicts)
+	}
+	if got, want := kvPairs(merged), []string{"a", "10", "b", "20", "e", "5", "f", "6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge3() = %v, want %v", got, want)
+	}
+}
+
+func TestMerge3Conflicts(t *testing.T) {
+	base := newKVTree("a", "1", "b", "2")
+	mine := newKVTree("a", "mine", "b", "2", "c", "mine")
+	theirs := newKVTree("a", "theirs", "c", "theirs")
+
+	merged, conflicts := Merge3(base, mine, theirs, nil)
+	if got, want := len(conflicts), 2; got != want {
+		t.Fatalf("Merge3() = %v conflicts, want %v", got, want)
+	}
+	if conflicts[1].Base != nil {
+		t.Errorf("Merge3() conflict on added key has base %v, want nil", conflicts[1].Base)
+	}
+	if got, want := kvPairs(merged), []string{"a", "mine", "c", "mine"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge3() = %v, want %v", got, want)
+	}
+
+	merged, conflicts = Merge3(base, mine, theirs, func(c Conflict) (*Node, bool) {
+		return c.Theirs, true
+	})
+	if len(conflicts) != 0 {
+		t.Errorf("Merge3(resolve) conflicts = %+v, want none", conflicts)
+	}
+	if got, want := kvPairs(merged), []string{"a", "theirs", "c", "theirs"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge3(resolve) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	a := newKVTree("a", "1", "c", "1", "e", "1")
+	b := newKVTree("b", "2", "c", "2")
+	c := newKVTree("c", "3", "e", "3", "f", "3")
+
+	join := func(x, y *Node) *Node {
+		return &Node{Payload: kv{key: x.Payload.(kv).key, val: x.Payload.(kv).val + y.Payload.(kv).val}}
+	}
+	merged := Merge(join, a, b, c)
+	want := []string{"a", "1", "b", "2", "c", "123", "e", "13", "f", "3"}
+	if got := kvPairs(merged); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge(join) = %v, want %v", got, want)
+	}
+	if err := merged.Verify(); err != nil {
+		t.Errorf("Merge(join).Verify() = %v", err)
+	}
+	// The inputs are untouched.
+	if got, want := kvPairs(a), []string{"a", "1", "c", "1", "e", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after Merge(): first tree = %v, want %v", got, want)
+	}
+
+	// Without a MergeFunc, the first tree wins.
+	want = []string{"a", "1", "b", "2", "c", "1", "e", "1", "f", "3"}
+	if got := kvPairs(Merge(nil, a, b, c)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge(nil) = %v, want %v", got, want)
+	}
+}
+
+func TestMergeEdgeCases(t *testing.T) {
+	if got := Merge(nil); got.Root != nil {
+		t.Errorf("Merge() of no trees = %v, want an empty tree", got.Root)
+	}
+	got, want := inOrderInts(Merge(nil, New(intLess), newIntTree(2, 1))), []int{1, 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge(empty, {1, 2}) = %v, want %v", got, want)
+	}
+	// Deleted nodes aren't merged.
+	b := newIntTree(1, 2, 3)
+	b.LazyDelete = true
+	b.Delete(&Node{Payload: 2})
+	if got, want := inOrderInts(Merge(nil, b)), []int{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() of a tree with a tombstone = %v, want %v", got, want)
+	}
+}
+
+func TestMergeBuildsFreshBalancedTree(t *testing.T) {
+	vals := make([]int, 1000)
+	for i := range vals {
+		vals[i] = i
+	}
+	a, b := newIntTree(vals[:500]...), newIntTree(vals[500:]...)
+	merged := Merge(nil, a, b)
+	if got := inOrderInts(merged); !reflect.DeepEqual(got, vals) {
+		t.Errorf("Merge() of two chains = %v, want %v", got, vals)
+	}
+	if h := merged.ShapeStats().Height; h != 10 {
+		t.Errorf("height of Merge() of 1000 nodes = %v, want 10", h)
+	}
+	if merged.Root == a.Root || merged.Root == b.Root || a.Root.Right.Payload != 1 {
+		t.Errorf("Merge() reused or relinked the nodes of the input trees")
+	}
+}
+
+func TestMergeSkipsExpiredNodes(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	a := newIntTree(1, 3)
+	a.Now = clock.now
+	a.UpsertWithExpiry(&Node{Payload: 2}, clock.t.Add(time.Second))
+	b := newIntTree(2, 4)
+	if got, want := inOrderInts(Merge(nil, a, b)), []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() before the expiry = %v, want %v", got, want)
+	}
+
+	clock.t = clock.t.Add(time.Minute)
+	if got, want := inOrderInts(Merge(nil, a)), []int{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() of a tree with expired nodes = %v, want %v", got, want)
+	}
+	// The node of `b` isn't combined with the expired one of `a`.
+	sum := func(x, y *Node) *Node { return &Node{Payload: x.Payload.(int) + y.Payload.(int)} }
+	if got, want := inOrderInts(Merge(sum, a, b)), []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge(sum) with expired nodes = %v, want %v", got, want)
+	}
+}
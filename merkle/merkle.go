@@ -0,0 +1,201 @@
+// Package merkle implements a Merkle tree: a binary tree in which each node also records a hash
+// of its payload and of the hashes of its sub-nodes. The hash of the root (`RootHash()`) then
+// summarizes the whole tree, so that two trees are compared by comparing two hashes, and
+// `Prove()` returns a small proof that a node is in the tree, which `VerifyProof()` checks against
+// the root hash alone.
+//
+// The hashes cover the shape of the tree, not only its nodes: trees with the same nodes but
+// different shapes have different root hashes. The tree is a `btree.Augmented`, which keeps
+// itself balanced, so its shape depends on the order in which nodes were added and removed.
+// `Rebalance()` gives trees with the same nodes the same shape, and so changes the root hash;
+// rebalance trees before comparing them.
+//
+// The nodes that are added are the caller's `*btree.Node`s, ordered by the caller's
+// `btree.LessFunc`. The tree keeps them in a `btree.Augmented` of its own, so their `Left` and
+// `Right` pointers are not used.
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/KarelKubat/btree"
+)
+
+// Hash is the SHA-256 hash of a node. The hash of an absent node is all zeroes.
+type Hash [sha256.Size]byte
+
+// EncodeFunc returns the byte form of the payload of a node, which is what is hashed. Equal
+// payloads must have equal byte forms, also across machines.
+type EncodeFunc func(n *btree.Node) []byte
+
+// Step is one level of a `Proof`: a node on the path from the proven node to the root.
+type Step struct {
+	// Payload is the byte form of the node's payload.
+	Payload []byte
+	// Sibling is the hash of the node's sub-node that is not on the path.
+	Sibling Hash
+	// Left is true when the path comes from the node's left sub-node.
+	Left bool
+}
+
+// Proof shows that a node is in a tree with a given root hash, see `Tree.Prove()`.
+type Proof struct {
+	// Left and Right are the hashes of the proven node's sub-nodes.
+	Left, Right Hash
+	// Path holds the node's ancestors, from its parent up to the root.
+	Path []Step
+}
+
+// item is the payload of the nodes of the underlying tree: a caller's node, and the hash of the
+// subtree.
+type item struct {
+	n    *btree.Node
+	hash Hash
+}
+
+// Tree is a Merkle tree. It is not safe for concurrent use.
+type Tree struct {
+	less   btree.LessFunc
+	encode EncodeFunc
+	a      *btree.Augmented
+}
+
+// New returns an empty `Tree` that orders its nodes using `less`, and hashes their payloads
+// in the form that `encode` returns.
+func New(less btree.LessFunc, encode EncodeFunc) *Tree {
+	t := &Tree{less: less, encode: encode}
+	t.a = btree.NewAugmented(func(a, b *btree.Node) bool {
+		return less(a.Payload.(*item).n, b.Payload.(*item).n)
+	}, t.fix)
+	return t
+}
+
+func itemOf(n *btree.Node) *item {
+	return n.Payload.(*item)
+}
+
+func hashOf(n *btree.Node) Hash {
+	if n == nil {
+		return Hash{}
+	}
+	return itemOf(n).hash
+}
+
+// hash returns the hash of a node with the given payload and hashes of sub-nodes. The payload is
+// preceded by its length, so that no two combinations hash the same input.
+func hash(payload []byte, left, right Hash) Hash {
+	h := sha256.New()
+	var size [binary.MaxVarintLen64]byte
+	h.Write(size[:binary.PutUvarint(size[:], uint64(len(payload)))])
+	h.Write(payload)
+	h.Write(left[:])
+	h.Write(right[:])
+	var sum Hash
+	h.Sum(sum[:0])
+	return sum
+}
+
+// fix recomputes the hash of the subtree under `n` from those of its sub-nodes.
+func (t *Tree) fix(n *btree.Node) {
+	it := itemOf(n)
+	it.hash = hash(t.encode(it.n), hashOf(n.Left), hashOf(n.Right))
+}
+
+// Len returns the number of nodes.
+func (t *Tree) Len() int {
+	return t.a.Len()
+}
+
+// RootHash returns the hash of the tree. It is all zeroes for an empty tree.
+func (t *Tree) RootHash() Hash {
+	return hashOf(t.a.Root())
+}
+
+// key returns a node of the underlying tree to look up the caller's node `n` with.
+func key(n *btree.Node) *btree.Node {
+	return &btree.Node{Payload: &item{n: n}}
+}
+
+// Upsert adds `n`, unless an equal node is present, like `btree.BTree.Upsert()`. The return value
+// `intree` is the node in the tree, and `inserted` is `true` when `n` was added.
+func (t *Tree) Upsert(n *btree.Node) (intree *btree.Node, inserted bool) {
+	at, inserted := t.a.Upsert(key(n))
+	return itemOf(at).n, inserted
+}
+
+// Find looks up a node, like `btree.BTree.Find()`. Its payload must not be changed in a way that
+// changes its byte form, except via `Update()`.
+func (t *Tree) Find(n *btree.Node) (intree *btree.Node, found bool) {
+	if at, found := t.a.Find(key(n)); found {
+		return itemOf(at).n, true
+	}
+	return nil, false
+}
+
+// Update calls `fn` with the node like `n`, so that its payload may be changed, and then
+// recomputes the hashes that depend on it. The payload must keep its place in the order. The
+// return value is `false` when there is no such node.
+func (t *Tree) Update(n *btree.Node, fn btree.WalkFunc) bool {
+	return t.a.Update(key(n), func(at *btree.Node) { fn(itemOf(at).n) })
+}
+
+// Delete removes a node, like `btree.BTree.Delete()`.
+func (t *Tree) Delete(n *btree.Node) (removed *btree.Node, deleted bool) {
+	if removed, deleted = t.a.Delete(key(n)); !deleted {
+		return nil, false
+	}
+	return itemOf(removed).n, true
+}
+
+// Prove returns the proof that the node like `n` is in the tree, which `VerifyProof()` checks
+// given the root hash and the node's payload. The return value `found` is `false` when there is
+// no such node.
+func (t *Tree) Prove(n *btree.Node) (proof Proof, found bool) {
+	path, at := t.a.Path(key(n))
+	if at == nil {
+		return Proof{}, false
+	}
+	proof.Left, proof.Right = hashOf(at.Left), hashOf(at.Right)
+	for i := len(path) - 1; i >= 0; i-- {
+		parent := path[i]
+		step := Step{
+			Payload: t.encode(itemOf(parent).n),
+			Left:    parent.Left == at,
+		}
+		if step.Left {
+			step.Sibling = hashOf(parent.Right)
+		} else {
+			step.Sibling = hashOf(parent.Left)
+		}
+		proof.Path = append(proof.Path, step)
+		at = parent
+	}
+	return proof, true
+}
+
+// VerifyProof returns `true` when `proof` shows that a node whose payload has the byte form
+// `payload` is in the tree whose root hash is `root`. It only needs the proof, not the tree.
+func VerifyProof(root Hash, payload []byte, proof Proof) bool {
+	h := hash(payload, proof.Left, proof.Right)
+	for _, step := range proof.Path {
+		if step.Left {
+			h = hash(step.Payload, h, step.Sibling)
+		} else {
+			h = hash(step.Payload, step.Sibling, h)
+		}
+	}
+	return h == root
+}
+
+// DepthFirstInOrder calls `walk` for each node, in order.
+func (t *Tree) DepthFirstInOrder(walk btree.WalkFunc) {
+	t.a.Tree().DepthFirstInOrder(func(n *btree.Node) { walk(itemOf(n).n) })
+}
+
+// Rebalance rebalances the underlying tree, see `btree.Augmented.Rebalance()`. The tree stays
+// balanced without it, but its shape, and hence its root hash, then depend on the order of the
+// mutations. After rebalancing, trees with the same nodes have the same root hash.
+func (t *Tree) Rebalance() {
+	t.a.Rebalance()
+}
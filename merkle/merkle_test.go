@@ -0,0 +1,158 @@
+package merkle
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/KarelKubat/btree"
+)
+
+func intLess(a, b *btree.Node) bool {
+	return a.Payload.(int) < b.Payload.(int)
+}
+
+func node(v int) *btree.Node {
+	return &btree.Node{Payload: v}
+}
+
+func encode(n *btree.Node) []byte {
+	return []byte(strconv.Itoa(n.Payload.(int)))
+}
+
+func newTree(vals ...int) *Tree {
+	tr := New(intLess, encode)
+	for _, v := range vals {
+		tr.Upsert(node(v))
+	}
+	return tr
+}
+
+// recomputed returns the hash of the subtree under `n`, computed from scratch.
+func recomputed(n *btree.Node) Hash {
+	if n == nil {
+		return Hash{}
+	}
+	return hash(encode(itemOf(n).n), recomputed(n.Left), recomputed(n.Right))
+}
+
+func TestRootHash(t *testing.T) {
+	if got := New(intLess, encode).RootHash(); got != (Hash{}) {
+		t.Errorf("RootHash() of an empty tree = %x, want zeroes", got)
+	}
+	a := newTree(50, 20, 80, 10, 30)
+	if a.RootHash() != newTree(50, 20, 80, 10, 30).RootHash() {
+		t.Errorf("RootHash() differs for equal trees")
+	}
+	if a.RootHash() == newTree(50, 20, 80, 10, 31).RootHash() {
+		t.Errorf("RootHash() is the same for trees with different nodes")
+	}
+
+	b := newTree(10, 20, 30, 50, 80)
+	if a.RootHash() == b.RootHash() {
+		t.Errorf("RootHash() is the same for trees with different shapes")
+	}
+	a.Rebalance()
+	b.Rebalance()
+	if a.RootHash() != b.RootHash() {
+		t.Errorf("RootHash() differs for rebalanced trees with the same nodes")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tr := newTree(50, 20, 80)
+	before := tr.RootHash()
+	if !tr.Update(node(20), func(n *btree.Node) { n.Payload = 21 }) {
+		t.Fatalf("Update(20) = false, want true")
+	}
+	if tr.RootHash() == before || tr.RootHash() != newTree(50, 21, 80).RootHash() {
+		t.Errorf("RootHash() after Update() does not match the updated tree")
+	}
+	if tr.Update(node(20), func(*btree.Node) {}) {
+		t.Errorf("Update(20) of an absent node = true, want false")
+	}
+}
+
+func TestProve(t *testing.T) {
+	vals := []int{50, 20, 80, 10, 30, 70, 90, 60}
+	tr := newTree(vals...)
+	root := tr.RootHash()
+	for _, v := range vals {
+		proof, found := tr.Prove(node(v))
+		if !found {
+			t.Fatalf("Prove(%d) = false, want true", v)
+		}
+		if !VerifyProof(root, encode(node(v)), proof) {
+			t.Errorf("VerifyProof() of %d = false, want true", v)
+		}
+		if VerifyProof(root, encode(node(v+1)), proof) {
+			t.Errorf("VerifyProof() of %d with the payload %d = true, want false", v, v+1)
+		}
+		if len(proof.Path) > 0 {
+			proof.Path[0].Left = !proof.Path[0].Left
+			if VerifyProof(root, encode(node(v)), proof) {
+				t.Errorf("VerifyProof() of %d with a tampered path = true, want false", v)
+			}
+		}
+	}
+	if _, found := tr.Prove(node(55)); found {
+		t.Errorf("Prove(55) = true, want false")
+	}
+	if proof, _ := tr.Prove(node(60)); len(proof.Path) != 3 {
+		t.Errorf("Prove(60) has %d steps, want 3", len(proof.Path))
+	}
+}
+
+func TestRandomized(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tr := New(intLess, encode)
+	present := map[int]bool{}
+	for i := 0; i < 2000; i++ {
+		v := rng.Intn(200)
+		if rng.Intn(3) == 0 {
+			_, deleted := tr.Delete(node(v))
+			if deleted != present[v] {
+				t.Fatalf("Delete(%d) = %v, want %v", v, deleted, present[v])
+			}
+			delete(present, v)
+		} else {
+			tr.Upsert(node(v))
+			present[v] = true
+		}
+		if i%100 == 0 {
+			tr.Rebalance()
+		}
+		if tr.Len() != len(present) {
+			t.Fatalf("Len() = %d, want %d", tr.Len(), len(present))
+		}
+		if got, want := tr.RootHash(), recomputed(tr.a.Root()); got != want {
+			t.Fatalf("step %d: RootHash() = %x, recomputed %x", i, got, want)
+		}
+	}
+	root := tr.RootHash()
+	for v := range present {
+		if proof, _ := tr.Prove(node(v)); !VerifyProof(root, encode(node(v)), proof) {
+			t.Errorf("VerifyProof() of %d = false, want true", v)
+		}
+	}
+}
+
+func TestBalanced(t *testing.T) {
+	tr := New(intLess, encode)
+	// Ascending insertions would make an unbalanced tree a chain; rebuilding subtrees to avoid
+	// that must keep the hashes up to date.
+	for v := 0; v < 4096; v++ {
+		tr.Upsert(node(v))
+	}
+	if h := tr.a.Tree().ShapeStats().Height; h > 2*13+2 {
+		t.Errorf("height = %v for 4096 nodes, want at most %v", h, 2*13+2)
+	}
+	if got, want := tr.RootHash(), recomputed(tr.a.Root()); got != want {
+		t.Fatalf("RootHash() = %x, recomputed %x", got, want)
+	}
+	proof, _ := tr.Prove(node(4095))
+	if len(proof.Path) > 2*13+2 || !VerifyProof(tr.RootHash(), encode(node(4095)), proof) {
+		t.Errorf("Prove(4095) has %v steps and verifies %v, want a short, valid proof",
+			len(proof.Path), VerifyProof(tr.RootHash(), encode(node(4095)), proof))
+	}
+}
@@ -0,0 +1,97 @@
+package btree
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Metrics counts and times the operations of a tree, per method, e.g. "Upsert" or "Find", since
+// `EnableMetrics()`. It is safe for concurrent use.
+type Metrics struct {
+	mu  sync.Mutex
+	ops map[string]*OpMetrics
+}
+
+// OpMetrics are the metrics of one method.
+type OpMetrics struct {
+	// Count is the number of calls.
+	Count uint64 `json:"count"`
+	// Total is the time that the calls took together, and Max that of the slowest one. They are
+	// published in nanoseconds.
+	Total time.Duration `json:"total_ns"`
+	Max   time.Duration `json:"max_ns"`
+}
+
+// EnableMetrics starts collecting `Metrics`, and returns them. Each operation then costs reading
+// the clock twice and taking a lock. Calling it again returns the same `Metrics`.
+//
+// To publish the metrics with `expvar`, see `SyncTree.PublishMetrics()`.
+func (b *BTree) EnableMetrics() *Metrics {
+	if b.metrics == nil {
+		b.metrics = &Metrics{ops: map[string]*OpMetrics{}}
+	}
+	return b.metrics
+}
+
+// Ops returns a copy of the metrics so far, by method.
+func (m *Metrics) Ops() map[string]OpMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]OpMetrics, len(m.ops))
+	for op, om := range m.ops {
+		out[op] = *om
+	}
+	return out
+}
+
+// timed wraps `end`, which ends the operation `op`, so that the operation is counted and timed.
+func (m *Metrics) timed(op string, end func()) func() {
+	if m == nil {
+		return end
+	}
+	start := time.Now()
+	return func() {
+		end()
+		d := time.Since(start)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		om := m.ops[op]
+		if om == nil {
+			om = &OpMetrics{}
+			m.ops[op] = om
+		}
+		om.Count++
+		om.Total += d
+		om.Max = max(om.Max, d)
+	}
+}
+
+// PublishMetrics enables the metrics of the wrapped tree (see `BTree.EnableMetrics()`), and
+// publishes them with `expvar` as the variable `name`, so that they are served by e.g.
+// `/debug/vars`. The variable is a JSON object of:
+//
+//   - "size": the number of nodes, and "height": the number of levels of the tree. They are
+//     determined under the read lock when the variable is read, which takes time in proportion
+//     to the size of the tree.
+//   - "ops": the `OpMetrics` by method, e.g. `{"Upsert": {"count": 3, "total_ns": 1200,
+//     "max_ns": 500}}`.
+//
+// Like `expvar.Publish()`, it panics when `name` is already in use.
+func (s *SyncTree) PublishMetrics(name string) *Metrics {
+	s.mu.Lock()
+	m := s.t.EnableMetrics()
+	s.mu.Unlock()
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		s.mu.RLock()
+		shape := s.t.shapeStats()
+		size := shape.Nodes - len(s.t.tombstones)
+		s.mu.RUnlock()
+		return map[string]interface{}{
+			"size":   size,
+			"height": shape.Height,
+			"ops":    m.Ops(),
+		}
+	}))
+	return m
+}
@@ -0,0 +1,51 @@
+package btree
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	b := newIntTree(2, 1, 3)
+	m := b.EnableMetrics()
+	if b.EnableMetrics() != m {
+		t.Errorf("EnableMetrics() again returned other Metrics")
+	}
+	b.Upsert(&Node{Payload: 4})
+	b.Upsert(&Node{Payload: 5})
+	b.Find(&Node{Payload: 1})
+	ops := m.Ops()
+	if ops["Upsert"].Count != 2 || ops["Find"].Count != 1 || len(ops) != 2 {
+		t.Errorf("Ops() = %v, want 2 upserts and 1 find", ops)
+	}
+	if up := ops["Upsert"]; up.Max <= 0 || up.Total < up.Max {
+		t.Errorf("Ops()[Upsert] = %+v, want a positive Max and Total >= Max", up)
+	}
+}
+
+// publishRuns counts the runs of `TestPublishMetrics()`.
+var publishRuns int
+
+func TestPublishMetrics(t *testing.T) {
+	b := newIntTree(2, 1, 3, 4)
+	b.LazyDelete = true
+	b.Delete(&Node{Payload: 4})
+	s := Synchronized(b)
+	// expvar names can't be published twice, e.g. with -count.
+	publishRuns++
+	name := fmt.Sprintf("btree_test_tree_%v", publishRuns)
+	s.PublishMetrics(name)
+	s.Upsert(&Node{Payload: 7})
+	var got struct {
+		Size, Height int
+		Ops          map[string]OpMetrics
+	}
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Size != 4 || got.Height != 4 || got.Ops["Upsert"].Count != 1 {
+		t.Errorf("published %+v, want size 4, height 4 and 1 upsert", got)
+	}
+}
@@ -0,0 +1,174 @@
+package btree
+
+import "sync"
+
+// MVCCTree is a binary tree with multi-version concurrency control. Each mutation creates a new
+// version of the tree by copying the nodes on the path to the change, like `COWTree` does.
+// `Snapshot()` returns a `View` of the current version, which stays stable while writers
+// continue, so that long-running scans see a consistent state.
+//
+// The tree keeps track of the versions that views refer to. Nodes that only belong to versions
+// that no open view refers to are reclaimed: their sub-node links are cleared, so that they don't
+// keep other old nodes alive. Therefore, views must be closed when done, and nodes that are
+// returned by mutations or views must not be examined for their `Left` or `Right` afterwards.
+type MVCCTree struct {
+	mu      sync.Mutex
+	root    *Node
+	less    LessFunc
+	version uint64
+	views   map[uint64]int // number of open views per version
+	retired []retiredNodes // in order of version
+}
+
+// retiredNodes are nodes that belong to versions up to, but not including, `version`.
+type retiredNodes struct {
+	version uint64
+	nodes   []*Node
+}
+
+// View is a read-only view of an `MVCCTree` at one version.
+type View struct {
+	t       *MVCCTree
+	tree    *BTree
+	version uint64
+	closed  bool
+}
+
+// NewMVCC returns an empty `MVCCTree` that orders its nodes using `less`.
+func NewMVCC(less LessFunc) *MVCCTree {
+	return &MVCCTree{less: less, views: map[uint64]int{}}
+}
+
+// Version returns the current version of the tree. Each successful mutation increments it.
+func (t *MVCCTree) Version() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.version
+}
+
+// Retained returns the number of old nodes that are kept for open views.
+func (t *MVCCTree) Retained() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, r := range t.retired {
+		n += len(r.nodes)
+	}
+	return n
+}
+
+// Snapshot returns a view of the current version. It must be closed using `View.Close()`.
+func (t *MVCCTree) Snapshot() *View {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.views[t.version]++
+	return &View{t: t, tree: &BTree{Root: t.root, Less: t.less}, version: t.version}
+}
+
+// Find looks up a node in the current version, see `BTree.Find()`.
+func (t *MVCCTree) Find(n *Node) (intree *Node, found bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return (&BTree{Root: t.root, Less: t.less}).Find(n)
+}
+
+// Upsert adds `n` to the tree, unless an equal node is present. The return values are as for
+// `BTree.Upsert()`. The node `n` becomes part of the tree and must not be changed afterwards.
+func (t *MVCCTree) Upsert(n *Node) (intree *Node, inserted bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n.Left, n.Right = nil, nil
+	var retired []*Node
+	top, intree, inserted := pathCopier{less: t.less, retired: &retired}.upsert(t.root, n, false)
+	if inserted {
+		t.publish(top, retired)
+	}
+	return intree, inserted
+}
+
+// Replace adds `n` to the tree, or when an equal node is present, puts `n` in its place. The
+// return values are as for `COWTree.Replace()`.
+func (t *MVCCTree) Replace(n *Node) (old *Node, replaced bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n.Left, n.Right = nil, nil
+	var retired []*Node
+	top, old, inserted := pathCopier{less: t.less, retired: &retired}.upsert(t.root, n, true)
+	t.publish(top, retired)
+	if inserted {
+		return nil, false
+	}
+	return old, true
+}
+
+// Delete removes the node that is equal to `n`. The return values are as for `BTree.Delete()`.
+func (t *MVCCTree) Delete(n *Node) (removed *Node, deleted bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var retired []*Node
+	top, removed := pathCopier{less: t.less, retired: &retired}.delete(t.root, n)
+	if removed == nil {
+		return nil, false
+	}
+	t.publish(top, retired)
+	return removed, true
+}
+
+// publish makes `top` the root of a new version. The nodes in `retired` belong to the older
+// versions only.
+func (t *MVCCTree) publish(top *Node, retired []*Node) {
+	t.root = top
+	t.version++
+	t.retired = append(t.retired, retiredNodes{version: t.version, nodes: retired})
+	t.reclaim()
+}
+
+// reclaim releases the retired nodes of versions that no view refers to.
+func (t *MVCCTree) reclaim() {
+	oldest := t.version
+	for v := range t.views {
+		if v < oldest {
+			oldest = v
+		}
+	}
+	i := 0
+	for ; i < len(t.retired) && t.retired[i].version <= oldest; i++ {
+		for _, n := range t.retired[i].nodes {
+			n.Left, n.Right = nil, nil
+		}
+		t.retired[i] = retiredNodes{}
+	}
+	t.retired = t.retired[i:]
+}
+
+// Tree returns the version of the view as a `BTree`, which can be examined using all read-only
+// methods (`Find()`, `DepthFirstInOrder()`, `AscendRange()` and so on) without any locking. It
+// must not be modified, nor used after `Close()`.
+func (v *View) Tree() *BTree {
+	return v.tree
+}
+
+// Version returns the version of the tree that the view refers to.
+func (v *View) Version() uint64 {
+	return v.version
+}
+
+// Find looks up a node in the view, see `BTree.Find()`.
+func (v *View) Find(n *Node) (intree *Node, found bool) {
+	return v.tree.Find(n)
+}
+
+// Close releases the view, so that the nodes of its version can be reclaimed. Further calls
+// have no effect.
+func (v *View) Close() {
+	v.t.mu.Lock()
+	defer v.t.mu.Unlock()
+	if v.closed {
+		return
+	}
+	v.closed = true
+	if v.t.views[v.version]--; v.t.views[v.version] == 0 {
+		delete(v.t.views, v.version)
+	}
+	v.t.reclaim()
+}
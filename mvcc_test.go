@@ -0,0 +1,80 @@
+package btree
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestMVCCTree(t *testing.T) {
+	m := NewMVCC(intLess)
+	for _, v := range []int{5, 3, 8, 1, 4} {
+		m.Upsert(&Node{Payload: v})
+	}
+	if m.Retained() != 0 {
+		t.Errorf("Retained() without views = %v, want 0", m.Retained())
+	}
+	view := m.Snapshot()
+	if view.Version() != 5 {
+		t.Errorf("Version() = %v, want 5", view.Version())
+	}
+
+	m.Delete(&Node{Payload: 5})
+	m.Upsert(&Node{Payload: 7})
+	m.Replace(&Node{Payload: 1})
+	if _, deleted := m.Delete(&Node{Payload: 42}); deleted {
+		t.Errorf("Delete(42) = deleted")
+	}
+	if m.Version() != 8 {
+		t.Errorf("Version() = %v, want 8", m.Version())
+	}
+
+	if got, want := inOrderInts(view.Tree()), []int{1, 3, 4, 5, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("view = %v, want %v", got, want)
+	}
+	if _, found := view.Find(&Node{Payload: 7}); found {
+		t.Errorf("view has 7")
+	}
+	if got, want := inOrderInts(m.Snapshot().Tree()), []int{1, 3, 4, 7, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("current = %v, want %v", got, want)
+	}
+	if m.Retained() == 0 {
+		t.Errorf("Retained() with open view = 0")
+	}
+	view.Close()
+	view.Close()
+	if m.Retained() != 0 {
+		t.Errorf("Retained() after Close() = %v, want 0", m.Retained())
+	}
+}
+
+func TestMVCCTreeConcurrent(t *testing.T) {
+	m := NewMVCC(intLess)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 2000; i++ {
+			m.Upsert(&Node{Payload: i % 200})
+			m.Delete(&Node{Payload: (i + 100) % 200})
+		}
+	}()
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				view := m.Snapshot()
+				first := inOrderInts(view.Tree())
+				if second := inOrderInts(view.Tree()); !reflect.DeepEqual(first, second) {
+					t.Errorf("view %v changed from %v to %v", view.Version(), first, second)
+				}
+				view.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	if m.Retained() != 0 {
+		t.Errorf("Retained() after all views closed = %v, want 0", m.Retained())
+	}
+}
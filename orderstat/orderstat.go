@@ -0,0 +1,212 @@
+// Package orderstat implements an order-statistics tree: a binary tree in which each node also
+// records the size of its subtree, so that finding the k-th smallest node (`Select()`), the
+// position of a node (`Rank()`) and the number of nodes in a range (`RangeCount()`) take
+// O(log n), instead of a walk of all preceding nodes. The tree is a `btree.Augmented`, so it stays
+// balanced as nodes are added and removed.
+//
+// The nodes that are added are the caller's `*btree.Node`s, ordered by the caller's
+// `btree.LessFunc`. The tree keeps them in a `btree.Augmented` of its own, so their `Left` and
+// `Right` pointers are not used.
+//
+// The subtree sizes also allow drawing random nodes in O(log n): `RandomNode()` draws uniformly,
+// and `RandomWeighted()` draws in proportion to the weights of a `WeightFunc`.
+package orderstat
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/KarelKubat/btree"
+)
+
+// WeightFunc returns the weight of a node for `RandomWeighted()`, e.g. a field of its payload.
+// Weights must not be negative, and must not change while the node is in the tree.
+type WeightFunc func(n *btree.Node) float64
+
+// item is the payload of the nodes of the underlying tree: a caller's node, and the number of
+// nodes and the sum of the weights in the subtree.
+type item struct {
+	n      *btree.Node
+	size   int
+	weight float64
+}
+
+// Tree is an order-statistics tree. It is not safe for concurrent use.
+type Tree struct {
+	less   btree.LessFunc
+	weight WeightFunc
+	a      *btree.Augmented
+}
+
+// New returns an empty `Tree` that orders its nodes using `less`.
+func New(less btree.LessFunc) *Tree {
+	return NewWeighted(less, nil)
+}
+
+// NewWeighted returns an empty `Tree` that orders its nodes using `less`, and that weighs them
+// using `weight` for `RandomWeighted()`. When `weight` is `nil`, all nodes weigh 1.
+func NewWeighted(less btree.LessFunc, weight WeightFunc) *Tree {
+	t := &Tree{less: less, weight: weight}
+	t.a = btree.NewAugmented(func(a, b *btree.Node) bool {
+		return less(a.Payload.(*item).n, b.Payload.(*item).n)
+	}, t.fix)
+	return t
+}
+
+func itemOf(n *btree.Node) *item {
+	return n.Payload.(*item)
+}
+
+func size(n *btree.Node) int {
+	if n == nil {
+		return 0
+	}
+	return itemOf(n).size
+}
+
+func weight(n *btree.Node) float64 {
+	if n == nil {
+		return 0
+	}
+	return itemOf(n).weight
+}
+
+// weightOf returns the weight of the caller's node `n`.
+func (t *Tree) weightOf(n *btree.Node) float64 {
+	if t.weight == nil {
+		return 1
+	}
+	return t.weight(n)
+}
+
+// fix recomputes the size and weight of the subtree under `n` from those of its sub-nodes.
+func (t *Tree) fix(n *btree.Node) {
+	it := itemOf(n)
+	it.size = 1 + size(n.Left) + size(n.Right)
+	it.weight = t.weightOf(it.n) + weight(n.Left) + weight(n.Right)
+}
+
+// Len returns the number of nodes.
+func (t *Tree) Len() int {
+	return size(t.a.Root())
+}
+
+// key returns a node of the underlying tree to look up the caller's node `n` with.
+func key(n *btree.Node) *btree.Node {
+	return &btree.Node{Payload: &item{n: n}}
+}
+
+// Upsert adds `n`, unless an equal node is present, like `btree.BTree.Upsert()`. The return value
+// `intree` is the node in the tree, and `inserted` is `true` when `n` was added.
+func (t *Tree) Upsert(n *btree.Node) (intree *btree.Node, inserted bool) {
+	at, inserted := t.a.Upsert(key(n))
+	return itemOf(at).n, inserted
+}
+
+// Find looks up a node, like `btree.BTree.Find()`.
+func (t *Tree) Find(n *btree.Node) (intree *btree.Node, found bool) {
+	if at, found := t.a.Find(key(n)); found {
+		return itemOf(at).n, true
+	}
+	return nil, false
+}
+
+// Delete removes a node, like `btree.BTree.Delete()`.
+func (t *Tree) Delete(n *btree.Node) (removed *btree.Node, deleted bool) {
+	if removed, deleted = t.a.Delete(key(n)); !deleted {
+		return nil, false
+	}
+	return itemOf(removed).n, true
+}
+
+// Select returns the node at position `k` in order, counting from 0, or `nil` when `k` is out of
+// range.
+func (t *Tree) Select(k int) *btree.Node {
+	for at := t.a.Root(); at != nil; {
+		switch left := size(at.Left); {
+		case k < left:
+			at = at.Left
+		case k > left:
+			k -= left + 1
+			at = at.Right
+		default:
+			return itemOf(at).n
+		}
+	}
+	return nil
+}
+
+// Rank returns the number of nodes that are smaller than `n`, which is the position of `n` when
+// the tree holds it. `n` only needs to be filled in as far as the `LessFunc` requires.
+func (t *Tree) Rank(n *btree.Node) int {
+	rank := 0
+	for at := t.a.Root(); at != nil; {
+		if it := itemOf(at); t.less(it.n, n) {
+			rank += size(at.Left) + 1
+			at = at.Right
+		} else {
+			at = at.Left
+		}
+	}
+	return rank
+}
+
+// RangeCount returns the number of nodes `n` with `from <= n < to`. When `from` is `nil`, the
+// range starts at the smallest node; when `to` is `nil`, it runs up to and including the
+// largest node. These are the bounds of `btree.BTree.AscendRange()`.
+func (t *Tree) RangeCount(from, to *btree.Node) int {
+	hi := t.Len()
+	if to != nil {
+		hi = t.Rank(to)
+	}
+	lo := 0
+	if from != nil {
+		lo = t.Rank(from)
+	}
+	return max(hi-lo, 0)
+}
+
+// RandomNode returns a node drawn uniformly at random using `rng`, or `nil` when the tree is
+// empty.
+func (t *Tree) RandomNode(rng *rand.Rand) *btree.Node {
+	if t.Len() == 0 {
+		return nil
+	}
+	return t.Select(rng.Intn(t.Len()))
+}
+
+// RandomWeighted returns a node drawn at random using `rng`, with a probability in proportion to
+// its weight, see `NewWeighted()`. It returns `nil` when the tree is empty or weighs nothing.
+func (t *Tree) RandomWeighted(rng *rand.Rand) *btree.Node {
+	if weight(t.a.Root()) <= 0 {
+		return nil
+	}
+	r := rng.Float64() * weight(t.a.Root())
+	for at := t.a.Root(); ; {
+		left, own, right := weight(at.Left), t.weightOf(itemOf(at).n), weight(at.Right)
+		switch {
+		case r < left:
+			at = at.Left
+		case r < left+own || (own > 0 && right <= 0):
+			return itemOf(at).n
+		case right > 0:
+			r -= left + own
+			at = at.Right
+		default:
+			// Rounding left `r` beyond the last node with a weight, which is on the left.
+			r = math.Nextafter(left, 0)
+			at = at.Left
+		}
+	}
+}
+
+// DepthFirstInOrder calls `walk` for each node, in order.
+func (t *Tree) DepthFirstInOrder(walk btree.WalkFunc) {
+	t.a.Tree().DepthFirstInOrder(func(n *btree.Node) { walk(itemOf(n).n) })
+}
+
+// Rebalance rebalances the underlying tree, see `btree.Augmented.Rebalance()`. The tree stays
+// balanced without it.
+func (t *Tree) Rebalance() {
+	t.a.Rebalance()
+}
@@ -0,0 +1,175 @@
+package orderstat
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/KarelKubat/btree"
+)
+
+func intLess(a, b *btree.Node) bool {
+	return a.Payload.(int) < b.Payload.(int)
+}
+
+func node(v int) *btree.Node {
+	return &btree.Node{Payload: v}
+}
+
+func TestSelectRank(t *testing.T) {
+	tr := New(intLess)
+	for _, v := range []int{50, 20, 80, 10, 30, 70, 90} {
+		tr.Upsert(node(v))
+	}
+	want := []int{10, 20, 30, 50, 70, 80, 90}
+	for k, v := range want {
+		if got := tr.Select(k); got == nil || got.Payload != v {
+			t.Errorf("Select(%v) = %v, want %v", k, got, v)
+		}
+		if got := tr.Rank(node(v)); got != k {
+			t.Errorf("Rank(%v) = %v, want %v", v, got, k)
+		}
+	}
+	if got := tr.Select(7); got != nil {
+		t.Errorf("Select(7) = %v, want nil", got)
+	}
+	if got := tr.Rank(node(55)); got != 4 {
+		t.Errorf("Rank(55) = %v, want 4", got)
+	}
+	for _, test := range []struct {
+		from, to *btree.Node
+		want     int
+	}{
+		{from: nil, to: nil, want: 7},
+		{from: node(20), to: node(80), want: 4},
+		{from: node(25), to: nil, want: 5},
+		{from: nil, to: node(10), want: 0},
+		{from: node(80), to: node(20), want: 0},
+	} {
+		if got := tr.RangeCount(test.from, test.to); got != test.want {
+			t.Errorf("RangeCount(%v, %v) = %v, want %v", test.from, test.to, got, test.want)
+		}
+	}
+}
+
+func TestAgainstSortedSlice(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tr := New(intLess)
+	present := map[int]bool{}
+	for i := 0; i < 5000; i++ {
+		v := r.Intn(500)
+		switch op := r.Intn(10); {
+		case op < 5:
+			if _, inserted := tr.Upsert(node(v)); inserted == present[v] {
+				t.Fatalf("op %v: Upsert(%v) = %v", i, v, inserted)
+			}
+			present[v] = true
+		case op < 8:
+			if _, deleted := tr.Delete(node(v)); deleted != present[v] {
+				t.Fatalf("op %v: Delete(%v) = %v", i, v, deleted)
+			}
+			delete(present, v)
+		case op < 9:
+			tr.Rebalance()
+		default:
+			var sorted []int
+			for p := range present {
+				sorted = append(sorted, p)
+			}
+			sort.Ints(sorted)
+			if tr.Len() != len(sorted) {
+				t.Fatalf("op %v: Len() = %v, want %v", i, tr.Len(), len(sorted))
+			}
+			for k, p := range sorted {
+				if got := tr.Select(k).Payload; got != p {
+					t.Fatalf("op %v: Select(%v) = %v, want %v", i, k, got, p)
+				}
+			}
+			if got, want := tr.Rank(node(v)), sort.SearchInts(sorted, v); got != want {
+				t.Fatalf("op %v: Rank(%v) = %v, want %v", i, v, got, want)
+			}
+			var walked []int
+			tr.DepthFirstInOrder(func(n *btree.Node) { walked = append(walked, n.Payload.(int)) })
+			if len(sorted) > 0 && !reflect.DeepEqual(walked, sorted) {
+				t.Fatalf("op %v: DepthFirstInOrder() = %v, want %v", i, walked, sorted)
+			}
+		}
+	}
+}
+
+func TestRandomNode(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tr := New(intLess)
+	if got := tr.RandomNode(rng); got != nil {
+		t.Errorf("RandomNode() of an empty tree = %v, want nil", got)
+	}
+	for _, v := range []int{3, 1, 4, 0, 2} {
+		tr.Upsert(node(v))
+	}
+	const draws = 50000
+	counts := make([]int, 5)
+	for i := 0; i < draws; i++ {
+		counts[tr.RandomNode(rng).Payload.(int)]++
+	}
+	for v, c := range counts {
+		if want := draws / 5; c < want*9/10 || c > want*11/10 {
+			t.Errorf("RandomNode() drew %v %v times, want about %v", v, c, want)
+		}
+	}
+}
+
+func TestRandomWeighted(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tr := NewWeighted(intLess, func(n *btree.Node) float64 { return float64(n.Payload.(int)) })
+	if got := tr.RandomWeighted(rng); got != nil {
+		t.Errorf("RandomWeighted() of an empty tree = %v, want nil", got)
+	}
+	tr.Upsert(node(0))
+	if got := tr.RandomWeighted(rng); got != nil {
+		t.Errorf("RandomWeighted() of a tree without weight = %v, want nil", got)
+	}
+	for _, v := range []int{3, 1, 4, 2, 9, 5} {
+		tr.Upsert(node(v))
+	}
+	// Deleting and rebalancing must keep the weights of the subtrees.
+	tr.Delete(node(9))
+	tr.Delete(node(5))
+	tr.Rebalance()
+
+	const draws = 50000
+	counts := make([]int, 5)
+	for i := 0; i < draws; i++ {
+		counts[tr.RandomWeighted(rng).Payload.(int)]++
+	}
+	if counts[0] != 0 {
+		t.Errorf("RandomWeighted() drew 0, which weighs nothing, %v times", counts[0])
+	}
+	for v := 1; v < 5; v++ {
+		if want := draws * v / 10; counts[v] < want*9/10 || counts[v] > want*11/10 {
+			t.Errorf("RandomWeighted() drew %v %v times, want about %v", v, counts[v], want)
+		}
+	}
+}
+
+func TestBalanced(t *testing.T) {
+	tr := New(intLess)
+	// Ascending insertions would make an unbalanced tree a chain.
+	for v := 0; v < 4096; v++ {
+		tr.Upsert(node(v))
+	}
+	for v := 0; v < 4096; v += 2 {
+		tr.Delete(node(v))
+	}
+	if h := tr.a.Tree().ShapeStats().Height; h > 2*12+2 {
+		t.Errorf("height = %v for 2048 nodes, want at most %v", h, 2*12+2)
+	}
+	for _, k := range []int{0, 1000, 2047} {
+		if got := tr.Select(k).Payload.(int); got != 2*k+1 {
+			t.Errorf("Select(%v) = %v, want %v", k, got, 2*k+1)
+		}
+		if got := tr.Rank(node(2*k + 1)); got != k {
+			t.Errorf("Rank(%v) = %v, want %v", 2*k+1, got, k)
+		}
+	}
+}
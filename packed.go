@@ -0,0 +1,227 @@
+package btree
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// Layout is the order in which `PackWith()` stores the nodes.
+type Layout int
+
+const (
+	// LayoutBFS stores the nodes level by level (the Eytzinger layout): the sub-nodes of the node
+	// at index `i` are at `2i+1` and `2i+2`, so no links need to be stored.
+	LayoutBFS Layout = iota
+	// LayoutVEB stores the nodes in the recursive van Emde Boas order: the top half of the levels
+	// first, followed by each of the subtrees below them, each again in van Emde Boas order. A
+	// lookup then touches far fewer cache lines (or pages) on large trees, at the cost of storing
+	// the indices of the sub-nodes.
+	LayoutVEB
+)
+
+// Packed is a read-only form of a tree for pure-lookup workloads. It keeps the payloads in one
+// flat slice, laid out as a balanced tree, and navigates by index. Without `Node`s and their
+// pointers, a node takes less memory, the garbage collector has a single object to track, and a
+// lookup touches contiguous memory. A `Packed` is safe for concurrent use.
+type Packed struct {
+	less     LessFunc
+	payloads []interface{} // for `LayoutBFS`
+	slots    []vebSlot     // for `LayoutVEB`
+	probes   sync.Pool     // of *Node, to pass payloads to `less`
+}
+
+// vebSlot is a node in `LayoutVEB`: its payload, and the indices of its sub-nodes or -1.
+type vebSlot struct {
+	payload     interface{}
+	left, right int32
+}
+
+// Pack returns the payloads of the tree as a `Packed` in `LayoutBFS`. The tree itself is
+// unchanged, and may be discarded; its payloads are shared, so they must not be changed
+// afterwards.
+func (b *BTree) Pack() *Packed {
+	return b.PackWith(LayoutBFS)
+}
+
+// PackWith is `Pack()` using the given layout.
+func (b *BTree) PackWith(layout Layout) *Packed {
+	defer b.beginRead("PackWith")()
+	sorted := []interface{}{}
+	var it inorderIter
+	for it.init(b); ; {
+		n := it.next()
+		if n == nil {
+			break
+		}
+		sorted = append(sorted, n.Payload)
+	}
+	p := &Packed{less: b.Less, payloads: make([]interface{}, len(sorted))}
+	p.probes.New = func() interface{} { return &Node{} }
+	p.fill(0, sorted)
+	if layout == LayoutVEB {
+		p.slots = vebSlots(p.payloads)
+		p.payloads = nil
+	}
+	return p
+}
+
+// fill stores `sorted` in order in the subtree at index `i`, and returns the unstored rest.
+func (p *Packed) fill(i int, sorted []interface{}) []interface{} {
+	if i >= len(p.payloads) {
+		return sorted
+	}
+	sorted = p.fill(2*i+1, sorted)
+	p.payloads[i], sorted = sorted[0], sorted[1:]
+	return p.fill(2*i+2, sorted)
+}
+
+// vebSlots reorders payloads that are in `LayoutBFS` into `LayoutVEB`.
+func vebSlots(bfs []interface{}) []vebSlot {
+	order := make([]int, 0, len(bfs))
+	var emit func(i, height int)
+	emit = func(i, height int) {
+		if i >= len(bfs) {
+			return
+		}
+		if height == 1 {
+			order = append(order, i)
+			return
+		}
+		top := height / 2
+		emit(i, top)
+		// The roots of the bottom subtrees are the descendants of `i`, `top` levels down.
+		first := (i+1)<<top - 1
+		for j := first; j < first+1<<top; j++ {
+			emit(j, height-top)
+		}
+	}
+	emit(0, bits.Len(uint(len(bfs))))
+
+	pos := make([]int32, len(bfs))
+	for slot, i := range order {
+		pos[i] = int32(slot)
+	}
+	link := func(i int) int32 {
+		if i >= len(bfs) {
+			return -1
+		}
+		return pos[i]
+	}
+	slots := make([]vebSlot, len(bfs))
+	for slot, i := range order {
+		slots[slot] = vebSlot{payload: bfs[i], left: link(2*i + 1), right: link(2*i + 2)}
+	}
+	return slots
+}
+
+// Len returns the number of nodes.
+func (p *Packed) Len() int {
+	if p.slots != nil {
+		return len(p.slots)
+	}
+	return len(p.payloads)
+}
+
+// Root returns the index of the top node, or -1 when there are no nodes.
+func (p *Packed) Root() int {
+	if p.Len() == 0 {
+		return -1
+	}
+	return 0
+}
+
+// Left returns the index of the left sub-node of the node at index `i`, or -1 when there is none.
+func (p *Packed) Left(i int) int {
+	if p.slots != nil {
+		return int(p.slots[i].left)
+	}
+	return p.index(2*i + 1)
+}
+
+// Right returns the index of the right sub-node of the node at index `i`, or -1 when there is
+// none.
+func (p *Packed) Right(i int) int {
+	if p.slots != nil {
+		return int(p.slots[i].right)
+	}
+	return p.index(2*i + 2)
+}
+
+func (p *Packed) index(i int) int {
+	if i >= len(p.payloads) {
+		return -1
+	}
+	return i
+}
+
+// Payload returns the payload of the node at index `i`.
+func (p *Packed) Payload(i int) interface{} {
+	if p.slots != nil {
+		return p.slots[i].payload
+	}
+	return p.payloads[i]
+}
+
+// Find looks up a node, like `BTree.Find()`, and returns the index of the match. The argument `n`
+// only needs to be filled in as far as the `LessFunc` requires.
+func (p *Packed) Find(n *Node) (index int, found bool) {
+	probe := p.probes.Get().(*Node)
+	defer p.probes.Put(probe)
+	if p.slots != nil {
+		for i := int32(0); i >= 0 && int(i) < len(p.slots); {
+			s := &p.slots[i]
+			probe.Payload = s.payload
+			switch {
+			case p.less(n, probe):
+				i = s.left
+			case p.less(probe, n):
+				i = s.right
+			default:
+				probe.Payload = nil
+				return int(i), true
+			}
+		}
+		probe.Payload = nil
+		return -1, false
+	}
+	for i := p.Root(); i >= 0; {
+		probe.Payload = p.Payload(i)
+		switch {
+		case p.less(n, probe):
+			i = p.Left(i)
+		case p.less(probe, n):
+			i = p.Right(i)
+		default:
+			probe.Payload = nil
+			return i, true
+		}
+	}
+	probe.Payload = nil
+	return -1, false
+}
+
+// DepthFirstInOrder calls `walk` for each node, in order. The `Node` that is passed is reused
+// between calls; only its `Payload` is filled in.
+func (p *Packed) DepthFirstInOrder(walk WalkFunc) {
+	probe := &Node{}
+	p.walk(p.Root(), probe, walk)
+}
+
+func (p *Packed) walk(i int, probe *Node, walk WalkFunc) {
+	if i < 0 {
+		return
+	}
+	p.walk(p.Left(i), probe, walk)
+	probe.Payload = p.Payload(i)
+	walk(probe)
+	p.walk(p.Right(i), probe, walk)
+}
+
+// Unpack returns the nodes as a balanced `BTree` of fresh nodes, e.g. to modify them.
+func (p *Packed) Unpack() *BTree {
+	nodes := make([]*Node, 0, p.Len())
+	p.DepthFirstInOrder(func(n *Node) {
+		nodes = append(nodes, &Node{Payload: n.Payload})
+	})
+	return &BTree{Root: buildBalanced(nodes), Less: p.less}
+}
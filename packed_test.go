@@ -0,0 +1,129 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestPack(t *testing.T) {
+	for _, layout := range []Layout{LayoutBFS, LayoutVEB} {
+		for _, size := range []int{0, 1, 2, 3, 7, 10, 100, 1000} {
+			testPack(t, layout, size)
+		}
+	}
+}
+
+func testPack(t *testing.T, layout Layout, size int) {
+	t.Helper()
+	vals := rand.New(rand.NewSource(int64(size))).Perm(size)
+	b := newIntTree(vals...)
+	p := b.PackWith(layout)
+	if p.Len() != size {
+		t.Errorf("layout %v, size %v: Len() = %v", layout, size, p.Len())
+	}
+	var got []int
+	p.DepthFirstInOrder(func(n *Node) { got = append(got, n.Payload.(int)) })
+	if want := inOrderInts(b); len(want) > 0 && !reflect.DeepEqual(got, want) {
+		t.Errorf("layout %v, size %v: DepthFirstInOrder() = %v, want %v", layout, size, got, want)
+	}
+	for v := -1; v <= size; v++ {
+		i, found := p.Find(&Node{Payload: v})
+		if wantFound := v >= 0 && v < size; found != wantFound {
+			t.Errorf("layout %v, size %v: Find(%v) = %v, want %v", layout, size, v, found, wantFound)
+			continue
+		}
+		if found && p.Payload(i) != v {
+			t.Errorf("layout %v, size %v: Payload(Find(%v)) = %v", layout, size, v, p.Payload(i))
+		}
+	}
+	if !p.Unpack().Equal(b, intEqual) {
+		t.Errorf("layout %v, size %v: Unpack() differs from the packed tree", layout, size)
+	}
+}
+
+func TestPackNavigation(t *testing.T) {
+	for _, layout := range []Layout{LayoutBFS, LayoutVEB} {
+		testPackNavigation(t, layout)
+	}
+	if got := New(intLess).Pack().Root(); got != -1 {
+		t.Errorf("Root() of an empty tree = %v, want -1", got)
+	}
+}
+
+func testPackNavigation(t *testing.T, layout Layout) {
+	t.Helper()
+	p := newIntTree(1, 2, 3, 4, 5).PackWith(layout)
+	// Five nodes: 4 on top, 2 and 5 below it, 1 and 3 below 2.
+	var shape func(i int) interface{}
+	shape = func(i int) interface{} {
+		if i < 0 {
+			return nil
+		}
+		return []interface{}{p.Payload(i), shape(p.Left(i)), shape(p.Right(i))}
+	}
+	want := []interface{}{4, []interface{}{2, []interface{}{1, nil, nil}, []interface{}{3, nil, nil}},
+		[]interface{}{5, nil, nil}}
+	if got := shape(p.Root()); !reflect.DeepEqual(got, want) {
+		t.Errorf("layout %v: shape = %v, want %v", layout, got, want)
+	}
+}
+
+func TestPackVEBOrder(t *testing.T) {
+	vals := make([]int, 15)
+	for i := range vals {
+		vals[i] = i
+	}
+	bfs := newIntTree(vals...).Pack()
+	veb := newIntTree(vals...).PackWith(LayoutVEB)
+	// Height 4: the top two levels, then the four subtrees of two levels below them.
+	for slot, i := range []int{0, 1, 2, 3, 7, 8, 4, 9, 10, 5, 11, 12, 6, 13, 14} {
+		if got, want := veb.Payload(slot), bfs.Payload(i); got != want {
+			t.Errorf("slot %v holds %v, want %v", slot, got, want)
+		}
+	}
+}
+
+func TestPackSkipsTombstones(t *testing.T) {
+	b := newIntTree(1, 2, 3)
+	b.LazyDelete = true
+	b.Delete(&Node{Payload: 2})
+	if _, found := b.Pack().Find(&Node{Payload: 2}); found {
+		t.Errorf("Find() of a deleted node = true, want false")
+	}
+}
+
+func TestPackedFindDoesNotAllocate(t *testing.T) {
+	p := BuildParallel(intLess, intNodes(rand.New(rand.NewSource(1)).Perm(1000)...)).Pack()
+	n := &Node{Payload: 500}
+	if allocs := testing.AllocsPerRun(100, func() { p.Find(n) }); allocs != 0 {
+		t.Errorf("Find() allocates %v times, want 0", allocs)
+	}
+}
+
+func benchmarkFind(b *testing.B, find func(n *Node) bool) {
+	r := rand.New(rand.NewSource(1))
+	probes := make([]*Node, 1024)
+	for i := range probes {
+		probes[i] = &Node{Payload: r.Intn(1 << 20)}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		find(probes[i%len(probes)])
+	}
+}
+
+func BenchmarkFindPointers(b *testing.B) {
+	t := BuildParallel(intLess, intNodes(rand.New(rand.NewSource(1)).Perm(1<<20)...))
+	benchmarkFind(b, func(n *Node) bool { _, found := t.Find(n); return found })
+}
+
+func BenchmarkFindPacked(b *testing.B) {
+	p := BuildParallel(intLess, intNodes(rand.New(rand.NewSource(1)).Perm(1<<20)...)).Pack()
+	benchmarkFind(b, func(n *Node) bool { _, found := p.Find(n); return found })
+}
+
+func BenchmarkFindPackedVEB(b *testing.B) {
+	p := BuildParallel(intLess, intNodes(rand.New(rand.NewSource(1)).Perm(1<<20)...)).PackWith(LayoutVEB)
+	benchmarkFind(b, func(n *Node) bool { _, found := p.Find(n); return found })
+}
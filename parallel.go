@@ -0,0 +1,75 @@
+package btree
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// parallelCutoff is the number of nodes below which parallel work isn't worth the goroutine.
+const parallelCutoff = 4096
+
+// BuildParallel returns a balanced tree that orders its nodes using `less`, holding `nodes` in
+// any order. It is meant for quickly loading large trees: the nodes are sorted in chunks, one per
+// CPU (see `runtime.GOMAXPROCS`), the chunks are merged, and the balanced subtrees are built in
+// parallel and joined. Of nodes that are equal, the first one in `nodes` is kept. The `Left` and
+// `Right` pointers of the nodes are overwritten, and `nodes` itself is reordered.
+//
+// Since the goroutines call `less` concurrently, it must be safe for concurrent use.
+func BuildParallel(less LessFunc, nodes []*Node) *BTree {
+	return buildParallelTree(less, nodes, runtime.GOMAXPROCS(0))
+}
+
+func buildParallelTree(less LessFunc, nodes []*Node, procs int) *BTree {
+	b := New(less)
+	nodes = b.dedup(b.sortParallel(nodes, procs))
+	b.Root = buildParallel(nodes, procs)
+	return b
+}
+
+// sortParallel stably sorts `nodes` using up to `procs` goroutines and returns the result, which
+// may be a different slice.
+func (b *BTree) sortParallel(nodes []*Node, procs int) []*Node {
+	if procs <= 1 || len(nodes) < parallelCutoff {
+		sort.SliceStable(nodes, func(i, j int) bool { return b.Less(nodes[i], nodes[j]) })
+		return nodes
+	}
+	mid := len(nodes) / 2
+	var left []*Node
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		left = b.sortParallel(nodes[:mid], procs/2)
+	}()
+	right := b.sortParallel(nodes[mid:], procs-procs/2)
+	wg.Wait()
+
+	out := make([]*Node, 0, len(nodes))
+	for len(left) > 0 && len(right) > 0 {
+		if b.Less(right[0], left[0]) {
+			out, right = append(out, right[0]), right[1:]
+		} else {
+			out, left = append(out, left[0]), left[1:]
+		}
+	}
+	return append(append(out, left...), right...)
+}
+
+// buildParallel is the parallel version of `buildBalanced`, using up to `procs` goroutines.
+func buildParallel(nodes []*Node, procs int) *Node {
+	if procs <= 1 || len(nodes) < parallelCutoff {
+		return buildBalanced(nodes)
+	}
+	mid := len(nodes) / 2
+	top := nodes[mid]
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		top.Left = buildParallel(nodes[:mid], procs/2)
+	}()
+	top.Right = buildParallel(nodes[mid+1:], procs-procs/2)
+	wg.Wait()
+	return top
+}
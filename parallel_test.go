@@ -0,0 +1,73 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestBuildParallel(t *testing.T) {
+	for _, size := range []int{0, 1, 10, 3 * parallelCutoff, 10 * parallelCutoff} {
+		for _, procs := range []int{1, 3, 8} {
+			testBuildParallel(t, size, procs)
+		}
+	}
+}
+
+func testBuildParallel(t *testing.T, size, procs int) {
+	t.Helper()
+	r := rand.New(rand.NewSource(int64(size)))
+	vals := make([]int, size)
+	for i := range vals {
+		vals[i] = r.Intn(size + 1)
+	}
+	nodes := make([]*Node, size)
+	firsts := map[int]*Node{}
+	for i, v := range vals {
+		nodes[i] = &Node{Payload: v}
+		if _, ok := firsts[v]; !ok {
+			firsts[v] = nodes[i]
+		}
+	}
+
+	b := buildParallelTree(intLess, nodes, procs)
+
+	want := []int{}
+	for v := range firsts {
+		want = append(want, v)
+	}
+	sort.Ints(want)
+	if got := inOrderInts(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("size %v, procs %v: BuildParallel() has %v nodes, want %v", size, procs, len(got), len(want))
+		return
+	}
+	for v, first := range firsts {
+		if intree, _ := b.Find(&Node{Payload: v}); intree != first {
+			t.Errorf("size %v, procs %v: BuildParallel() didn't keep the first node of %v", size, procs, v)
+			break
+		}
+	}
+	if balanced := buildBalanced(intNodes(want...)); !b.StructurallyEqual(&BTree{Root: balanced}, nil) {
+		t.Errorf("size %v, procs %v: BuildParallel() is not balanced", size, procs)
+	}
+}
+
+func BenchmarkBuildParallel(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	vals := r.Perm(1000000)
+	for i := 0; i < b.N; i++ {
+		BuildParallel(intLess, intNodes(vals...))
+	}
+}
+
+func BenchmarkBuildSequential(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	vals := r.Perm(1000000)
+	for i := 0; i < b.N; i++ {
+		t := New(intLess)
+		nodes := intNodes(vals...)
+		sort.SliceStable(nodes, func(i, j int) bool { return intLess(nodes[i], nodes[j]) })
+		t.BulkUpsert(nodes)
+	}
+}
@@ -0,0 +1,24 @@
+package btree
+
+// ApplyPatch changes the tree according to `d`, which is typically the result of `Diff()`. After
+// `a.ApplyPatch(a.Diff(b, eq))`, tree `a` holds the same contents as tree `b`:
+//
+//   - The nodes in `d.Removed` are deleted,
+//   - Fresh nodes are added for `d.Added`,
+//   - The nodes matching `d.Changed` get the payload of the `To` side of the change.
+//
+// The payloads are not copied, so that they are shared between the tree and whatever the diff
+// was computed from.
+func (b *BTree) ApplyPatch(d Diff) {
+	for _, n := range d.Removed {
+		b.Delete(n)
+	}
+	for _, n := range d.Added {
+		b.Upsert(&Node{Payload: n.Payload})
+	}
+	for _, c := range d.Changed {
+		if intree, found := b.Find(c.To); found {
+			intree.Payload = c.To.Payload
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package btree
+
+import "testing"
+
+func TestApplyPatch(t *testing.T) {
+	a := newKVTree("a", "1", "b", "2", "c", "3", "e", "5")
+	b := newKVTree("b", "2", "c", "30", "d", "4", "f", "6")
+
+	a.ApplyPatch(a.Diff(b, kvEqual))
+	if !a.Equal(b, kvEqual) {
+		t.Errorf("ApplyPatch(Diff()) didn't make both trees equal")
+	}
+	if d := a.Diff(b, kvEqual); !d.Empty() {
+		t.Errorf("Diff() after ApplyPatch() = %+v, want empty", d)
+	}
+}
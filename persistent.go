@@ -0,0 +1,69 @@
+package btree
+
+// Persistent is an immutable binary tree. Its mutations don't change the tree; they return a new
+// `Persistent` that shares all unchanged nodes with the old one. Older versions remain valid, which
+// makes it cheap to keep snapshots or an undo history, and a `Persistent` can be shared between
+// goroutines without locking.
+//
+// Since nodes are shared between versions, they must never be changed, and that includes their
+// payloads. To change a payload, use `Replace()` with a fresh payload.
+type Persistent struct {
+	root *Node
+	less LessFunc
+	len  int
+}
+
+// NewPersistent returns an empty `Persistent` tree that orders its nodes using `less`.
+func NewPersistent(less LessFunc) *Persistent {
+	return &Persistent{less: less}
+}
+
+// Len returns the number of nodes in the tree.
+func (p *Persistent) Len() int {
+	return p.len
+}
+
+// Tree returns the tree as a `BTree`, which can be examined using all read-only methods
+// (`Find()`, `DepthFirstInOrder()`, `AscendRange()` and so on). It must not be modified.
+func (p *Persistent) Tree() *BTree {
+	return &BTree{Root: p.root, Less: p.less}
+}
+
+// Find looks up a node, see `BTree.Find()`.
+func (p *Persistent) Find(n *Node) (intree *Node, found bool) {
+	return p.Tree().Find(n)
+}
+
+// Upsert returns a tree that has `n`, unless an equal node is present; then `p` itself is
+// returned. The other return values are as for `BTree.Upsert()`. The node `n` becomes part of
+// the tree and must not be changed afterwards.
+func (p *Persistent) Upsert(n *Node) (next *Persistent, intree *Node, inserted bool) {
+	n.Left, n.Right = nil, nil
+	top, intree, inserted := pathCopier{less: p.less}.upsert(p.root, n, false)
+	if !inserted {
+		return p, intree, false
+	}
+	return &Persistent{root: top, less: p.less, len: p.len + 1}, intree, true
+}
+
+// Replace returns a tree that has `n`, in the place of an equal node if there is one. The return
+// value `old` is the node that was replaced (or `nil`), and `replaced` is `true` when there was
+// such a node.
+func (p *Persistent) Replace(n *Node) (next *Persistent, old *Node, replaced bool) {
+	n.Left, n.Right = nil, nil
+	top, old, inserted := pathCopier{less: p.less}.upsert(p.root, n, true)
+	if inserted {
+		return &Persistent{root: top, less: p.less, len: p.len + 1}, nil, false
+	}
+	return &Persistent{root: top, less: p.less, len: p.len}, old, true
+}
+
+// Delete returns a tree without the node that is equal to `n`; when there is no such node, `p`
+// itself is returned. The other return values are as for `BTree.Delete()`.
+func (p *Persistent) Delete(n *Node) (next *Persistent, removed *Node, deleted bool) {
+	top, removed := pathCopier{less: p.less}.delete(p.root, n)
+	if removed == nil {
+		return p, nil, false
+	}
+	return &Persistent{root: top, less: p.less, len: p.len - 1}, removed, true
+}
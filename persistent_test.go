@@ -0,0 +1,85 @@
+package btree
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestPersistent(t *testing.T) {
+	history := []*Persistent{NewPersistent(intLess)}
+	for _, v := range []int{5, 3, 8, 1, 4} {
+		next, _, inserted := history[len(history)-1].Upsert(&Node{Payload: v})
+		if !inserted {
+			t.Fatalf("Upsert(%v) = not inserted", v)
+		}
+		history = append(history, next)
+	}
+	last := history[len(history)-1]
+	if same, _, inserted := last.Upsert(&Node{Payload: 3}); inserted || same != last {
+		t.Errorf("Upsert(3) again = %v, new tree %v; want not inserted, same tree", inserted, same != last)
+	}
+	deleted, _, ok := last.Delete(&Node{Payload: 5})
+	if !ok {
+		t.Fatalf("Delete(5) = not deleted")
+	}
+	if same, _, ok := deleted.Delete(&Node{Payload: 5}); ok || same != deleted {
+		t.Errorf("Delete(5) again = %v, new tree %v; want not deleted, same tree", ok, same != deleted)
+	}
+
+	for _, test := range []struct {
+		p    *Persistent
+		want []int
+	}{
+		{history[0], []int{}},
+		{history[2], []int{3, 5}},
+		{last, []int{1, 3, 4, 5, 8}},
+		{deleted, []int{1, 3, 4, 8}},
+	} {
+		if got := inOrderInts(test.p.Tree()); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("version = %v, want %v", got, test.want)
+		}
+		if got := test.p.Len(); got != len(test.want) {
+			t.Errorf("Len() = %v, want %v", got, len(test.want))
+		}
+	}
+}
+
+func TestPersistentReplace(t *testing.T) {
+	p, _, _ := NewPersistent(kvLess).Upsert(&Node{Payload: kv{key: "a", val: "1"}})
+	q, old, replaced := p.Replace(&Node{Payload: kv{key: "a", val: "2"}})
+	if !replaced || old.Payload.(kv).val != "1" {
+		t.Errorf("Replace(a) = %v, %v; want replaced a=1", old, replaced)
+	}
+	if got := p.Tree().Root.Payload.(kv).val; got != "1" {
+		t.Errorf("old version has a=%v, want a=1", got)
+	}
+	if got := q.Tree().Root.Payload.(kv).val; got != "2" || q.Len() != 1 {
+		t.Errorf("new version has a=%v and %v nodes, want a=2 and 1 node", got, q.Len())
+	}
+}
+
+func TestPersistentShared(t *testing.T) {
+	p := NewPersistent(intLess)
+	for v := 0; v < 100; v++ {
+		p, _, _ = p.Upsert(&Node{Payload: v})
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			q := p
+			for v := 0; v < 100; v += 4 {
+				q, _, _ = q.Delete(&Node{Payload: v + g})
+			}
+			if q.Len() != 75 {
+				t.Errorf("goroutine %v: Len() = %v, want 75", g, q.Len())
+			}
+		}(g)
+	}
+	wg.Wait()
+	if p.Len() != 100 || len(inOrderInts(p.Tree())) != 100 {
+		t.Errorf("shared version changed")
+	}
+}
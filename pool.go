@@ -0,0 +1,50 @@
+package btree
+
+import "sync"
+
+// NodePool recycles nodes, to reduce the load on the garbage collector when many short-lived
+// nodes are added and removed. It is backed by a `sync.Pool`, so it is safe for concurrent use and
+// may be shared between trees; nodes that are not recycled soon are eventually freed.
+//
+// A pool is used by setting the `Pool` of a tree, and creating the nodes to add using
+// `BTree.NewNode()`. Nodes that are returned to a pool must no longer be used by the caller.
+type NodePool struct {
+	p sync.Pool
+}
+
+// NewNodePool returns an empty `NodePool`.
+func NewNodePool() *NodePool {
+	return &NodePool{p: sync.Pool{New: func() interface{} { return &Node{} }}}
+}
+
+// Get returns a node with the given payload and no sub-nodes, recycled if possible.
+func (p *NodePool) Get(payload interface{}) *Node {
+	n := p.p.Get().(*Node)
+	n.Payload = payload
+	return n
+}
+
+// Put returns `n` to the pool. It must not be in a tree.
+func (p *NodePool) Put(n *Node) {
+	n.Payload = nil
+	p.put(n)
+}
+
+// put returns `n` to the pool; its payload is kept until it is reused.
+func (p *NodePool) put(n *Node) {
+	n.Left, n.Right = nil, nil
+	p.p.Put(n)
+}
+
+// NewNode returns a node with the given payload. It is allocated from the tree's `Arena`, or
+// else taken from its `Pool`, when it has one. A node from a pool that `Upsert()` doesn't insert
+// can be returned using `NodePool.Put()`.
+func (b *BTree) NewNode(payload interface{}) *Node {
+	switch {
+	case b.Arena != nil:
+		return b.Arena.New(payload)
+	case b.Pool != nil:
+		return b.Pool.Get(payload)
+	}
+	return &Node{Payload: payload}
+}
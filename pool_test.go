@@ -0,0 +1,54 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNodePool(t *testing.T) {
+	for _, pool := range []*NodePool{nil, NewNodePool()} {
+		b := New(intLess)
+		b.Pool = pool
+		for _, v := range []int{5, 3, 8, 1, 4} {
+			b.Upsert(b.NewNode(v))
+		}
+		removed, deleted := b.Delete(&Node{Payload: 3})
+		if !deleted || removed.Payload != 3 {
+			t.Errorf("Delete(3) = %v, %v; want 3, true", removed, deleted)
+		}
+		if got, want := inOrderInts(b), []int{1, 4, 5, 8}; !reflect.DeepEqual(got, want) {
+			t.Errorf("after Delete(3) = %v, want %v", got, want)
+		}
+		if n := b.NewNode(6); n.Payload != 6 || n.Left != nil || n.Right != nil {
+			t.Errorf("NewNode(6) = %+v, want a fresh node", n)
+		}
+		b.Clear()
+		if b.Root != nil {
+			t.Errorf("Clear() leaves nodes behind")
+		}
+		b.Upsert(b.NewNode(2))
+		if got, want := inOrderInts(b), []int{2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("after Clear() and Upsert(2) = %v, want %v", got, want)
+		}
+	}
+}
+
+func BenchmarkChurn(b *testing.B) {
+	for _, test := range []struct {
+		desc string
+		pool *NodePool
+	}{
+		{desc: "new", pool: nil},
+		{desc: "pool", pool: NewNodePool()},
+	} {
+		b.Run(test.desc, func(b *testing.B) {
+			t := New(intLess)
+			t.Pool = test.pool
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				t.Upsert(t.NewNode(i % 1000))
+				t.Delete(&Node{Payload: (i + 500) % 1000})
+			}
+		})
+	}
+}
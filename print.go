@@ -0,0 +1,41 @@
+package btree
+
+import (
+	"bufio"
+	"io"
+)
+
+// Print draws the tree sideways on `w`, using `label` to get the text of each node. The root is
+// in the leftmost column, right sub-nodes are drawn above their parent and left sub-nodes below
+// it:
+//
+//	    ┌── 7
+//	┌── 6
+//	│   └── 5
+//	4
+//	└── 2
+//	    └── 1
+func (b *BTree) Print(w io.Writer, label LabelFunc) error {
+	bw := bufio.NewWriter(w)
+	if b.Root != nil {
+		printFrom(bw, b.Root.Right, "", false, label)
+		bw.WriteString(label(b.Root) + "\n")
+		printFrom(bw, b.Root.Left, "", true, label)
+	}
+	return bw.Flush()
+}
+
+// printFrom draws the subtree under `n`, which is the left or right sub-node of its parent. All
+// lines of the subtree start with `prefix`.
+func printFrom(w *bufio.Writer, n *Node, prefix string, isLeft bool, label LabelFunc) {
+	if n == nil {
+		return
+	}
+	above, below, branch := "    ", "│   ", "┌── "
+	if isLeft {
+		above, below, branch = "│   ", "    ", "└── "
+	}
+	printFrom(w, n.Right, prefix+above, false, label)
+	w.WriteString(prefix + branch + label(n) + "\n")
+	printFrom(w, n.Left, prefix+below, true, label)
+}
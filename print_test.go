@@ -0,0 +1,35 @@
+package btree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrint(t *testing.T) {
+	for _, test := range []struct {
+		vals []int
+		want string
+	}{
+		{vals: nil, want: ""},
+		{vals: []int{1}, want: "1\n"},
+		{
+			vals: []int{4, 6, 2, 7, 5, 1, 3},
+			want: `    ┌── 7
+┌── 6
+│   └── 5
+4
+│   ┌── 3
+└── 2
+    └── 1
+`,
+		},
+	} {
+		var buf bytes.Buffer
+		if err := newIntTree(test.vals...).Print(&buf, intLabel); err != nil {
+			t.Fatalf("Print(%v) = %v", test.vals, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("Print(%v) = \n%s\nwant\n%s", test.vals, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package btree
+
+import (
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// profiled labels the calling goroutine for the operation `op` and starts a trace region for it,
+// see `ProfileContext`. It returns the function that ends both, and then calls `end`.
+func (b *BTree) profiled(op string, end func()) func() {
+	ctx := pprof.WithLabels(b.ProfileContext, pprof.Labels("btree.op", op))
+	pprof.SetGoroutineLabels(ctx)
+	region := trace.StartRegion(ctx, "btree."+op)
+	return func() {
+		region.End()
+		pprof.SetGoroutineLabels(b.ProfileContext)
+		end()
+	}
+}
@@ -0,0 +1,57 @@
+package btree
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"testing"
+)
+
+// goroutineLabels returns the goroutine profile, which lists the labels of the goroutines.
+func goroutineLabels(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		t.Fatalf("goroutine profile: %v", err)
+	}
+	return buf.String()
+}
+
+func TestProfileLabels(t *testing.T) {
+	b := newIntTree(2, 1, 3)
+	b.ProfileContext = pprof.WithLabels(context.Background(), pprof.Labels("tree", "test"))
+	var during string
+	b.DepthFirstInOrder(func(n *Node) {
+		if n.Payload == 2 {
+			during = goroutineLabels(t)
+		}
+	})
+	if !strings.Contains(during, `"btree.op":"DepthFirstInOrder"`) || !strings.Contains(during, `"tree":"test"`) {
+		t.Errorf("labels during DepthFirstInOrder() lack btree.op and tree:\n%s", during)
+	}
+	if after := goroutineLabels(t); strings.Contains(after, `"btree.op"`) {
+		t.Errorf("btree.op label remains after DepthFirstInOrder():\n%s", after)
+	}
+}
+
+func TestProfileTraceRegions(t *testing.T) {
+	if trace.IsEnabled() {
+		t.Skip("tracing is already enabled")
+	}
+	b := New(intLess)
+	b.ProfileContext = context.Background()
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Fatalf("trace.Start() = %v", err)
+	}
+	b.Upsert(&Node{Payload: 1})
+	b.Delete(&Node{Payload: 1})
+	trace.Stop()
+	for _, region := range []string{"btree.Upsert", "btree.Delete"} {
+		if !bytes.Contains(buf.Bytes(), []byte(region)) {
+			t.Errorf("trace lacks region %q", region)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+)
+
+// Generate implements `quick.Generator`, so that `testing/quick` produces random trees for
+// properties that take a `*BTree`. The trees hold up to `size` distinct `int` payloads between
+// `-size` and `size`, ordered numerically, and vary in shape. For trees of other payloads, see
+// `Arbitrary()`.
+func (*BTree) Generate(rng *rand.Rand, size int) reflect.Value {
+	payload := func(rng *rand.Rand) interface{} { return rng.Intn(2*size+1) - size }
+	return reflect.ValueOf(Arbitrary(fixtureLess, payload, rng, size))
+}
+
+// Arbitrary returns a random, valid tree of up to `size` nodes, ordered by `less`, whose payloads
+// are drawn from `payload`; payloads that are already in the tree are skipped. Since the nodes are
+// inserted in random order, the trees vary in shape, from balanced to skewed. It is meant for
+// property-based tests of code that takes trees, e.g. in the `Values` function of a
+// `quick.Config`:
+//
+//	cfg := &quick.Config{Values: func(args []reflect.Value, rng *rand.Rand) {
+//	    args[0] = reflect.ValueOf(btree.Arbitrary(lessFunc, randomPerson, rng, 100))
+//	}}
+func Arbitrary(less LessFunc, payload func(rng *rand.Rand) interface{}, rng *rand.Rand,
+	size int) *BTree {
+	b := New(less)
+	for n := rng.Intn(size + 1); n > 0; n-- {
+		b.upsert(&Node{Payload: payload(rng)})
+	}
+	return b
+}
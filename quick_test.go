@@ -0,0 +1,45 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+)
+
+func TestGenerate(t *testing.T) {
+	shapes := map[string]struct{}{}
+	property := func(b *BTree) bool {
+		shapes[b.SExpr(intLabel)] = struct{}{}
+		got := inOrderInts(b)
+		return b.Verify() == nil && sort.IntsAreSorted(got)
+	}
+	if err := quick.Check(property, &quick.Config{Rand: rand.New(rand.NewSource(1))}); err != nil {
+		t.Error(err)
+	}
+	if len(shapes) < 50 {
+		t.Errorf("quick.Check() generated %v different trees, want at least 50", len(shapes))
+	}
+}
+
+func TestArbitrary(t *testing.T) {
+	words := []string{"apple", "banana", "cherry", "date", "elderberry", "fig"}
+	stringLess := func(a, b *Node) bool { return a.Payload.(string) < b.Payload.(string) }
+	cfg := &quick.Config{
+		Rand: rand.New(rand.NewSource(1)),
+		Values: func(args []reflect.Value, rng *rand.Rand) {
+			args[0] = reflect.ValueOf(Arbitrary(stringLess, func(rng *rand.Rand) interface{} {
+				return words[rng.Intn(len(words))]
+			}, rng, 10))
+		},
+	}
+	property := func(b *BTree) bool {
+		got := []string{}
+		b.DepthFirstInOrder(func(n *Node) { got = append(got, n.Payload.(string)) })
+		return b.Verify() == nil && len(got) <= len(words) && sort.StringsAreSorted(got)
+	}
+	if err := quick.Check(property, cfg); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,88 @@
+package btree
+
+// VisitFunc is supplied by the caller of traversal functions that may stop early, such as
+// `AscendRange()`. It is called for each visited node, and returns `false` to stop the traversal.
+type VisitFunc func(n *Node) bool
+
+// Min returns the smallest node of the tree, or `nil` when the tree is empty.
+func (b *BTree) Min() *Node {
+	defer b.beginRead("Min")()
+	if b.Root == nil {
+		return nil
+	}
+	if len(b.tombstones) > 0 || len(b.expiry) > 0 {
+		var min *Node
+		b.ascendFrom(b.Root, nil, nil, func(n *Node) bool { min = n; return false })
+		return min
+	}
+	return leftmost(b.Root)
+}
+
+// Max returns the largest node of the tree, or `nil` when the tree is empty.
+func (b *BTree) Max() *Node {
+	defer b.beginRead("Max")()
+	if b.Root == nil {
+		return nil
+	}
+	if len(b.tombstones) > 0 || len(b.expiry) > 0 {
+		var max *Node
+		b.descendFrom(b.Root, nil, nil, func(n *Node) bool { max = n; return false })
+		return max
+	}
+	return rightmost(b.Root)
+}
+
+// AscendRange calls `visit` for the nodes `n` with `from <= n < to`, in ascending order, until
+// `visit` returns `false`. When `from` is `nil`, the range starts at the smallest node; when `to`
+// is `nil`, the range runs up to and including the largest node. Subtrees outside of the range are
+// not visited.
+func (b *BTree) AscendRange(from, to *Node, visit VisitFunc) {
+	defer b.beginRead("AscendRange")()
+	b.ascendFrom(b.Root, from, to, visit)
+}
+
+func (b *BTree) ascendFrom(n, from, to *Node, visit VisitFunc) bool {
+	if n == nil {
+		return true
+	}
+	b.stats.visit()
+	aboveFrom := from == nil || !b.Less(n, from)
+	belowTo := to == nil || b.Less(n, to)
+	if aboveFrom && !b.ascendFrom(n.Left, from, to, visit) {
+		return false
+	}
+	if aboveFrom && belowTo && !b.hidden(n) && !visit(n) {
+		return false
+	}
+	if belowTo {
+		return b.ascendFrom(n.Right, from, to, visit)
+	}
+	return true
+}
+
+// DescendRange calls `visit` for the nodes `n` with `to < n <= from`, in descending order, until
+// `visit` returns `false`. When `from` is `nil`, the range starts at the largest node; when `to`
+// is `nil`, the range runs down to and including the smallest node.
+func (b *BTree) DescendRange(from, to *Node, visit VisitFunc) {
+	defer b.beginRead("DescendRange")()
+	b.descendFrom(b.Root, from, to, visit)
+}
+
+func (b *BTree) descendFrom(n, from, to *Node, visit VisitFunc) bool {
+	if n == nil {
+		return true
+	}
+	b.stats.visit()
+	belowFrom := from == nil || !b.Less(from, n)
+	aboveTo := to == nil || b.Less(to, n)
+	if belowFrom && !b.descendFrom(n.Right, from, to, visit) {
+		return false
+	}
+	if belowFrom && aboveTo && !b.hidden(n) && !visit(n) {
+		return false
+	}
+	if aboveTo {
+		return b.descendFrom(n.Left, from, to, visit)
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func nodeOrNil(v int) *Node {
+	if v < 0 {
+		return nil
+	}
+	return &Node{Payload: v}
+}
+
+func TestMinMax(t *testing.T) {
+	if n := New(intLess).Min(); n != nil {
+		t.Errorf("Min() of empty tree = %v, want nil", n)
+	}
+	if n := New(intLess).Max(); n != nil {
+		t.Errorf("Max() of empty tree = %v, want nil", n)
+	}
+	b := newIntTree(5, 3, 8, 1, 9)
+	if got := b.Min().Payload; got != 1 {
+		t.Errorf("Min() = %v, want 1", got)
+	}
+	if got := b.Max().Payload; got != 9 {
+		t.Errorf("Max() = %v, want 9", got)
+	}
+}
+
+func TestAscendDescendRange(t *testing.T) {
+	b := newIntTree(5, 3, 8, 1, 4, 7, 9, 2, 6)
+	for _, test := range []struct {
+		from, to, stop int // -1: nil or no stop
+		asc, desc      []int
+	}{
+		{from: -1, to: -1, stop: -1, asc: []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, desc: []int{9, 8, 7, 6, 5, 4, 3, 2, 1}},
+		{from: 3, to: 7, stop: -1, asc: []int{3, 4, 5, 6}, desc: []int{}},
+		{from: 7, to: 3, stop: -1, asc: []int{}, desc: []int{7, 6, 5, 4}},
+		{from: 0, to: 3, stop: -1, asc: []int{1, 2}, desc: []int{}},
+		{from: -1, to: 4, stop: -1, asc: []int{1, 2, 3}, desc: []int{9, 8, 7, 6, 5}},
+		{from: 6, to: -1, stop: -1, asc: []int{6, 7, 8, 9}, desc: []int{6, 5, 4, 3, 2, 1}},
+		{from: -1, to: -1, stop: 3, asc: []int{1, 2, 3}, desc: []int{9, 8, 7, 6, 5, 4, 3}},
+	} {
+		collect := func(walk func(from, to *Node, visit VisitFunc)) []int {
+			got := []int{}
+			walk(nodeOrNil(test.from), nodeOrNil(test.to), func(n *Node) bool {
+				got = append(got, n.Payload.(int))
+				return n.Payload.(int) != test.stop
+			})
+			return got
+		}
+		if got := collect(b.AscendRange); !reflect.DeepEqual(got, test.asc) {
+			t.Errorf("AscendRange(%v, %v) = %v, want %v", test.from, test.to, got, test.asc)
+		}
+		if got := collect(b.DescendRange); !reflect.DeepEqual(got, test.desc) {
+			t.Errorf("DescendRange(%v, %v) = %v, want %v", test.from, test.to, got, test.desc)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package btree
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Rebalance restructures the tree so that it is balanced: its height becomes minimal for its
+// number of nodes. The nodes are relinked in place using rotations (the Day-Stout-Warren
+// algorithm), so nothing is allocated.
+func (b *BTree) Rebalance() {
+	defer b.beginWrite("Rebalance")()
+	b.rebalance()
+}
+
+// rebalance is `Rebalance()` without the concurrent use check.
+func (b *BTree) rebalance() {
+	b.ResetFinger()
+	var rotations int
+	b.Root, rotations = rebalanced(b.Root, b.OnRotate)
+	b.stats.rotate(rotations)
+	b.logRotations("Rebalance", rotations)
+}
+
+// rebalanced relinks the subtree under `top` using the Day-Stout-Warren algorithm and returns its
+// new top, plus the number of rotations: the subtree is first rotated into a "vine" where each
+// node only has a right sub-node, which is then rotated into a balanced tree. When `rotated` is
+// not `nil`, it is called after each rotation, see `OnRotate`.
+func rebalanced(top *Node, rotated func(up, down *Node)) (newTop *Node, rotations int) {
+	pseudo := &Node{Right: top}
+	size, rotations := treeToVine(pseudo, rotated)
+	leaves := size + 1 - 1<<(bits.Len(uint(size+1))-1)
+	compress(pseudo, leaves, rotated)
+	rotations += leaves
+	for size -= leaves; size > 1; size /= 2 {
+		compress(pseudo, size/2, rotated)
+		rotations += size / 2
+	}
+	return pseudo.Right, rotations
+}
+
+// treeToVine rotates the subtree right of `pseudo` into a vine and returns its number of nodes,
+// plus the number of rotations that it took.
+func treeToVine(pseudo *Node, rotated func(up, down *Node)) (size, rotations int) {
+	tail, rest := pseudo, pseudo.Right
+	for rest != nil {
+		if rest.Left == nil {
+			tail, rest = rest, rest.Right
+			size++
+			continue
+		}
+		// Rotate right.
+		left := rest.Left
+		rest.Left = left.Right
+		left.Right = rest
+		rest = left
+		tail.Right = left
+		rotations++
+		if rotated != nil {
+			rotated(left, left.Right)
+		}
+	}
+	return size, rotations
+}
+
+// compress rotates `count` nodes of the vine right of `pseudo` to the left.
+func compress(pseudo *Node, count int, rotated func(up, down *Node)) {
+	scanner := pseudo
+	for i := 0; i < count; i++ {
+		child := scanner.Right
+		scanner.Right = child.Right
+		scanner = scanner.Right
+		child.Right = scanner.Left
+		scanner.Left = child
+		if rotated != nil {
+			rotated(scanner, child)
+		}
+	}
+}
+
+// skewed returns `true` when a subtree of `size` nodes and the given height is more than twice as
+// high as a balanced one.
+func skewed(size, height int) bool {
+	return height > 2*bits.Len(uint(size))
+}
+
+// rebalanceSkewed looks for skewed subtrees along one path down the tree, and rebalances the
+// largest one that it finds; it returns `true` when there was one. The path is chosen by `seq`:
+// at depth `d`, it goes left when bit `d` (modulo 64) of `seq` is 0, unless there is only one way
+// to go. Successive values of `seq` thus cover all paths of the top of the tree.
+//
+// The height of the subtree under a node of the path is at least the length of the path below
+// it, and the subtree is surely skewed when that is more than twice as high as a balanced subtree
+// of its size. The sizes are counted bottom-up, but only as far as a subtree can still be skewed
+// by this measure. A round thus takes O(h + 2^(h/2)) steps for a path of length `h`, plus the
+// size of the subtree that is rebalanced; on a tree in shape, far less than O(n).
+func (b *BTree) rebalanceSkewed(seq uint64) bool {
+	defer b.beginWrite("Maintain")()
+	var path []**Node
+	for slot, d := &b.Root, 0; *slot != nil; d++ {
+		path = append(path, slot)
+		n := *slot
+		switch {
+		case n.Left == nil:
+			slot = &n.Right
+		case n.Right == nil || seq>>(d%64)&1 == 0:
+			slot = &n.Left
+		default:
+			slot = &n.Right
+		}
+	}
+	// maxSize is the size from which no subtree on the path can be found skewed.
+	maxSize := math.MaxInt
+	if h := len(path) / 2; h < 62 {
+		maxSize = 1 << h
+	}
+	worst, size := -1, 0
+	for i := len(path) - 1; i >= 0 && size < maxSize; i-- {
+		n := *path[i]
+		sibling := n.Left
+		if i+1 < len(path) && sibling == *path[i+1] {
+			sibling = n.Right
+		}
+		size += 1 + countUpTo(sibling, maxSize-size)
+		if skewed(size, len(path)-i) {
+			worst = i
+		}
+	}
+	if worst < 0 {
+		return false
+	}
+	b.ResetFinger()
+	var rotations int
+	*path[worst], rotations = rebalanced(*path[worst], b.OnRotate)
+	b.stats.rotate(rotations)
+	b.logRotations("Maintain", rotations)
+	return true
+}
+
+// countUpTo returns the number of nodes of the subtree under `n`, or a number larger than `limit`
+// when there are more.
+func countUpTo(n *Node, limit int) int {
+	size := 0
+	stack := []*Node{}
+	for n != nil || len(stack) > 0 {
+		if n == nil {
+			n, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		}
+		if size++; size > limit {
+			break
+		}
+		if n.Right != nil {
+			stack = append(stack, n.Right)
+		}
+		n = n.Left
+	}
+	return size
+}
+
+// Maintain keeps the tree in shape in the background, until `ctx` is done. Every `interval`,
+// when no other goroutine is using the tree, it samples one path from the top of the tree down,
+// and rebalances the largest subtree along it that is more than twice as high as it should be.
+// Successive rounds sample different paths. Trees that are filled in a random order rarely need
+// this, but e.g. sorted insertions produce long chains. Since the work happens in idle periods,
+// inserts never pay for rebalancing.
+//
+// A round holds the write lock while it works. Looking for a skewed subtree takes time in
+// proportion to the length of the sampled path, plus the number of nodes near its bottom that
+// could make up a skewed subtree, which on a tree in shape is far less than the size of the tree.
+// Rebalancing takes time in proportion to the size of the skewed subtree: after many sorted
+// insertions, this may be the whole tree, which then blocks readers and writers for O(n) once.
+// Typical use is:
+//
+//	go s.Maintain(ctx, time.Second)
+func (s *SyncTree) Maintain(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for seq := uint64(0); ; {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !s.mu.TryLock() {
+			continue // busy, try again later
+		}
+		s.t.rebalanceSkewed(seq)
+		seq++
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,113 @@
+package btree
+
+import (
+	"context"
+	"math/bits"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebalance(t *testing.T) {
+	for size := 0; size < 70; size++ {
+		vals := make([]int, size)
+		for i := range vals {
+			vals[i] = i
+		}
+		b := newIntTree(vals...)
+		b.Rebalance()
+		if got := inOrderInts(b); !reflect.DeepEqual(got, vals) {
+			t.Errorf("Rebalance() of %v nodes = %v, want %v", size, got, vals)
+		}
+		if got, want := height(b.Root), bits.Len(uint(size)); got != want {
+			t.Errorf("Rebalance() of %v nodes has height %v, want %v", size, got, want)
+		}
+	}
+}
+
+func TestRebalanceSkewed(t *testing.T) {
+	// A balanced tree of 1023 nodes and height 10, with a chain of 10 nodes hanging off its
+	// smallest node.
+	vals := make([]int, 1023)
+	for i := range vals {
+		vals[i] = i * 100
+	}
+	b := &BTree{Root: buildBalanced(intNodes(vals...)), Less: intLess}
+	for v := 1; v <= 10; v++ {
+		b.Upsert(&Node{Payload: v})
+	}
+	rightBefore := b.Root.Right
+	// The chain is found along one of the paths of the top 10 levels.
+	found := 0
+	for seq := uint64(0); seq < 1<<10; seq++ {
+		if b.rebalanceSkewed(seq) {
+			found++
+		}
+	}
+	if found != 1 {
+		t.Errorf("rebalanceSkewed() over all paths = true %v times, want once", found)
+	}
+	if b.Root.Right != rightBefore {
+		t.Errorf("rebalanceSkewed() changed a subtree that was fine")
+	}
+	if got := height(b.Root); got != 11 {
+		t.Errorf("height after rebalanceSkewed() = %v, want 11", got)
+	}
+	if got := len(inOrderInts(b)); got != 1033 {
+		t.Errorf("rebalanceSkewed() leaves %v nodes, want 1033", got)
+	}
+}
+
+func TestRebalanceSkewedChain(t *testing.T) {
+	// A chain as from sorted insertions, too long for a recursive walk to be cheap.
+	const size = 1 << 20
+	b := New(intLess)
+	b.Root = &Node{Payload: 0}
+	for n, v := b.Root, 1; v < size; v++ {
+		n.Right = &Node{Payload: v}
+		n = n.Right
+	}
+	if !b.rebalanceSkewed(0) {
+		t.Fatalf("rebalanceSkewed() of a chain = false, want true")
+	}
+	if got, want := height(b.Root), bits.Len(size); got != want {
+		t.Errorf("height after rebalanceSkewed() of a chain = %v, want %v", got, want)
+	}
+
+	// Maintenance is a write, so it is subject to the concurrent use check.
+	b.CheckConcurrentUse = true
+	msg := panicOf(func() {
+		b.DepthFirstInOrder(func(*Node) { b.rebalanceSkewed(0) })
+	})
+	if !strings.Contains(msg, "write during a read") {
+		t.Errorf("rebalanceSkewed() during a walk: panic = %q, want a concurrent use panic", msg)
+	}
+}
+
+func TestMaintain(t *testing.T) {
+	s := Synchronized(New(intLess))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Maintain(ctx, time.Millisecond)
+		close(done)
+	}()
+	for v := 0; v < 1000; v++ {
+		s.Upsert(&Node{Payload: v})
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var h int
+		s.Read(func(b *BTree) { h = height(b.Root) })
+		if !skewed(1000, h) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Maintain() leaves a tree of height %v", h)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
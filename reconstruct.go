@@ -0,0 +1,67 @@
+package btree
+
+import "fmt"
+
+// FromTraversals returns a tree that is rebuilt from its preorder and in-order traversals. The
+// slices must hold the payloads of the same nodes; the in-order one must be sorted according to
+// `less`. Since the nodes of a binary tree are distinct, this uniquely determines the shape,
+// which is restored exactly. An error is returned when the traversals don't match.
+func FromTraversals(less LessFunc, preorder, inorder []interface{}) (*BTree, error) {
+	if len(preorder) != len(inorder) {
+		return nil, fmt.Errorf("btree: preorder has %v nodes, in-order has %v", len(preorder), len(inorder))
+	}
+	b := New(less)
+	nodes := make([]*Node, len(inorder))
+	for i, p := range inorder {
+		nodes[i] = &Node{Payload: p}
+		if i > 0 && !less(nodes[i-1], nodes[i]) {
+			return nil, fmt.Errorf("btree: in-order traversal is not sorted at position %v", i)
+		}
+	}
+
+	// Each preorder entry is the top of the in-order range that is being rebuilt. It is located in
+	// the in-order slice using binary search, since that slice is sorted.
+	next := 0
+	var build func(lo, hi int) (*Node, error)
+	build = func(lo, hi int) (*Node, error) {
+		if lo >= hi {
+			return nil, nil
+		}
+		want := &Node{Payload: preorder[next]}
+		i := lo + searchNodes(nodes[lo:hi], want, less)
+		if i == hi || less(want, nodes[i]) {
+			return nil, fmt.Errorf("btree: preorder node %v (%v) doesn't fit the in-order traversal", next, preorder[next])
+		}
+		next++
+		top := nodes[i]
+		var err error
+		if top.Left, err = build(lo, i); err != nil {
+			return nil, err
+		}
+		if top.Right, err = build(i+1, hi); err != nil {
+			return nil, err
+		}
+		return top, nil
+	}
+	root, err := build(0, len(nodes))
+	if err != nil {
+		return nil, err
+	}
+	b.Root = root
+	return b, nil
+}
+
+// searchNodes returns the index of the first node in the sorted `nodes` that is not less than
+// `n`, or `len(nodes)`.
+func searchNodes(nodes []*Node, n *Node, less LessFunc) int {
+	lo, hi := 0, len(nodes)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if less(nodes[mid], n) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
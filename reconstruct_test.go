@@ -0,0 +1,46 @@
+package btree
+
+import "testing"
+
+func preorderPayloads(n *Node, out []interface{}) []interface{} {
+	if n == nil {
+		return out
+	}
+	out = append(out, n.Payload)
+	out = preorderPayloads(n.Left, out)
+	return preorderPayloads(n.Right, out)
+}
+
+func TestFromTraversals(t *testing.T) {
+	for _, vals := range [][]int{nil, {1}, {5, 3, 8, 1, 4, 7, 9, 2, 6}, {1, 2, 3, 4}, {4, 3, 2, 1}} {
+		b := newIntTree(vals...)
+		pre := preorderPayloads(b.Root, nil)
+		in := b.Reduce([]interface{}{}, func(acc interface{}, n *Node) interface{} {
+			return append(acc.([]interface{}), n.Payload)
+		}).([]interface{})
+
+		got, err := FromTraversals(intLess, pre, in)
+		if err != nil {
+			t.Fatalf("FromTraversals(%v, %v) = %v", pre, in, err)
+		}
+		if !b.StructurallyEqual(got, intEqual) {
+			t.Errorf("FromTraversals(%v, %v) = %v, want the original shape", pre, in, got.LevelOrder(intLabel))
+		}
+	}
+}
+
+func TestFromTraversalsErrors(t *testing.T) {
+	for _, test := range []struct {
+		pre, in []interface{}
+	}{
+		{pre: []interface{}{1}, in: []interface{}{1, 2}},
+		{pre: []interface{}{1, 2}, in: []interface{}{2, 1}},
+		{pre: []interface{}{1, 3}, in: []interface{}{1, 2}},
+		{pre: []interface{}{1, 1}, in: []interface{}{1, 2}},
+		{pre: []interface{}{2, 3, 1}, in: []interface{}{1, 2, 3}},
+	} {
+		if _, err := FromTraversals(intLess, test.pre, test.in); err == nil {
+			t.Errorf("FromTraversals(%v, %v) succeeds, want error", test.pre, test.in)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package btree
+
+// ReduceFunc is supplied by the caller of `Reduce()`. It receives the accumulated value so far
+// and a node, and returns the new accumulated value.
+type ReduceFunc func(acc interface{}, n *Node) interface{}
+
+// Reduce folds the tree into a single value. It starts with `init` and calls `fn` for every node,
+// in the same order as `DepthFirstInOrder()`. The last value that `fn` returns is the result, or
+// `init` when the tree is empty.
+func (b *BTree) Reduce(init interface{}, fn ReduceFunc) interface{} {
+	acc := init
+	b.DepthFirstInOrder(func(n *Node) {
+		acc = fn(acc, n)
+	})
+	return acc
+}
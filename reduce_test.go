@@ -0,0 +1,14 @@
+package btree
+
+import "testing"
+
+func TestReduce(t *testing.T) {
+	sum := func(acc interface{}, n *Node) interface{} { return acc.(int) + n.Payload.(int) }
+
+	if got, want := newIntTree(4, 2, 9, 1).Reduce(0, sum), 16; got != want {
+		t.Errorf("Reduce(sum) = %v, want %v", got, want)
+	}
+	if got, want := New(intLess).Reduce(42, sum), 42; got != want {
+		t.Errorf("Reduce(sum) on empty tree = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,277 @@
+// Package rope implements a rope: a string held as a binary tree of chunks, so that inserting,
+// deleting and concatenating take O(log n) rather than copying the whole string, which suits e.g.
+// the buffers of text editors. The leaves of the tree hold the chunks, in order; every other node
+// records the length of the text below it, so that a position is found by descending the tree
+// (e.g. by going left when it lies within the length of the left subtree).
+//
+// Ropes are immutable: operations return new ropes, which share the unchanged parts of the tree
+// with the rope they came from. Keeping old ropes around is therefore cheap, e.g. for undo.
+// Positions are byte offsets, as for Go strings.
+package rope
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KarelKubat/btree"
+)
+
+// chunkSize is the size of the chunks of `New()`. Adjacent chunks are merged as long as the result
+// is no larger.
+const chunkSize = 128
+
+// inner is the payload of the nodes that are not leaves: the length of the text and the height of
+// the subtree. The payload of a leaf is its chunk, a `string`.
+type inner struct {
+	len, height int
+}
+
+// Rope is an immutable string. The zero value is the empty string.
+type Rope struct {
+	root *btree.Node
+}
+
+// New returns a rope that holds `s`.
+func New(s string) *Rope {
+	leaves := []*btree.Node{}
+	for len(s) > 0 {
+		n := min(len(s), chunkSize)
+		leaves = append(leaves, &btree.Node{Payload: s[:n]})
+		s = s[n:]
+	}
+	return &Rope{root: build(leaves)}
+}
+
+func length(n *btree.Node) int {
+	switch p := payload(n).(type) {
+	case string:
+		return len(p)
+	case *inner:
+		return p.len
+	}
+	return 0
+}
+
+func height(n *btree.Node) int {
+	if in, ok := payload(n).(*inner); ok {
+		return in.height
+	}
+	return 0
+}
+
+func payload(n *btree.Node) interface{} {
+	if n == nil {
+		return nil
+	}
+	return n.Payload
+}
+
+// join returns the concatenation of the subtrees `a` and `b`, which is kept balanced like an AVL
+// tree: the heights of the sub-nodes of a node differ by at most one. Small leaves are merged into
+// one.
+func join(a, b *btree.Node) *btree.Node {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	as, aok := a.Payload.(string)
+	bs, bok := b.Payload.(string)
+	if aok && bok && len(as)+len(bs) <= chunkSize {
+		return &btree.Node{Payload: as + bs}
+	}
+	switch ha, hb := height(a), height(b); {
+	case ha > hb+1:
+		return balance(a.Left, join(a.Right, b))
+	case hb > ha+1:
+		return balance(join(a, b.Left), b.Right)
+	}
+	return node(a, b)
+}
+
+// balance returns a node of `l` and `r`, whose heights differ by at most two, rotated so that they
+// differ by at most one.
+func balance(l, r *btree.Node) *btree.Node {
+	switch hl, hr := height(l), height(r); {
+	case hr > hl+1:
+		if height(r.Left) > height(r.Right) {
+			return node(node(l, r.Left.Left), node(r.Left.Right, r.Right))
+		}
+		return node(node(l, r.Left), r.Right)
+	case hl > hr+1:
+		if height(l.Right) > height(l.Left) {
+			return node(node(l.Left, l.Right.Left), node(l.Right.Right, r))
+		}
+		return node(l.Left, node(l.Right, r))
+	}
+	return node(l, r)
+}
+
+// node returns a node with the sub-nodes `l` and `r`.
+func node(l, r *btree.Node) *btree.Node {
+	return &btree.Node{
+		Payload: &inner{len: length(l) + length(r), height: 1 + max(height(l), height(r))},
+		Left:    l,
+		Right:   r,
+	}
+}
+
+// build returns a balanced tree of `leaves`.
+func build(leaves []*btree.Node) *btree.Node {
+	switch len(leaves) {
+	case 0:
+		return nil
+	case 1:
+		return leaves[0]
+	}
+	m := len(leaves) / 2
+	return join(build(leaves[:m]), build(leaves[m:]))
+}
+
+// Len returns the length of the rope in bytes.
+func (r *Rope) Len() int {
+	return length(r.root)
+}
+
+// String returns the text of the rope.
+func (r *Rope) String() string {
+	var sb strings.Builder
+	sb.Grow(r.Len())
+	r.walk(r.root, func(s string) { sb.WriteString(s) })
+	return sb.String()
+}
+
+// walk calls `fn` for the chunks of the subtree under `n`, in order.
+func (r *Rope) walk(n *btree.Node, fn func(s string)) {
+	if n == nil {
+		return
+	}
+	if s, ok := n.Payload.(string); ok {
+		fn(s)
+		return
+	}
+	r.walk(n.Left, fn)
+	r.walk(n.Right, fn)
+}
+
+// check panics when `i` is not a position within the rope, including its end.
+func (r *Rope) check(i int) {
+	if i < 0 || i > r.Len() {
+		panic(fmt.Sprintf("rope: index %d out of range [0, %d]", i, r.Len()))
+	}
+}
+
+// Index returns the byte at position `i`.
+func (r *Rope) Index(i int) byte {
+	if i < 0 || i >= r.Len() {
+		panic(fmt.Sprintf("rope: index %d out of range [0, %d)", i, r.Len()))
+	}
+	n := r.root
+	for {
+		if s, ok := n.Payload.(string); ok {
+			return s[i]
+		}
+		if w := length(n.Left); i < w {
+			n = n.Left
+		} else {
+			i -= w
+			n = n.Right
+		}
+	}
+}
+
+// Slice returns the text from position `i` up to, but not including, `j`.
+func (r *Rope) Slice(i, j int) string {
+	r.check(i)
+	r.check(j)
+	if i > j {
+		panic(fmt.Sprintf("rope: slice bounds %d > %d", i, j))
+	}
+	var sb strings.Builder
+	sb.Grow(j - i)
+	var slice func(n *btree.Node, i, j int)
+	slice = func(n *btree.Node, i, j int) {
+		if n == nil || i >= j {
+			return
+		}
+		if s, ok := n.Payload.(string); ok {
+			sb.WriteString(s[i:j])
+			return
+		}
+		w := length(n.Left)
+		slice(n.Left, min(i, w), min(j, w))
+		slice(n.Right, max(i-w, 0), max(j-w, 0))
+	}
+	slice(r.root, i, j)
+	return sb.String()
+}
+
+// Concat returns the rope of the text of `r` followed by that of `o`.
+func (r *Rope) Concat(o *Rope) *Rope {
+	return &Rope{root: join(r.root, o.root)}
+}
+
+// split returns the subtrees of the text of `n` before and from position `i`.
+func split(n *btree.Node, i int) (before, from *btree.Node) {
+	switch {
+	case n == nil:
+		return nil, nil
+	case i <= 0:
+		return nil, n
+	case i >= length(n):
+		return n, nil
+	}
+	if s, ok := n.Payload.(string); ok {
+		return &btree.Node{Payload: s[:i]}, &btree.Node{Payload: s[i:]}
+	}
+	if w := length(n.Left); i >= w {
+		rl, rr := split(n.Right, i-w)
+		return join(n.Left, rl), rr
+	}
+	ll, lr := split(n.Left, i)
+	return ll, join(lr, n.Right)
+}
+
+// Split returns the ropes of the text before and from position `i`.
+func (r *Rope) Split(i int) (before, from *Rope) {
+	r.check(i)
+	b, f := split(r.root, i)
+	return &Rope{root: b}, &Rope{root: f}
+}
+
+// Insert returns the rope with `s` inserted at position `i`.
+func (r *Rope) Insert(i int, s string) *Rope {
+	r.check(i)
+	b, f := split(r.root, i)
+	return &Rope{root: join(join(b, New(s).root), f)}
+}
+
+// Delete returns the rope without the text from position `i` up to, but not including, `j`.
+func (r *Rope) Delete(i, j int) *Rope {
+	r.check(i)
+	r.check(j)
+	if i > j {
+		panic(fmt.Sprintf("rope: slice bounds %d > %d", i, j))
+	}
+	b, _ := split(r.root, i)
+	_, f := split(r.root, j)
+	return &Rope{root: join(b, f)}
+}
+
+// Rebalance returns the rope as a tree in which adjacent small chunks are merged, and which is
+// perfectly balanced. The other operations keep the tree balanced enough for O(log n), but may
+// leave many small chunks, e.g. after many small insertions; this is useful before many reads.
+func (r *Rope) Rebalance() *Rope {
+	leaves := []*btree.Node{}
+	r.walk(r.root, func(s string) {
+		if last := len(leaves) - 1; last >= 0 {
+			if ls := leaves[last].Payload.(string); len(ls)+len(s) <= chunkSize {
+				leaves[last] = &btree.Node{Payload: ls + s}
+				return
+			}
+		}
+		leaves = append(leaves, &btree.Node{Payload: s})
+	})
+	return &Rope{root: build(leaves)}
+}
@@ -0,0 +1,147 @@
+package rope
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/KarelKubat/btree"
+)
+
+// check returns what's wrong with the lengths, heights and balance of the subtree under `n`.
+func check(n *btree.Node) string {
+	if n == nil || n.Left == nil && n.Right == nil {
+		return ""
+	}
+	for _, sub := range []*btree.Node{n.Left, n.Right} {
+		if err := check(sub); err != "" {
+			return err
+		}
+	}
+	in := n.Payload.(*inner)
+	switch hl, hr := height(n.Left), height(n.Right); {
+	case in.len != length(n.Left)+length(n.Right):
+		return fmt.Sprintf("node has length %v, want %v", in.len, length(n.Left)+length(n.Right))
+	case in.height != 1+max(hl, hr):
+		return fmt.Sprintf("node has height %v, want %v", in.height, 1+max(hl, hr))
+	case hl > hr+1 || hr > hl+1:
+		return fmt.Sprintf("node has sub-nodes of heights %v and %v", hl, hr)
+	}
+	return ""
+}
+
+func TestOperations(t *testing.T) {
+	r := New("Hello, world")
+	if got, want := r.Insert(7, "big ").String(), "Hello, big world"; got != want {
+		t.Errorf("Insert() = %q, want %q", got, want)
+	}
+	if got, want := r.Delete(5, 12).String(), "Hello"; got != want {
+		t.Errorf("Delete() = %q, want %q", got, want)
+	}
+	if got, want := r.Concat(New("!")).String(), "Hello, world!"; got != want {
+		t.Errorf("Concat() = %q, want %q", got, want)
+	}
+	if got, want := r.Slice(7, 12), "world"; got != want {
+		t.Errorf("Slice() = %q, want %q", got, want)
+	}
+	before, from := r.Split(5)
+	if before.String() != "Hello" || from.String() != ", world" {
+		t.Errorf("Split(5) = %q, %q, want %q, %q", before, from, "Hello", ", world")
+	}
+	if got := r.Index(4); got != 'o' {
+		t.Errorf("Index(4) = %q, want 'o'", got)
+	}
+	// Ropes are immutable.
+	if got, want := r.String(), "Hello, world"; got != want || r.Len() != len(want) {
+		t.Errorf("after the operations, the rope is %q of length %v, want %q", got, r.Len(), want)
+	}
+	var empty Rope
+	if got := empty.Concat(New("x")).Insert(0, "y").String(); got != "yx" {
+		t.Errorf("operations on the zero Rope = %q, want %q", got, "yx")
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+	r := New("abc")
+	for _, test := range []struct {
+		desc string
+		fn   func()
+		want string
+	}{
+		{desc: "Index", fn: func() { r.Index(3) }, want: "rope: index 3 out of range [0, 3)"},
+		{desc: "Insert", fn: func() { r.Insert(4, "x") }, want: "rope: index 4 out of range [0, 3]"},
+		{desc: "Slice", fn: func() { r.Slice(2, 1) }, want: "rope: slice bounds 2 > 1"},
+		{desc: "Delete", fn: func() { r.Delete(-1, 1) }, want: "rope: index -1 out of range [0, 3]"},
+	} {
+		func() {
+			defer func() {
+				if got := recover(); got != test.want {
+					t.Errorf("%v: panic = %v, want %v", test.desc, got, test.want)
+				}
+			}()
+			test.fn()
+		}()
+	}
+}
+
+func TestAgainstString(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	text := func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte('a' + rng.Intn(26))
+		}
+		return string(b)
+	}
+	want := text(1000)
+	r := New(want)
+	for i := 0; i < 3000; i++ {
+		at := rng.Intn(len(want) + 1)
+		switch op := rng.Intn(10); {
+		case op < 6:
+			// Mostly small insertions, like typing.
+			s := text(1 + rng.Intn(3))
+			if rng.Intn(10) == 0 {
+				s = text(rng.Intn(500))
+			}
+			r, want = r.Insert(at, s), want[:at]+s+want[at:]
+		case op < 9:
+			to := at + rng.Intn(len(want)-at+1)
+			r, want = r.Delete(at, to), want[:at]+want[to:]
+		default:
+			before, from := r.Split(at)
+			r = from.Concat(before)
+			want = want[at:] + want[:at]
+		}
+		if r.Len() != len(want) {
+			t.Fatalf("op %v: Len() = %v, want %v", i, r.Len(), len(want))
+		}
+		if i%100 == 0 && r.String() != want {
+			t.Fatalf("op %v: String() differs", i)
+		}
+		if len(want) > 0 {
+			k := rng.Intn(len(want))
+			if got := r.Index(k); got != want[k] {
+				t.Fatalf("op %v: Index(%v) = %q, want %q", i, k, got, want[k])
+			}
+			j := k + rng.Intn(len(want)-k+1)
+			if got := r.Slice(k, j); got != want[k:j] {
+				t.Fatalf("op %v: Slice(%v, %v) differs", i, k, j)
+			}
+		}
+		if err := check(r.root); err != "" {
+			t.Fatalf("op %v: %v", i, err)
+		}
+	}
+	if r.String() != want || r.Rebalance().String() != want {
+		t.Errorf("String() differs at the end")
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	r := New(strings.Repeat("x", 1<<20))
+	for i := 0; i < b.N; i++ {
+		r = r.Insert(r.Len()/2, "y")
+	}
+}
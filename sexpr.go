@@ -0,0 +1,149 @@
+package btree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SExpr returns the tree as a nested, parenthesized text that is easy to write by hand, e.g. for
+// test fixtures. Each node is `(label left right)`, where a missing sub-node is `()`, and missing
+// trailing sub-nodes are left out. For example, `(2 (1) (4 (3)))` is a tree with root 2, its left
+// sub-node 1, and its right sub-node 4 which has a left sub-node 3. An empty tree is `()`. The
+// text of each node is determined by `label`; it is quoted when it contains spaces, parentheses or
+// quotes.
+func (b *BTree) SExpr(label LabelFunc) string {
+	var sb strings.Builder
+	sexprFrom(&sb, b.Root, label)
+	return sb.String()
+}
+
+func sexprFrom(sb *strings.Builder, n *Node, label LabelFunc) {
+	if n == nil {
+		sb.WriteString("()")
+		return
+	}
+	sb.WriteString("(")
+	sb.WriteString(sexprAtom(label(n)))
+	if n.Left != nil || n.Right != nil {
+		sb.WriteString(" ")
+		sexprFrom(sb, n.Left, label)
+	}
+	if n.Right != nil {
+		sb.WriteString(" ")
+		sexprFrom(sb, n.Right, label)
+	}
+	sb.WriteString(")")
+}
+
+// sexprAtom quotes `s` when it can't be written as-is.
+func sexprAtom(s string) string {
+	if s == "" || strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+	}) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// ReadSExpr replaces the nodes of the tree with the ones in `s`, which must be in the format of
+// `SExpr()`. The text of each node is converted into a payload by `parse`. The tree is built
+// exactly as described by `s`; the `LessFunc` of the tree is kept.
+func (b *BTree) ReadSExpr(s string, parse ParseFunc) error {
+	p := &sexprParser{s: s, parse: parse}
+	root, err := p.node()
+	if err != nil {
+		return err
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return p.errorf("unexpected text after the tree")
+	}
+	b.load(root)
+	return nil
+}
+
+type sexprParser struct {
+	s     string
+	pos   int
+	parse ParseFunc
+}
+
+func (p *sexprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("btree: s-expression at position %v: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *sexprParser) skipSpace() {
+	for p.pos < len(p.s) && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// expect consumes the byte `c` when it is next, and returns `true` if so.
+func (p *sexprParser) expect(c byte) bool {
+	if p.skipSpace(); p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// node parses `()` or `(label [left [right]])`.
+func (p *sexprParser) node() (*Node, error) {
+	if !p.expect('(') {
+		return nil, p.errorf("expected (")
+	}
+	if p.expect(')') {
+		return nil, nil
+	}
+	text, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := p.parse(text)
+	if err != nil {
+		return nil, p.errorf("cannot parse %q: %v", text, err)
+	}
+	n := &Node{Payload: payload}
+	for _, sub := range []**Node{&n.Left, &n.Right} {
+		if p.expect(')') {
+			return n, nil
+		}
+		if *sub, err = p.node(); err != nil {
+			return nil, err
+		}
+	}
+	if !p.expect(')') {
+		return nil, p.errorf("expected )")
+	}
+	return n, nil
+}
+
+// atom parses a bare or quoted label.
+func (p *sexprParser) atom() (string, error) {
+	p.skipSpace()
+	start := p.pos
+	if p.pos < len(p.s) && p.s[p.pos] == '"' {
+		for p.pos++; p.pos < len(p.s) && p.s[p.pos] != '"'; p.pos++ {
+			if p.s[p.pos] == '\\' {
+				p.pos++
+			}
+		}
+		if p.pos >= len(p.s) {
+			return "", p.errorf("unterminated quoted label")
+		}
+		p.pos++
+		text, err := strconv.Unquote(p.s[start:p.pos])
+		if err != nil {
+			return "", p.errorf("bad quoted label %s", p.s[start:p.pos])
+		}
+		return text, nil
+	}
+	for p.pos < len(p.s) && !unicode.IsSpace(rune(p.s[p.pos])) && p.s[p.pos] != '(' && p.s[p.pos] != ')' {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a label")
+	}
+	return p.s[start:p.pos], nil
+}
@@ -0,0 +1,69 @@
+package btree
+
+import "testing"
+
+func TestSExpr(t *testing.T) {
+	for _, test := range []struct {
+		vals []int
+		want string
+	}{
+		{vals: nil, want: "()"},
+		{vals: []int{1}, want: "(1)"},
+		{vals: []int{2, 1, 4, 3}, want: "(2 (1) (4 (3)))"},
+		{vals: []int{1, 2}, want: "(1 () (2))"},
+	} {
+		b := newIntTree(test.vals...)
+		got := b.SExpr(intLabel)
+		if got != test.want {
+			t.Errorf("SExpr(%v) = %q, want %q", test.vals, got, test.want)
+		}
+		back := New(intLess)
+		if err := back.ReadSExpr(got, parseInt); err != nil {
+			t.Fatalf("ReadSExpr(%q) = %v", got, err)
+		}
+		if !b.StructurallyEqual(back, intEqual) {
+			t.Errorf("ReadSExpr(%q) = %q, want the original tree", got, back.SExpr(intLabel))
+		}
+	}
+}
+
+func TestSExprQuoting(t *testing.T) {
+	b := New(func(a, b *Node) bool { return a.Payload.(string) < b.Payload.(string) })
+	for _, s := range []string{"m", "a b", "(x)", `say "hi"`, ""} {
+		b.Upsert(&Node{Payload: s})
+	}
+	label := func(n *Node) string { return n.Payload.(string) }
+	text := b.SExpr(label)
+
+	back := New(b.Less)
+	if err := back.ReadSExpr(text, func(s string) (interface{}, error) { return s, nil }); err != nil {
+		t.Fatalf("ReadSExpr(%q) = %v", text, err)
+	}
+	if !b.StructurallyEqual(back, func(a, b *Node) bool { return a.Payload == b.Payload }) {
+		t.Errorf("ReadSExpr(%q) = %q, want the original tree", text, back.SExpr(label))
+	}
+}
+
+func TestReadSExprErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"1",
+		"(1",
+		"(1 (2) (3) (4))",
+		"(x)",
+		"(1) (2)",
+		`("1)`,
+		"(1 ( ))x",
+	} {
+		if err := New(intLess).ReadSExpr(s, parseInt); err == nil {
+			t.Errorf("ReadSExpr(%q) succeeds, want error", s)
+		}
+	}
+	b := New(intLess)
+	if err := b.ReadSExpr("  ( 2\n  (1)\n  (3) )  ", parseInt); err != nil {
+		t.Fatalf("ReadSExpr() with whitespace = %v", err)
+	}
+	if got, want := b.SExpr(intLabel), "(2 (1) (3))"; got != want {
+		t.Errorf("ReadSExpr() with whitespace = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package btree
+
+import "fmt"
+
+// ShadowKeyFunc returns the key of a node for the shadow map, see `ShadowKey`. Nodes that are
+// equal according to the `LessFunc` must have equal keys, and unequal nodes unequal keys. The key
+// must be comparable, e.g. a string or an int.
+type ShadowKeyFunc func(n *Node) interface{}
+
+// shadowWrite wraps `end`, which ends the write operation `op`, so that the shadow map is rebuilt
+// from the tree before the operation ends. `Upsert()` and `Delete()` maintain the map themselves.
+func (b *BTree) shadowWrite(op string, end func()) func() {
+	if b.ShadowKey == nil || op == "Upsert" || op == "Delete" {
+		return end
+	}
+	return func() {
+		b.rebuildShadow()
+		end()
+	}
+}
+
+// rebuildShadow sets the shadow map to the live nodes of the tree. It panics when two nodes have
+// the same key.
+func (b *BTree) rebuildShadow() {
+	b.shadow = map[interface{}]*Node{}
+	stack := []*Node{}
+	for n := b.Root; n != nil || len(stack) > 0; n = n.Right {
+		for ; n != nil; n = n.Left {
+			stack = append(stack, n)
+		}
+		n, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if b.dead(n) {
+			continue
+		}
+		key := b.ShadowKey(n)
+		if other, ok := b.shadow[key]; ok {
+			panic(fmt.Sprintf("btree: shadow check: nodes %v and %v are both in the tree, but have "+
+				"the same key %v; is the LessFunc consistent with the ShadowKey?",
+				other.Payload, n.Payload, key))
+		}
+		b.shadow[key] = n
+	}
+}
+
+// shadowed returns the key of `n`, and the live node in the shadow map with that key, or `nil`.
+func (b *BTree) shadowed(n *Node) (key interface{}, live *Node) {
+	key = b.ShadowKey(n)
+	if live = b.shadow[key]; live != nil && b.expired(live) {
+		live = nil
+	}
+	return key, live
+}
+
+// shadowFailed panics with the diagnostics of a divergence between the tree and the shadow map.
+func shadowFailed(op string, n *Node, key interface{}, format string, args ...interface{}) {
+	panic(fmt.Sprintf("btree: shadow check: %v(%v), key %v: %v; is the LessFunc consistent with "+
+		"the ShadowKey?", op, n.Payload, key, fmt.Sprintf(format, args...)))
+}
+
+// shadowUpsert is `upsert()`, whose outcome is checked against the shadow map.
+func (b *BTree) shadowUpsert(n *Node) (intree *Node, inserted bool) {
+	if b.shadow == nil {
+		b.rebuildShadow()
+	}
+	key, live := b.shadowed(n)
+	intree, inserted = b.upsert(n)
+	switch {
+	case live == nil && !inserted:
+		shadowFailed("Upsert", n, key, "the tree holds %v, but the shadow map nothing",
+			intree.Payload)
+	case live != nil && inserted:
+		shadowFailed("Upsert", n, key, "the tree inserted a node, but the shadow map holds %v",
+			live.Payload)
+	case live != nil && live != intree:
+		shadowFailed("Upsert", n, key, "the tree holds %v, but the shadow map %v",
+			intree.Payload, live.Payload)
+	}
+	b.shadow[key] = intree
+	return intree, inserted
+}
+
+// shadowFind is `find()`, whose outcome is checked against the shadow map once there is one.
+func (b *BTree) shadowFind(n *Node) (intree *Node, found bool) {
+	intree, found = b.find(n)
+	if b.shadow == nil {
+		return intree, found
+	}
+	key, live := b.shadowed(n)
+	switch {
+	case live == nil && found:
+		shadowFailed("Find", n, key, "the tree found %v, but the shadow map nothing", intree.Payload)
+	case live != nil && !found:
+		shadowFailed("Find", n, key, "the tree found nothing, but the shadow map %v", live.Payload)
+	case live != intree:
+		shadowFailed("Find", n, key, "the tree found %v, but the shadow map %v",
+			intree.Payload, live.Payload)
+	}
+	return intree, found
+}
+
+// shadowDelete is `delete()`, whose outcome is checked against the shadow map.
+func (b *BTree) shadowDelete(n *Node) (removed *Node, deleted bool) {
+	if b.shadow == nil {
+		b.rebuildShadow()
+	}
+	key, live := b.shadowed(n)
+	removed, deleted = b.delete(n)
+	switch {
+	case live == nil && deleted:
+		shadowFailed("Delete", n, key, "the tree removed %v, but the shadow map holds nothing",
+			removed.Payload)
+	case live != nil && !deleted:
+		shadowFailed("Delete", n, key, "the tree removed nothing, but the shadow map holds %v",
+			live.Payload)
+	case live != removed:
+		shadowFailed("Delete", n, key, "the tree removed %v, but the shadow map holds %v",
+			removed.Payload, live.Payload)
+	}
+	delete(b.shadow, key)
+	return removed, deleted
+}
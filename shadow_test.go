@@ -0,0 +1,82 @@
+package btree
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func intKey(n *Node) interface{} {
+	return n.Payload.(int)
+}
+
+func TestShadowAgrees(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		b := newIntTree(5, 3, 8) // before the shadow map
+		b.ShadowKey = intKey
+		b.LazyDelete = lazy
+		rng := rand.New(rand.NewSource(1))
+		msg := panicOf(func() {
+			for i := 0; i < 5000; i++ {
+				v := rng.Intn(50)
+				switch rng.Intn(5) {
+				case 0, 1:
+					b.Upsert(&Node{Payload: v})
+				case 2:
+					b.Delete(&Node{Payload: v})
+				case 3:
+					b.Find(&Node{Payload: v})
+				default:
+					if rng.Intn(50) == 0 {
+						b.Compact()
+					}
+				}
+			}
+		})
+		if msg != "" {
+			t.Errorf("LazyDelete=%v: panic %q, want none", lazy, msg)
+		}
+	}
+}
+
+func TestShadowCatchesBrokenLess(t *testing.T) {
+	type person struct {
+		name string
+		age  int
+	}
+	// The LessFunc ignores the age, which the key doesn't.
+	b := New(func(a, b *Node) bool { return a.Payload.(person).name < b.Payload.(person).name })
+	b.ShadowKey = func(n *Node) interface{} { return n.Payload }
+	b.Upsert(&Node{Payload: person{name: "John", age: 40}})
+	msg := panicOf(func() { b.Upsert(&Node{Payload: person{name: "John", age: 41}}) })
+	if !strings.HasPrefix(msg, "btree: shadow check: Upsert({John 41}), key {John 41}: the tree "+
+		"holds {John 40}, but the shadow map nothing") {
+		t.Errorf("Upsert() panic = %q, want a shadow check", msg)
+	}
+}
+
+func TestShadowRebuild(t *testing.T) {
+	b := newIntTree(2, 1, 3)
+	b.ShadowKey = intKey
+	b.Upsert(&Node{Payload: 4})
+	// A write other than Upsert() and Delete() rebuilds the shadow map.
+	b.Clear()
+	if msg := panicOf(func() {
+		b.Upsert(&Node{Payload: 2})
+		b.Find(&Node{Payload: 4})
+	}); msg != "" {
+		t.Errorf("after Clear(): panic %q, want none", msg)
+	}
+	// Changing the tree directly isn't seen.
+	b.Root = nil
+	msg := panicOf(func() { b.Find(&Node{Payload: 2}) })
+	if !strings.Contains(msg, "the tree found nothing, but the shadow map 2") {
+		t.Errorf("Find() after changing Root: panic %q, want a shadow check", msg)
+	}
+	// Duplicates are caught when rebuilding.
+	b.Root = &Node{Payload: 1, Right: &Node{Payload: 1}}
+	msg = panicOf(func() { b.Rebalance() })
+	if !strings.Contains(msg, "nodes 1 and 1 are both in the tree") {
+		t.Errorf("Rebalance() of a tree with duplicates: panic %q, want a shadow check", msg)
+	}
+}
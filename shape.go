@@ -0,0 +1,70 @@
+package btree
+
+import "math/bits"
+
+// ShapeStats describes the structure of a tree, as returned by `ShapeStats()`, e.g. for dashboards
+// or for tests that assert on the health of a tree. Depths count the number of steps from the
+// root, which is at depth 0.
+type ShapeStats struct {
+	// Nodes is the number of nodes.
+	Nodes int
+	// Height is the number of levels: 0 for an empty tree, 1 for just a root.
+	Height int
+	// Leaves is the number of nodes without sub-nodes.
+	Leaves int
+	// MinLeafDepth is the depth of the shallowest leaf, and AvgLeafDepth the average depth of the
+	// leaves.
+	MinLeafDepth int
+	AvgLeafDepth float64
+	// DepthHistogram holds, for each depth, the number of nodes at that depth.
+	DepthHistogram []int
+	// Balance is the minimal height for the number of nodes divided by the actual height: 1 for a
+	// balanced tree, approaching 0 for a degenerate chain.
+	Balance float64
+	// MaxImbalance is the largest difference between the heights of the left and right subtree of
+	// any node: at most 1 in a balanced tree.
+	MaxImbalance int
+}
+
+// ShapeStats walks the tree and returns its `ShapeStats`. Nodes that are marked deleted with
+// `LazyDelete` are part of the structure, so they are included. Unlike `Stats()`, this takes time
+// in proportion to the size of the tree.
+func (b *BTree) ShapeStats() ShapeStats {
+	defer b.beginRead("ShapeStats")()
+	return b.shapeStats()
+}
+
+// shapeStats is `ShapeStats()` without the concurrent use check.
+func (b *BTree) shapeStats() ShapeStats {
+	s := ShapeStats{DepthHistogram: []int{}}
+	if b.Root == nil {
+		return s
+	}
+	leafDepths := 0
+	s.MinLeafDepth = -1
+	var walk func(n *Node, depth int) int
+	walk = func(n *Node, depth int) int {
+		if n == nil {
+			return 0
+		}
+		s.Nodes++
+		if depth == len(s.DepthHistogram) {
+			s.DepthHistogram = append(s.DepthHistogram, 0)
+		}
+		s.DepthHistogram[depth]++
+		if n.Left == nil && n.Right == nil {
+			s.Leaves++
+			leafDepths += depth
+			if s.MinLeafDepth < 0 || depth < s.MinLeafDepth {
+				s.MinLeafDepth = depth
+			}
+		}
+		left, right := walk(n.Left, depth+1), walk(n.Right, depth+1)
+		s.MaxImbalance = max(s.MaxImbalance, left-right, right-left)
+		return 1 + max(left, right)
+	}
+	s.Height = walk(b.Root, 0)
+	s.AvgLeafDepth = float64(leafDepths) / float64(s.Leaves)
+	s.Balance = float64(bits.Len(uint(s.Nodes))) / float64(s.Height)
+	return s
+}
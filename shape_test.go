@@ -0,0 +1,48 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShapeStats(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		b    *BTree
+		want ShapeStats
+	}{
+		{
+			desc: "empty",
+			b:    New(intLess),
+			want: ShapeStats{DepthHistogram: []int{}},
+		},
+		{
+			desc: "balanced",
+			b:    newIntTree(4, 2, 6, 1, 3, 5, 7),
+			want: ShapeStats{
+				Nodes: 7, Height: 3, Leaves: 4, MinLeafDepth: 2, AvgLeafDepth: 2,
+				DepthHistogram: []int{1, 2, 4}, Balance: 1, MaxImbalance: 0,
+			},
+		},
+		{
+			desc: "chain",
+			b:    newIntTree(1, 2, 3, 4),
+			want: ShapeStats{
+				Nodes: 4, Height: 4, Leaves: 1, MinLeafDepth: 3, AvgLeafDepth: 3,
+				DepthHistogram: []int{1, 1, 1, 1}, Balance: 0.75, MaxImbalance: 3,
+			},
+		},
+		{
+			desc: "lopsided",
+			b:    newIntTree(3, 2, 4, 1),
+			want: ShapeStats{
+				Nodes: 4, Height: 3, Leaves: 2, MinLeafDepth: 1, AvgLeafDepth: 1.5,
+				DepthHistogram: []int{1, 2, 1}, Balance: 1, MaxImbalance: 1,
+			},
+		},
+	} {
+		if got := test.b.ShapeStats(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: ShapeStats() = %+v, want %+v", test.desc, got, test.want)
+		}
+	}
+}
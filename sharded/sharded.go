@@ -0,0 +1,128 @@
+// Package sharded partitions the nodes of a tree across a number of `btree.SyncTree`s, so that
+// many writers don't contend on one lock. Which shard holds a node is decided by a caller-supplied
+// `ShardFunc`, e.g. a hash of the key, or by key ranges (see `NewRange()`). Walks merge the shards,
+// so they visit the nodes in order, just as for a single tree.
+package sharded
+
+import (
+	"fmt"
+
+	"github.com/KarelKubat/btree"
+)
+
+// ShardFunc returns the shard, in the range 0 up to the number of shards, that holds `n`. Nodes
+// that are equal according to the `btree.LessFunc` must be assigned the same shard.
+type ShardFunc func(n *btree.Node) int
+
+// Tree is a sharded tree. It is safe for concurrent use.
+type Tree struct {
+	less   btree.LessFunc
+	shard  ShardFunc
+	shards []*btree.SyncTree
+}
+
+// New returns an empty `Tree` with `n` shards, that orders its nodes using `less` and assigns
+// them to shards using `shard`.
+func New(less btree.LessFunc, n int, shard ShardFunc) *Tree {
+	t := &Tree{less: less, shard: shard, shards: make([]*btree.SyncTree, n)}
+	for i := range t.shards {
+		t.shards[i] = btree.Synchronized(btree.New(less))
+	}
+	return t
+}
+
+// NewRange returns an empty `Tree` that is sharded by key ranges. The `bounds` must be sorted
+// according to `less`; there is a shard for the nodes below `bounds[0]`, one for the nodes from
+// `bounds[0]` up to (but not including) `bounds[1]`, and so on.
+func NewRange(less btree.LessFunc, bounds []*btree.Node) *Tree {
+	return New(less, len(bounds)+1, func(n *btree.Node) int {
+		lo, hi := 0, len(bounds)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if less(n, bounds[mid]) {
+				hi = mid
+			} else {
+				lo = mid + 1
+			}
+		}
+		return lo
+	})
+}
+
+// Shards returns the number of shards.
+func (t *Tree) Shards() int {
+	return len(t.shards)
+}
+
+// Shard returns the `btree.SyncTree` that holds the nodes like `n`. It panics when the
+// `ShardFunc` returns a shard that is out of range.
+func (t *Tree) Shard(n *btree.Node) *btree.SyncTree {
+	i := t.shard(n)
+	if i < 0 || i >= len(t.shards) {
+		panic(fmt.Sprintf("sharded: shard %d out of range 0..%d", i, len(t.shards)-1))
+	}
+	return t.shards[i]
+}
+
+// Upsert is `btree.SyncTree.Upsert()` on the shard of `n`.
+func (t *Tree) Upsert(n *btree.Node) (intree *btree.Node, inserted bool) {
+	return t.Shard(n).Upsert(n)
+}
+
+// Update is `btree.SyncTree.Update()` on the shard of `n`.
+func (t *Tree) Update(n *btree.Node, fn func(intree *btree.Node, inserted bool)) {
+	t.Shard(n).Update(n, fn)
+}
+
+// Find is `btree.SyncTree.Find()` on the shard of `n`.
+func (t *Tree) Find(n *btree.Node) (intree *btree.Node, found bool) {
+	return t.Shard(n).Find(n)
+}
+
+// Delete is `btree.SyncTree.Delete()` on the shard of `n`.
+func (t *Tree) Delete(n *btree.Node) (removed *btree.Node, deleted bool) {
+	return t.Shard(n).Delete(n)
+}
+
+// DepthFirstInOrder walks all nodes in order. Like `btree.SyncTree.DepthFirstInOrder()`, it walks
+// snapshots of copied nodes, taken shard by shard; it is therefore not consistent across shards.
+func (t *Tree) DepthFirstInOrder(walk btree.WalkFunc) {
+	t.AscendRange(nil, nil, func(n *btree.Node) bool {
+		walk(n)
+		return true
+	})
+}
+
+// AscendRange calls `visit` for the nodes `n` with `from <= n < to` in ascending order, until
+// `visit` returns `false`; see `btree.BTree.AscendRange()`. The visited nodes are copies, as for
+// `DepthFirstInOrder()`.
+func (t *Tree) AscendRange(from, to *btree.Node, visit btree.VisitFunc) {
+	// Snapshot the range of each shard, then merge.
+	runs := make([][]*btree.Node, 0, len(t.shards))
+	for _, s := range t.shards {
+		var run []*btree.Node
+		s.Read(func(b *btree.BTree) {
+			b.AscendRange(from, to, func(n *btree.Node) bool {
+				run = append(run, &btree.Node{Payload: n.Payload})
+				return true
+			})
+		})
+		if len(run) > 0 {
+			runs = append(runs, run)
+		}
+	}
+	for len(runs) > 0 {
+		min := 0
+		for i := 1; i < len(runs); i++ {
+			if t.less(runs[i][0], runs[min][0]) {
+				min = i
+			}
+		}
+		if !visit(runs[min][0]) {
+			return
+		}
+		if runs[min] = runs[min][1:]; len(runs[min]) == 0 {
+			runs = append(runs[:min], runs[min+1:]...)
+		}
+	}
+}
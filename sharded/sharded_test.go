@@ -0,0 +1,77 @@
+package sharded
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/KarelKubat/btree"
+)
+
+func intLess(a, b *btree.Node) bool {
+	return a.Payload.(int) < b.Payload.(int)
+}
+
+func ints(t *Tree) []int {
+	out := []int{}
+	t.DepthFirstInOrder(func(n *btree.Node) { out = append(out, n.Payload.(int)) })
+	return out
+}
+
+func TestTree(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		tree *Tree
+	}{
+		{desc: "hashed", tree: New(intLess, 4, func(n *btree.Node) int { return n.Payload.(int) % 4 })},
+		{desc: "ranges", tree: NewRange(intLess, []*btree.Node{{Payload: 10}, {Payload: 20}})},
+	} {
+		tr := test.tree
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for v := g; v < 30; v += 4 {
+					tr.Upsert(&btree.Node{Payload: v})
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		want := make([]int, 30)
+		for i := range want {
+			want[i] = i
+		}
+		if got := ints(tr); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: DepthFirstInOrder() = %v, want %v", test.desc, got, want)
+		}
+		if _, found := tr.Find(&btree.Node{Payload: 17}); !found {
+			t.Errorf("%s: Find(17) = not found", test.desc)
+		}
+		if _, deleted := tr.Delete(&btree.Node{Payload: 17}); !deleted {
+			t.Errorf("%s: Delete(17) = not deleted", test.desc)
+		}
+		var got []int
+		tr.AscendRange(&btree.Node{Payload: 15}, &btree.Node{Payload: 25}, func(n *btree.Node) bool {
+			got = append(got, n.Payload.(int))
+			return len(got) < 5
+		})
+		if want := []int{15, 16, 18, 19, 20}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: AscendRange(15, 25) = %v, want %v", test.desc, got, want)
+		}
+	}
+}
+
+func TestNewRangeShards(t *testing.T) {
+	tr := NewRange(intLess, []*btree.Node{{Payload: 10}, {Payload: 20}})
+	for _, test := range []struct{ val, shard int }{{-5, 0}, {9, 0}, {10, 1}, {19, 1}, {20, 2}, {99, 2}} {
+		want := tr.shards[test.shard]
+		if got := tr.Shard(&btree.Node{Payload: test.val}); got != want {
+			t.Errorf("Shard(%v) is not shard %v", test.val, test.shard)
+		}
+	}
+	if got := tr.Shards(); got != 3 {
+		t.Errorf("Shards() = %v, want 3", got)
+	}
+}
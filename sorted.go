@@ -0,0 +1,120 @@
+package btree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// EmitSorted writes the payloads of the tree to `w`, in the order of `DepthFirstInOrder()`, but
+// without the structure of the tree. Each payload is encoded using `codec` and written as a varint
+// length followed by the encoded bytes. Such sorted streams suit external merge sorts and
+// map-reduce style workflows, and can be loaded back by `LoadSorted()`.
+func (b *BTree) EmitSorted(w io.Writer, codec PayloadCodec) error {
+	bw := bufio.NewWriter(w)
+	var it inorderIter
+	it.init(b)
+	for n := it.next(); n != nil; n = it.next() {
+		if err := writeSorted(bw, codec, n.Payload); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadSorted reads a stream of payloads in the format of `EmitSorted()` from `r`, and adds them to
+// the tree using `BulkUpsert()`. When the stream is sorted according to the tree's `LessFunc` and
+// the tree is empty, the result is a balanced tree.
+func (b *BTree) LoadSorted(r io.Reader, codec PayloadCodec) error {
+	br := bufio.NewReader(r)
+	var nodes []*Node
+	for {
+		payload, err := readSorted(br, codec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, &Node{Payload: payload})
+	}
+	b.BulkUpsert(nodes)
+	return nil
+}
+
+// readSorted reads the next payload of a stream in the format of `EmitSorted()`. It returns
+// `io.EOF` at the end of the stream.
+func readSorted(br *bufio.Reader, codec PayloadCodec) (payload interface{}, err error) {
+	size, err := binary.ReadUvarint(br)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if size > maxBinPayload {
+		return nil, fmt.Errorf("btree: corrupt sorted stream, payload size %v too large", size)
+	}
+	data, err := readPayload(br, size)
+	if err != nil {
+		return nil, err
+	}
+	if payload, err = codec.DecodePayload(data); err != nil {
+		return nil, fmt.Errorf("btree: cannot decode payload: %v", err)
+	}
+	return payload, nil
+}
+
+// writeSorted writes a payload to a stream in the format of `EmitSorted()`.
+func writeSorted(bw *bufio.Writer, codec PayloadCodec, payload interface{}) error {
+	data, err := codec.EncodePayload(payload)
+	if err != nil {
+		return fmt.Errorf("btree: cannot encode payload %v: %v", payload, err)
+	}
+	var lenbuf [binary.MaxVarintLen64]byte
+	if _, err := bw.Write(lenbuf[:binary.PutUvarint(lenbuf[:], uint64(len(data)))]); err != nil {
+		return err
+	}
+	_, err = bw.Write(data)
+	return err
+}
+
+// MergeSorted merges streams in the format of `EmitSorted()`, each sorted according to `less`,
+// into one sorted stream that it writes to `w`. The streams are read in step, using a
+// `Tournament`, so that they needn't fit in memory, as in the merge phase of an external sort.
+// Equal payloads are all kept, those of earlier streams first.
+func MergeSorted(w io.Writer, codec PayloadCodec, less LessFunc, streams ...io.Reader) error {
+	readers := make([]*bufio.Reader, len(streams))
+	heads := make([]*Node, len(streams))
+	next := func(i int) (*Node, error) {
+		payload, err := readSorted(readers[i], codec)
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Payload: payload}, nil
+	}
+	for i, r := range streams {
+		readers[i] = bufio.NewReader(r)
+		var err error
+		if heads[i], err = next(i); err != nil {
+			return err
+		}
+	}
+	bw := bufio.NewWriter(w)
+	t := NewTournament(less, heads)
+	for n, i := t.Winner(); n != nil; n, i = t.Winner() {
+		if err := writeSorted(bw, codec, n.Payload); err != nil {
+			return err
+		}
+		head, err := next(i)
+		if err != nil {
+			return err
+		}
+		t.Replay(i, head)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,49 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+)
+
+func TestEmitLoadSorted(t *testing.T) {
+	b := newIntTree(1, 2, 3, 4, 5, 6, 7) // degenerate chain
+	var buf bytes.Buffer
+	if err := b.EmitSorted(&buf, intCodec{}); err != nil {
+		t.Fatalf("EmitSorted() = %v", err)
+	}
+	data := buf.Bytes()
+
+	back := New(intLess)
+	if err := back.LoadSorted(bytes.NewReader(data), intCodec{}); err != nil {
+		t.Fatalf("LoadSorted() = %v", err)
+	}
+	if !b.Equal(back, intEqual) {
+		t.Errorf("LoadSorted(EmitSorted()) = %v, want %v", inOrderInts(back), inOrderInts(b))
+	}
+	if got, want := back.SExpr(intLabel), "(4 (2 (1) (3)) (6 (5) (7)))"; got != want {
+		t.Errorf("LoadSorted() = %v, want balanced %v", got, want)
+	}
+
+	if err := New(intLess).LoadSorted(bytes.NewReader(data[:len(data)-1]), intCodec{}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("LoadSorted() of truncated stream = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestLoadSortedHugePayloadSize(t *testing.T) {
+	// A payload that claims to be 1GB but holds 3 bytes.
+	data := append(binary.AppendUvarint(nil, maxBinPayload), "123"...)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	err := New(intLess).LoadSorted(bytes.NewReader(data), intCodec{})
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("LoadSorted() = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<20 {
+		t.Errorf("LoadSorted() allocated %v bytes for a 3-byte payload", alloc)
+	}
+}
@@ -0,0 +1,92 @@
+package btree
+
+import "sync/atomic"
+
+// Stats holds counters of the work that a tree did since `EnableStats()` or `ResetStats()`. They
+// quantify e.g. the effect of a comparator or of rebalancing.
+type Stats struct {
+	// Comparisons is the number of `LessFunc` calls.
+	Comparisons uint64
+	// Visited is the number of nodes that lookups, insertions, deletions and traversals visited.
+	Visited uint64
+	// MaxDepth is the largest number of nodes that a single lookup, insertion or deletion visited.
+	MaxDepth int
+	// Rotations is the number of rotations that rebalancing did.
+	Rotations uint64
+}
+
+// counters are the `Stats` of a tree as it collects them. They are updated atomically, so that
+// concurrent readers, e.g. of a `SyncTree`, can count.
+type counters struct {
+	comparisons, visited, rotations atomic.Uint64
+	maxDepth                        atomic.Int64
+}
+
+// EnableStats starts collecting `Stats`. It wraps the tree's `Less` to count comparisons, so it
+// must be called after `Less` is set. Collecting costs a little time for each operation. The
+// counters are safe for concurrent use.
+func (b *BTree) EnableStats() {
+	if b.stats != nil {
+		return
+	}
+	s := &counters{}
+	less := b.Less
+	b.Less = func(x, y *Node) bool {
+		s.comparisons.Add(1)
+		return less(x, y)
+	}
+	b.stats = s
+}
+
+// Stats returns the counters that were collected so far. They are zero unless `EnableStats()` was
+// called.
+func (b *BTree) Stats() Stats {
+	if b.stats == nil {
+		return Stats{}
+	}
+	s := b.stats
+	return Stats{
+		Comparisons: s.comparisons.Load(),
+		Visited:     s.visited.Load(),
+		MaxDepth:    int(s.maxDepth.Load()),
+		Rotations:   s.rotations.Load(),
+	}
+}
+
+// ResetStats zeroes the collected counters.
+func (b *BTree) ResetStats() {
+	if s := b.stats; s != nil {
+		s.comparisons.Store(0)
+		s.visited.Store(0)
+		s.maxDepth.Store(0)
+		s.rotations.Store(0)
+	}
+}
+
+// descent records a lookup, insertion or deletion that visited `nodes` nodes.
+func (s *counters) descent(nodes int) {
+	if s == nil {
+		return
+	}
+	s.visited.Add(uint64(nodes))
+	for {
+		cur := s.maxDepth.Load()
+		if int64(nodes) <= cur || s.maxDepth.CompareAndSwap(cur, int64(nodes)) {
+			return
+		}
+	}
+}
+
+// visit records a node that a traversal visited.
+func (s *counters) visit() {
+	if s != nil {
+		s.visited.Add(1)
+	}
+}
+
+// rotate records `n` rotations.
+func (s *counters) rotate(n int) {
+	if s != nil {
+		s.rotations.Add(uint64(n))
+	}
+}
@@ -0,0 +1,104 @@
+package btree
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	b := New(intLess)
+	if got := b.Stats(); got != (Stats{}) {
+		t.Errorf("Stats() before EnableStats() = %+v, want zero", got)
+	}
+	b.EnableStats()
+	b.EnableStats() // no double counting
+	for _, v := range []int{4, 2, 6, 1, 3, 5, 7} {
+		b.Upsert(&Node{Payload: v})
+	}
+	// Inserting the root visits nothing, 2 and 6 visit the root, the others visit two nodes.
+	got := b.Stats()
+	if got.MaxDepth != 2 {
+		t.Errorf("MaxDepth = %v, want 2", got.MaxDepth)
+	}
+	if got.Visited != 2*1+4*2 {
+		t.Errorf("Visited = %v, want %v", got.Visited, 2*1+4*2)
+	}
+	if got.Comparisons == 0 {
+		t.Errorf("Comparisons = 0, want > 0")
+	}
+
+	b.ResetStats()
+	b.Find(&Node{Payload: 4})
+	if got := b.Stats(); got.Comparisons != 2 || got.Visited != 1 {
+		t.Errorf("Stats() after Find(root) = %+v, want 2 comparisons and 1 visit", got)
+	}
+	b.ResetStats()
+	b.Find(&Node{Payload: 8})
+	if got := b.Stats(); got.Visited != 3 || got.MaxDepth != 3 {
+		t.Errorf("Stats() after a missed Find() = %+v, want 3 visited, depth 3", got)
+	}
+	b.ResetStats()
+	b.DepthFirstInOrder(func(*Node) {})
+	if got := b.Stats(); got.Visited != 7 || got.Comparisons != 0 {
+		t.Errorf("Stats() after DepthFirstInOrder() = %+v, want 7 visited", got)
+	}
+	b.ResetStats()
+	b.Delete(&Node{Payload: 7})
+	if got := b.Stats(); got.Visited != 3 {
+		t.Errorf("Stats() after Delete() = %+v, want 3 visited", got)
+	}
+}
+
+func TestStatsRotations(t *testing.T) {
+	b := New(intLess)
+	b.EnableStats()
+	b.Rebalance()
+	if got := b.Stats().Rotations; got != 0 {
+		t.Errorf("Rotations of an empty tree = %v, want 0", got)
+	}
+	for i := 0; i < 100; i++ {
+		b.Upsert(&Node{Payload: i})
+	}
+	b.Rebalance()
+	if got := b.Stats().Rotations; got == 0 {
+		t.Errorf("Rotations after rebalancing a chain = 0, want > 0")
+	}
+	b.ResetStats()
+	b.Rebalance()
+	balanced := b.Stats().Rotations
+	b.ResetStats()
+	for i := 100; i < 200; i++ {
+		b.Upsert(&Node{Payload: i})
+	}
+	b.ResetStats()
+	b.Rebalance()
+	if got := b.Stats().Rotations; got <= balanced {
+		t.Errorf("Rotations for a skewed tree = %v, want more than %v for a balanced one", got, balanced)
+	}
+}
+
+func TestStatsConcurrent(t *testing.T) {
+	b := newIntTree(4, 2, 6, 1, 3, 5, 7)
+	b.EnableStats()
+	s := Synchronized(b)
+	const readers, finds = 8, 100
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < finds; j++ {
+				s.Find(&Node{Payload: 1 + j%8})
+			}
+		}()
+	}
+	wg.Wait()
+	// Finding 1..7 visits up to 3 nodes, and so does missing 8.
+	got := b.Stats()
+	if got.MaxDepth != 3 {
+		t.Errorf("MaxDepth = %v, want 3", got.MaxDepth)
+	}
+	if got.Visited < readers*finds || got.Comparisons < readers*finds {
+		t.Errorf("Stats() = %+v, want at least %v visits and comparisons", got, readers*finds)
+	}
+}
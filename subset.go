@@ -0,0 +1,20 @@
+package btree
+
+// IsSubsetOf returns `true` when every node of `b` is also present in `other`. Nodes are matched
+// using the `LessFunc` of `b`: two nodes are the same when neither is less than the other. Both
+// trees are walked side by side, so that the check takes O(n+m) steps.
+func (b *BTree) IsSubsetOf(other *BTree) bool {
+	var mine, theirs inorderIter
+	mine.init(b)
+	theirs.init(other)
+	o := theirs.next()
+	for n := mine.next(); n != nil; n = mine.next() {
+		for o != nil && b.Less(o, n) {
+			o = theirs.next()
+		}
+		if o == nil || b.Less(n, o) {
+			return false
+		}
+	}
+	return true
+}
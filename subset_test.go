@@ -0,0 +1,23 @@
+package btree
+
+import "testing"
+
+func TestIsSubsetOf(t *testing.T) {
+	for _, test := range []struct {
+		a, b []int
+		want bool
+	}{
+		{a: nil, b: nil, want: true},
+		{a: nil, b: []int{1}, want: true},
+		{a: []int{1}, b: nil, want: false},
+		{a: []int{3, 1}, b: []int{1, 2, 3}, want: true},
+		{a: []int{1, 2, 3}, b: []int{3, 2, 1}, want: true},
+		{a: []int{1, 4}, b: []int{1, 2, 3}, want: false},
+		{a: []int{0, 2}, b: []int{1, 2, 3}, want: false},
+	} {
+		a, b := newIntTree(test.a...), newIntTree(test.b...)
+		if got := a.IsSubsetOf(b); got != test.want {
+			t.Errorf("%v.IsSubsetOf(%v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
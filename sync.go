@@ -0,0 +1,172 @@
+package btree
+
+import "sync"
+
+// SyncTree wraps a `BTree` so that it can be used by concurrent goroutines. All operations are
+// guarded by a `sync.RWMutex`: lookups and walks share the lock, mutations lock exclusively.
+//
+// Nodes returned by e.g. `Upsert()` or `Find()` point into the tree; changing their payloads is
+// only safe within `Update()` or `Write()`.
+type SyncTree struct {
+	mu       sync.RWMutex
+	t        *BTree
+	version  uint64
+	watchers map[*watcher]struct{}
+}
+
+// Synchronized returns a `SyncTree` that guards `t`. From then on, `t` should only be accessed
+// via the `SyncTree`.
+func Synchronized(t *BTree) *SyncTree {
+	return &SyncTree{t: t}
+}
+
+// Read calls `fn` with the wrapped tree while holding a read lock. `fn` must not modify the tree.
+func (s *SyncTree) Read(fn func(t *BTree)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.t)
+}
+
+// Write calls `fn` with the wrapped tree while holding the write lock. Changes made by `fn` are
+// not reported to watchers (see `Watch()`).
+func (s *SyncTree) Write(fn func(t *BTree)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.t)
+	s.version++
+}
+
+// Upsert is the synchronized version of `BTree.Upsert()`.
+func (s *SyncTree) Upsert(n *Node) (intree *Node, inserted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.upsert(n)
+}
+
+// upsert is `Upsert()` with the write lock held.
+func (s *SyncTree) upsert(n *Node) (intree *Node, inserted bool) {
+	intree, inserted = s.t.Upsert(n)
+	if inserted {
+		s.version++
+		s.notify(Inserted, intree)
+	}
+	return intree, inserted
+}
+
+// Update upserts `n` and calls `fn` with the outcome while still holding the write lock, so that
+// the payload in the tree can be updated safely. E.g., counting occurrences becomes:
+//
+//	s.Update(&btree.Node{Payload: &stringcount{str: word}}, func(intree *btree.Node, _ bool) {
+//		intree.Payload.(*stringcount).count++
+//	})
+func (s *SyncTree) Update(n *Node, fn func(intree *Node, inserted bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.update(n, fn)
+}
+
+// update is `Update()` with the write lock held.
+func (s *SyncTree) update(n *Node, fn func(intree *Node, inserted bool)) {
+	intree, inserted := s.t.Upsert(n)
+	fn(intree, inserted)
+	s.version++
+	if inserted {
+		s.notify(Inserted, intree)
+	} else {
+		s.notify(Updated, intree)
+	}
+}
+
+// BulkUpsert is the synchronized version of `BTree.BulkUpsert()`.
+func (s *SyncTree) BulkUpsert(nodes []*Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	if len(s.watchers) == 0 {
+		s.t.BulkUpsert(nodes)
+		return
+	}
+	batch := append([]*Node(nil), nodes...)
+	s.t.BulkUpsert(nodes)
+	for _, n := range batch {
+		// `n` was inserted when the tree holds that very node.
+		if intree, _ := s.t.Find(n); intree == n {
+			s.notify(Inserted, n)
+		}
+	}
+}
+
+// Find is the synchronized version of `BTree.Find()`.
+func (s *SyncTree) Find(n *Node) (intree *Node, found bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Find(n)
+}
+
+// Delete is the synchronized version of `BTree.Delete()`.
+func (s *SyncTree) Delete(n *Node) (removed *Node, deleted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delete(n)
+}
+
+// delete is `Delete()` with the write lock held.
+func (s *SyncTree) delete(n *Node) (removed *Node, deleted bool) {
+	removed, deleted = s.t.Delete(n)
+	if deleted {
+		s.version++
+		s.notify(Deleted, removed)
+	}
+	return removed, deleted
+}
+
+// Min is the synchronized version of `BTree.Min()`.
+func (s *SyncTree) Min() *Node {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Min()
+}
+
+// Max is the synchronized version of `BTree.Max()`.
+func (s *SyncTree) Max() *Node {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Max()
+}
+
+// Clone is the synchronized version of `BTree.Clone()`. The clone is a plain, unsynchronized
+// `BTree`.
+func (s *SyncTree) Clone(copyPayload CopyFunc) *BTree {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Clone(copyPayload)
+}
+
+// snapshot returns fresh nodes holding the payloads of the tree, in order.
+func (s *SyncTree) snapshot() []*Node {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var nodes []*Node
+	s.t.DepthFirstInOrder(func(n *Node) {
+		nodes = append(nodes, &Node{Payload: n.Payload})
+	})
+	return nodes
+}
+
+// DepthFirstInOrder walks a snapshot of the tree, taken under the read lock. Since `walk` is
+// called after the lock is released, it may use the `SyncTree` itself, and concurrent writers are
+// not held up by slow callbacks. The nodes that `walk` receives are copies: they share the payload
+// with the tree but have no sub-nodes.
+func (s *SyncTree) DepthFirstInOrder(walk WalkFunc) {
+	for _, n := range s.snapshot() {
+		walk(n)
+	}
+}
+
+// DepthFirstReverse is like `DepthFirstInOrder()`, but walks in reverse order.
+func (s *SyncTree) DepthFirstReverse(walk WalkFunc) {
+	nodes := s.snapshot()
+	for i := len(nodes) - 1; i >= 0; i-- {
+		walk(nodes[i])
+	}
+}
@@ -0,0 +1,70 @@
+package btree
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSynchronized(t *testing.T) {
+	type counter struct {
+		key, count int
+	}
+	s := Synchronized(New(func(a, b *Node) bool { return a.Payload.(*counter).key < b.Payload.(*counter).key }))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				s.Update(&Node{Payload: &counter{key: i % 10}}, func(intree *Node, _ bool) {
+					intree.Payload.(*counter).count++
+				})
+				s.Find(&Node{Payload: &counter{key: i % 7}})
+				s.DepthFirstInOrder(func(*Node) {})
+			}
+		}()
+	}
+	wg.Wait()
+
+	got := []int{}
+	s.DepthFirstInOrder(func(n *Node) {
+		got = append(got, n.Payload.(*counter).count)
+		// Using the tree from within a walk must not deadlock.
+		s.Find(n)
+	})
+	want := []int{800, 800, 800, 800, 800, 800, 800, 800, 800, 800}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("counts = %v, want %v", got, want)
+	}
+}
+
+func TestSynchronizedOperations(t *testing.T) {
+	s := Synchronized(New(intLess))
+	s.BulkUpsert(intNodes(1, 2, 3))
+	s.Upsert(&Node{Payload: 4})
+	if _, deleted := s.Delete(&Node{Payload: 2}); !deleted {
+		t.Errorf("Delete(2) = not deleted")
+	}
+	if _, found := s.Find(&Node{Payload: 2}); found {
+		t.Errorf("Find(2) after Delete(2) = found")
+	}
+	if s.Min().Payload != 1 || s.Max().Payload != 4 {
+		t.Errorf("Min(), Max() = %v, %v, want 1, 4", s.Min().Payload, s.Max().Payload)
+	}
+	got := []int{}
+	s.DepthFirstReverse(func(n *Node) { got = append(got, n.Payload.(int)) })
+	if want := []int{4, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DepthFirstReverse() = %v, want %v", got, want)
+	}
+	if c := s.Clone(nil); !reflect.DeepEqual(inOrderInts(c), []int{1, 3, 4}) {
+		t.Errorf("Clone() = %v, want [1 3 4]", inOrderInts(c))
+	}
+	s.Write(func(b *BTree) { b.Root = nil })
+	s.Read(func(b *BTree) {
+		if b.Root != nil {
+			t.Errorf("Write() didn't clear the tree")
+		}
+	})
+}
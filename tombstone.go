@@ -0,0 +1,65 @@
+package btree
+
+// dead returns `true` when `n` is marked deleted, see `LazyDelete`.
+func (b *BTree) dead(n *Node) bool {
+	if len(b.tombstones) == 0 {
+		return false
+	}
+	_, ok := b.tombstones[n]
+	return ok
+}
+
+// bury marks `n` deleted. It returns `false` when `n` already was.
+func (b *BTree) bury(n *Node) bool {
+	if b.dead(n) {
+		return false
+	}
+	if b.tombstones == nil {
+		b.tombstones = map[*Node]struct{}{}
+	}
+	b.tombstones[n] = struct{}{}
+	return true
+}
+
+// Tombstones returns the number of nodes that are marked deleted but are still linked into the
+// tree.
+func (b *BTree) Tombstones() int {
+	return len(b.tombstones)
+}
+
+// Compact physically removes the nodes that `Delete()` marked deleted while `LazyDelete` was set,
+// and returns how many there were. The remaining nodes are relinked into a balanced tree in one
+// pass. When the tree has a `Pool`, the removed nodes are returned to it.
+func (b *BTree) Compact() int {
+	defer b.beginWrite("Compact")()
+	dead := len(b.tombstones)
+	if dead == 0 {
+		return 0
+	}
+	b.ResetFinger()
+	nodes := []*Node{}
+	var it inorderIter
+	for it.initLinked(b); ; {
+		n := it.next()
+		if n == nil {
+			break
+		}
+		nodes = append(nodes, n)
+	}
+	b.Root = buildBalanced(nodes)
+	b.dropTombstones()
+	b.logRemoved("Compact", dead)
+	return dead
+}
+
+// dropTombstones forgets the nodes that are marked deleted, after they were unlinked. When the
+// tree has a `Pool`, they are returned to it.
+func (b *BTree) dropTombstones() {
+	if b.Pool != nil {
+		for n := range b.tombstones {
+			n.Left, n.Right = nil, nil
+			b.Pool.put(n)
+		}
+	}
+	b.tombstones = nil
+}
@@ -0,0 +1,145 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLazyDelete(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	b := New(intLess)
+	b.LazyDelete = true
+	want := map[int]bool{}
+	for i := 0; i < 5000; i++ {
+		v := r.Intn(200)
+		switch op := r.Intn(20); {
+		case op < 8:
+			if _, inserted := b.Upsert(&Node{Payload: v}); inserted == want[v] {
+				t.Fatalf("op %v: Upsert(%v) = %v, want %v", i, v, inserted, !want[v])
+			}
+			want[v] = true
+		case op < 12:
+			if _, found := b.Find(&Node{Payload: v}); found != want[v] {
+				t.Fatalf("op %v: Find(%v) = %v, want %v", i, v, found, want[v])
+			}
+		case op < 17:
+			if _, deleted := b.Delete(&Node{Payload: v}); deleted != want[v] {
+				t.Fatalf("op %v: Delete(%v) = %v, want %v", i, v, deleted, want[v])
+			}
+			delete(want, v)
+		case op < 18:
+			b.BulkUpsert(intNodes(v, v+1, v+2))
+			want[v], want[v+1], want[v+2] = true, true, true
+		default:
+			b.Compact()
+			if b.Tombstones() != 0 {
+				t.Fatalf("op %v: Tombstones() = %v after Compact(), want 0", i, b.Tombstones())
+			}
+		}
+	}
+	var vals []int
+	for v := range want {
+		vals = append(vals, v)
+	}
+	sort.Ints(vals)
+	if got := inOrderInts(b); !reflect.DeepEqual(got, vals) {
+		t.Errorf("tree = %v, want %v", got, vals)
+	}
+}
+
+func TestLazyDeleteSkipsTombstones(t *testing.T) {
+	b := newIntTree(5, 3, 8, 1, 4, 7, 9)
+	b.LazyDelete = true
+	for _, v := range []int{1, 5, 9} {
+		b.Delete(&Node{Payload: v})
+	}
+	want := []int{3, 4, 7, 8}
+	if got := inOrderInts(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("DepthFirstInOrder() = %v, want %v", got, want)
+	}
+	var reverse []int
+	b.DepthFirstReverse(func(n *Node) { reverse = append(reverse, n.Payload.(int)) })
+	if w := []int{8, 7, 4, 3}; !reflect.DeepEqual(reverse, w) {
+		t.Errorf("DepthFirstReverse() = %v, want %v", reverse, w)
+	}
+	var walked []int
+	NewWalker(b).DepthFirstInOrder(b, func(n *Node) { walked = append(walked, n.Payload.(int)) })
+	if !reflect.DeepEqual(walked, want) {
+		t.Errorf("Walker.DepthFirstInOrder() = %v, want %v", walked, want)
+	}
+	var ranged []int
+	b.AscendRange(nil, nil, func(n *Node) bool { ranged = append(ranged, n.Payload.(int)); return true })
+	if !reflect.DeepEqual(ranged, want) {
+		t.Errorf("AscendRange() = %v, want %v", ranged, want)
+	}
+	if got := b.Min().Payload; got != 3 {
+		t.Errorf("Min() = %v, want 3", got)
+	}
+	if got := b.Max().Payload; got != 8 {
+		t.Errorf("Max() = %v, want 8", got)
+	}
+	if !b.Equal(newIntTree(want...), intEqual) {
+		t.Errorf("Equal() = false, want true")
+	}
+	if _, deleted := b.Delete(&Node{Payload: 5}); deleted {
+		t.Errorf("Delete(5) of a tombstone = true, want false")
+	}
+}
+
+func TestLazyDeleteRevives(t *testing.T) {
+	b := New(kvLess)
+	b.LazyDelete = true
+	b.Upsert(&Node{Payload: kv{"a", "old"}})
+	b.Delete(&Node{Payload: kv{"a", ""}})
+	if intree, inserted := b.Upsert(&Node{Payload: kv{"a", "new"}}); !inserted || intree.Payload.(kv).val != "new" {
+		t.Errorf("Upsert() of a tombstone = %v, %v, want a new node", intree.Payload, inserted)
+	}
+	if b.Tombstones() != 0 {
+		t.Errorf("Tombstones() = %v, want 0", b.Tombstones())
+	}
+}
+
+func TestCompact(t *testing.T) {
+	b := New(intLess)
+	for i := 0; i < 1000; i++ {
+		b.Upsert(&Node{Payload: i})
+	}
+	b.LazyDelete = true
+	for i := 0; i < 1000; i += 2 {
+		b.Delete(&Node{Payload: i})
+	}
+	if got := b.Tombstones(); got != 500 {
+		t.Fatalf("Tombstones() = %v, want 500", got)
+	}
+	if got := b.Compact(); got != 500 {
+		t.Errorf("Compact() = %v, want 500", got)
+	}
+	if got := b.Compact(); got != 0 {
+		t.Errorf("second Compact() = %v, want 0", got)
+	}
+	got := inOrderInts(b)
+	if len(got) != 500 || got[0] != 1 || got[499] != 999 {
+		t.Errorf("tree after Compact() = %v...", got[:5])
+	}
+	if h := height(b.Root); h > 9 {
+		t.Errorf("height after Compact() = %v, want at most 9", h)
+	}
+}
+
+func TestLazyDeleteEagerDeleteOfTombstone(t *testing.T) {
+	b := newIntTree(2, 1, 3)
+	b.LazyDelete = true
+	b.Delete(&Node{Payload: 1})
+	b.LazyDelete = false
+	if _, deleted := b.Delete(&Node{Payload: 1}); deleted {
+		t.Errorf("Delete(1) of a tombstone = true, want false")
+	}
+	if b.Tombstones() != 0 {
+		t.Errorf("Tombstones() = %v, want 0", b.Tombstones())
+	}
+	if got, want := inOrderInts(b), []int{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tree = %v, want %v", got, want)
+	}
+}
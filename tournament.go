@@ -0,0 +1,87 @@
+package btree
+
+// Tournament is a tournament tree (a loser tree) for k-way merging: it holds the current head node
+// of each of k sorted streams, and determines the smallest of them, the winner, in O(log k)
+// comparisons after each change rather than in k. The inner nodes of the tournament remember the
+// loser of the match between their sub-trees, so that replaying after the winner's stream moves on
+// only takes the matches on the path from that stream to the top.
+//
+// Ties are won by the stream with the lower index, so that merges are stable.
+type Tournament struct {
+	less  LessFunc
+	heads []*Node
+	// losers holds the inner nodes, in the layout of a heap: the sub-nodes of `losers[i]` are at
+	// `2i` and `2i+1`, where the positions from `len(heads)` on are those of the streams. Each holds
+	// the index of the stream that lost the match there; position 0 is unused.
+	losers []int
+	winner int
+}
+
+// NewTournament returns a `Tournament` of k streams, whose heads are `heads`, compared using
+// `less`. The head of a stream that is exhausted is `nil`.
+func NewTournament(less LessFunc, heads []*Node) *Tournament {
+	k := len(heads)
+	t := &Tournament{
+		less:   less,
+		heads:  append([]*Node(nil), heads...),
+		losers: make([]int, k),
+		winner: -1,
+	}
+	if k == 0 {
+		return t
+	}
+	// Play all matches bottom-up, keeping the winners of the sub-trees for the next level.
+	winners := make([]int, 2*k)
+	for i := range heads {
+		winners[k+i] = i
+	}
+	for j := k - 1; j > 0; j-- {
+		a, b := winners[2*j], winners[2*j+1]
+		if t.beats(a, b) {
+			winners[j], t.losers[j] = a, b
+		} else {
+			winners[j], t.losers[j] = b, a
+		}
+	}
+	t.winner = winners[1]
+	return t
+}
+
+// beats returns `true` when the head of stream `a` wins from that of stream `b`.
+func (t *Tournament) beats(a, b int) bool {
+	ha, hb := t.heads[a], t.heads[b]
+	switch {
+	case ha == nil || hb == nil:
+		// An exhausted stream always loses.
+		return hb == nil && (ha != nil || a < b)
+	case t.less(ha, hb):
+		return true
+	case t.less(hb, ha):
+		return false
+	}
+	return a < b
+}
+
+// Winner returns the smallest head, and the index of its stream. It returns `nil` and -1 when all
+// streams are exhausted.
+func (t *Tournament) Winner() (n *Node, stream int) {
+	if t.winner < 0 || t.heads[t.winner] == nil {
+		return nil, -1
+	}
+	return t.heads[t.winner], t.winner
+}
+
+// Replay sets the head of stream `stream` to `head`, which is `nil` when the stream is exhausted,
+// and replays the matches that it takes part in. `stream` must be that of the winner, which moves
+// on to its next node.
+func (t *Tournament) Replay(stream int, head *Node) {
+	t.heads[stream] = head
+	k := len(t.heads)
+	w := stream
+	for j := (k + stream) / 2; j > 0; j /= 2 {
+		if t.beats(t.losers[j], w) {
+			t.losers[j], w = w, t.losers[j]
+		}
+	}
+	t.winner = w
+}
@@ -0,0 +1,87 @@
+package btree
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestTournament(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for k := 0; k < 10; k++ {
+		streams := make([][]int, k)
+		want := []int{}
+		for i := range streams {
+			for j := r.Intn(20); j > 0; j-- {
+				streams[i] = append(streams[i], r.Intn(50))
+			}
+			sort.Ints(streams[i])
+			want = append(want, streams[i]...)
+		}
+		sort.Ints(want)
+
+		heads := make([]*Node, k)
+		for i, s := range streams {
+			if len(s) > 0 {
+				heads[i] = &Node{Payload: s[0]}
+			}
+		}
+		tr := NewTournament(intLess, heads)
+		got := []int{}
+		for n, i := tr.Winner(); n != nil; n, i = tr.Winner() {
+			got = append(got, n.Payload.(int))
+			streams[i] = streams[i][1:]
+			var head *Node
+			if len(streams[i]) > 0 {
+				head = &Node{Payload: streams[i][0]}
+			}
+			tr.Replay(i, head)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("merge of %v streams = %v, want %v", k, got, want)
+		}
+	}
+}
+
+func TestTournamentIsStable(t *testing.T) {
+	// All heads are equal; they must win in the order of their streams.
+	heads := []*Node{{Payload: 1}, {Payload: 1}, {Payload: 1}}
+	tr := NewTournament(intLess, heads)
+	order := []int{}
+	for n, i := tr.Winner(); n != nil; n, i = tr.Winner() {
+		order = append(order, i)
+		tr.Replay(i, nil)
+	}
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order of winners = %v, want %v", order, want)
+	}
+}
+
+func TestMergeSorted(t *testing.T) {
+	streams := []io.Reader{}
+	for _, vals := range [][]int{{1, 4, 7}, {2, 5, 8}, {}, {3, 4, 9}} {
+		var buf bytes.Buffer
+		if err := newIntTree(vals...).EmitSorted(&buf, intCodec{}); err != nil {
+			t.Fatalf("EmitSorted() = %v", err)
+		}
+		streams = append(streams, &buf)
+	}
+	var merged bytes.Buffer
+	if err := MergeSorted(&merged, intCodec{}, intLess, streams...); err != nil {
+		t.Fatalf("MergeSorted() = %v", err)
+	}
+	back := New(intLess)
+	if err := back.LoadSorted(&merged, intCodec{}); err != nil {
+		t.Fatalf("LoadSorted() = %v", err)
+	}
+	if got, want := inOrderInts(back), []int{1, 2, 3, 4, 5, 7, 8, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadSorted(MergeSorted()) = %v, want %v", got, want)
+	}
+
+	if err := MergeSorted(&merged, intCodec{}, intLess, bytes.NewReader([]byte{5, 1})); err == nil {
+		t.Errorf("MergeSorted() of a truncated stream = nil, want error")
+	}
+}
@@ -0,0 +1,255 @@
+// Package treetest tests `btree.BTree`s against a model: it runs random sequences of operations on
+// both a tree and a sorted slice, the reference, and checks after every step that they agree. This
+// finds bugs in the tree, but also in the caller's `LessFunc` and payloads, e.g. a comparator that
+// is not a strict weak ordering. It is used by the fuzz target of this package, and may be used
+// by the tests of any package that stores its own payloads in a tree:
+//
+//	func TestPeopleTree(t *testing.T) {
+//	    cfg := treetest.Config{Less: lessByName, Payload: randomPerson}
+//	    if err := treetest.Run(cfg, rand.New(rand.NewSource(1))); err != nil {
+//	        t.Fatal(err)
+//	    }
+//	}
+package treetest
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/KarelKubat/btree"
+)
+
+// Config describes what to test.
+type Config struct {
+	// Less orders the payloads. It is the `LessFunc` of the tree, and orders the model.
+	Less btree.LessFunc
+	// Payload returns a random payload. Payloads should often be equal to earlier ones, so that
+	// lookups and deletions find them, e.g. by drawing from a limited range.
+	Payload func(rng *rand.Rand) interface{}
+	// New returns the empty tree to test, e.g. one with `LazyDelete` or `UseFinger` set. When it is
+	// `nil`, the tree is `btree.New(Less)`.
+	New func() *btree.BTree
+	// Steps is the number of operations of `Run()`. When it is 0, 1000 operations are run.
+	Steps int
+}
+
+// op is an operation on the tree, which `do` carries out on both the tree and the model.
+type op struct {
+	name string
+	do   func(h *harness, rng *rand.Rand) error
+}
+
+// ops are the operations, each listed as often as it should be chosen relative to the others.
+var ops = []op{
+	{"Upsert", upsert}, {"Upsert", upsert}, {"Upsert", upsert}, {"Upsert", upsert},
+	{"Delete", remove}, {"Delete", remove}, {"Delete", remove},
+	{"Find", find}, {"Find", find},
+	{"AscendRange", ascendRange},
+	{"MinMax", minMax},
+	{"BulkUpsert", bulkUpsert},
+	{"Rebalance", rebalance},
+}
+
+// harness holds the tree under test and the model: its payloads, in order.
+type harness struct {
+	cfg   Config
+	tree  *btree.BTree
+	model []interface{}
+}
+
+// Run runs `cfg.Steps` random operations, drawn from `rng`, on a tree and on the model. It returns
+// an error that describes the first operation after which the tree disagreed with the model, or
+// `nil`.
+func Run(cfg Config, rng *rand.Rand) error {
+	steps := cfg.Steps
+	if steps == 0 {
+		steps = 1000
+	}
+	h := newHarness(cfg)
+	for step := 0; step < steps; step++ {
+		if err := h.step(step, ops[rng.Intn(len(ops))], rng); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunBytes is `Run()`, but with the operations taken from `data`, two bytes each: the operation and
+// the seed of its random payloads. It is meant for fuzz targets, which then explore sequences of
+// operations:
+//
+//	func FuzzTree(f *testing.F) {
+//	    f.Fuzz(func(t *testing.T, data []byte) {
+//	        if err := treetest.RunBytes(cfg, data); err != nil {
+//	            t.Fatal(err)
+//	        }
+//	    })
+//	}
+func RunBytes(cfg Config, data []byte) error {
+	h := newHarness(cfg)
+	for step := 0; len(data) >= 2; step++ {
+		o, seed := ops[int(data[0])%len(ops)], int64(data[1])
+		data = data[2:]
+		if err := h.step(step, o, rand.New(rand.NewSource(seed))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newHarness(cfg Config) *harness {
+	tree := btree.New(cfg.Less)
+	if cfg.New != nil {
+		tree = cfg.New()
+	}
+	return &harness{cfg: cfg, tree: tree, model: []interface{}{}}
+}
+
+// step runs `o`, and compares the tree with the model afterwards.
+func (h *harness) step(step int, o op, rng *rand.Rand) error {
+	if err := o.do(h, rng); err != nil {
+		return fmt.Errorf("treetest: step %d: %v: %v", step, o.name, err)
+	}
+	if err := h.tree.Verify(); err != nil {
+		return fmt.Errorf("treetest: step %d: after %v: %v", step, o.name, err)
+	}
+	if err := h.compare(); err != nil {
+		return fmt.Errorf("treetest: step %d: after %v: %v", step, o.name, err)
+	}
+	return nil
+}
+
+func (h *harness) less(a, b interface{}) bool {
+	return h.cfg.Less(&btree.Node{Payload: a}, &btree.Node{Payload: b})
+}
+
+// search returns the position of `p` in the model, or where it would be inserted, and whether it
+// is present.
+func (h *harness) search(p interface{}) (i int, found bool) {
+	i = sort.Search(len(h.model), func(i int) bool { return !h.less(h.model[i], p) })
+	return i, i < len(h.model) && !h.less(p, h.model[i])
+}
+
+// compare returns an error when the in-order payloads of the tree differ from the model.
+func (h *harness) compare() error {
+	// The model only stays sorted when `Less` is a strict ordering; then successive payloads are
+	// smaller than the next, and not larger.
+	for i := 1; i < len(h.model); i++ {
+		if a, b := h.model[i-1], h.model[i]; !h.less(a, b) || h.less(b, a) {
+			return fmt.Errorf("the LessFunc does not order %v and %v consistently", a, b)
+		}
+	}
+	got := []interface{}{}
+	h.tree.DepthFirstInOrder(func(n *btree.Node) { got = append(got, n.Payload) })
+	for i := range got {
+		if i >= len(h.model) {
+			return fmt.Errorf("the tree holds %v, which is not in the model", got[i])
+		}
+		if got[i] != h.model[i] {
+			return fmt.Errorf("the tree holds %v at position %d, the model %v",
+				got[i], i, h.model[i])
+		}
+	}
+	if len(got) < len(h.model) {
+		return fmt.Errorf("the model holds %v at position %d, which is not in the tree",
+			h.model[len(got)], len(got))
+	}
+	return nil
+}
+
+func upsert(h *harness, rng *rand.Rand) error {
+	p := h.cfg.Payload(rng)
+	i, found := h.search(p)
+	intree, inserted := h.tree.Upsert(&btree.Node{Payload: p})
+	if inserted == found {
+		return fmt.Errorf("Upsert(%v) inserted = %v, want %v", p, inserted, !found)
+	}
+	if !found {
+		h.model = append(h.model[:i], append([]interface{}{p}, h.model[i:]...)...)
+	}
+	if intree.Payload != h.model[i] {
+		return fmt.Errorf("Upsert(%v) returned %v, want %v", p, intree.Payload, h.model[i])
+	}
+	return nil
+}
+
+func remove(h *harness, rng *rand.Rand) error {
+	p := h.cfg.Payload(rng)
+	i, found := h.search(p)
+	removed, deleted := h.tree.Delete(&btree.Node{Payload: p})
+	if deleted != found {
+		return fmt.Errorf("Delete(%v) deleted = %v, want %v", p, deleted, found)
+	}
+	if found {
+		if removed.Payload != h.model[i] {
+			return fmt.Errorf("Delete(%v) removed %v, want %v", p, removed.Payload, h.model[i])
+		}
+		h.model = append(h.model[:i], h.model[i+1:]...)
+	}
+	return nil
+}
+
+func find(h *harness, rng *rand.Rand) error {
+	p := h.cfg.Payload(rng)
+	i, found := h.search(p)
+	intree, ok := h.tree.Find(&btree.Node{Payload: p})
+	if ok != found {
+		return fmt.Errorf("Find(%v) found = %v, want %v", p, ok, found)
+	}
+	if found && intree.Payload != h.model[i] {
+		return fmt.Errorf("Find(%v) returned %v, want %v", p, intree.Payload, h.model[i])
+	}
+	return nil
+}
+
+func ascendRange(h *harness, rng *rand.Rand) error {
+	from, to := h.cfg.Payload(rng), h.cfg.Payload(rng)
+	if h.less(to, from) {
+		from, to = to, from
+	}
+	lo, _ := h.search(from)
+	hi, _ := h.search(to)
+	got := []interface{}{}
+	h.tree.AscendRange(&btree.Node{Payload: from}, &btree.Node{Payload: to}, func(n *btree.Node) bool {
+		got = append(got, n.Payload)
+		return true
+	})
+	if want := h.model[lo:hi]; fmt.Sprint(got) != fmt.Sprint(want) {
+		return fmt.Errorf("AscendRange(%v, %v) = %v, want %v", from, to, got, want)
+	}
+	return nil
+}
+
+func minMax(h *harness, _ *rand.Rand) error {
+	min, max := h.tree.Min(), h.tree.Max()
+	if len(h.model) == 0 {
+		if min != nil || max != nil {
+			return fmt.Errorf("Min(), Max() of an empty tree = %v, %v, want nil, nil", min, max)
+		}
+		return nil
+	}
+	first, last := h.model[0], h.model[len(h.model)-1]
+	if min == nil || max == nil || min.Payload != first || max.Payload != last {
+		return fmt.Errorf("Min(), Max() = %v, %v, want %v, %v", min, max, first, last)
+	}
+	return nil
+}
+
+func bulkUpsert(h *harness, rng *rand.Rand) error {
+	nodes := []*btree.Node{}
+	for n := rng.Intn(10); n > 0; n-- {
+		p := h.cfg.Payload(rng)
+		nodes = append(nodes, &btree.Node{Payload: p})
+		if i, found := h.search(p); !found {
+			h.model = append(h.model[:i], append([]interface{}{p}, h.model[i:]...)...)
+		}
+	}
+	h.tree.BulkUpsert(nodes)
+	return nil
+}
+
+func rebalance(h *harness, _ *rand.Rand) error {
+	h.tree.Rebalance()
+	return nil
+}
@@ -0,0 +1,62 @@
+package treetest
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/KarelKubat/btree"
+)
+
+func intLess(a, b *btree.Node) bool {
+	return a.Payload.(int) < b.Payload.(int)
+}
+
+func intPayload(rng *rand.Rand) interface{} {
+	return rng.Intn(100)
+}
+
+func TestRun(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		new  func() *btree.BTree
+	}{
+		{desc: "plain"},
+		{desc: "lazy delete", new: func() *btree.BTree {
+			b := btree.New(intLess)
+			b.LazyDelete = true
+			return b
+		}},
+		{desc: "finger", new: func() *btree.BTree {
+			b := btree.New(intLess)
+			b.UseFinger = true
+			return b
+		}},
+	} {
+		cfg := Config{Less: intLess, Payload: intPayload, New: test.new, Steps: 2000}
+		if err := Run(cfg, rand.New(rand.NewSource(1))); err != nil {
+			t.Errorf("%v: Run() = %v, want nil", test.desc, err)
+		}
+	}
+}
+
+func TestRunFindsBrokenLess(t *testing.T) {
+	// lessOrEqual is not a strict ordering: equal payloads are never found.
+	lessOrEqual := func(a, b *btree.Node) bool {
+		return a.Payload.(int) <= b.Payload.(int)
+	}
+	err := Run(Config{Less: lessOrEqual, Payload: intPayload}, rand.New(rand.NewSource(1)))
+	if err == nil || !strings.HasPrefix(err.Error(), "treetest: step ") {
+		t.Errorf("Run() with a broken LessFunc = %v, want an error about a step", err)
+	}
+}
+
+func FuzzRunBytes(f *testing.F) {
+	f.Add([]byte{0, 1, 0, 2, 7, 1, 4, 1, 9, 3})
+	f.Add([]byte{11, 0, 10, 5, 12, 0, 6, 5})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := RunBytes(Config{Less: intLess, Payload: intPayload}, data); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package btree
+
+import "errors"
+
+// ErrTxnDone is returned by `Txn.Commit()` when the transaction was already committed or rolled
+// back.
+var ErrTxnDone = errors.New("btree: transaction already committed or rolled back")
+
+// Txn is a transaction on a `COWTree`: a batch of mutations that is applied atomically on
+// `Commit()`, or discarded on `Rollback()`. Until then, the mutations are only visible to the
+// transaction itself. Like the mutations of the `COWTree`, they copy the affected paths, so the
+// tree is never changed in place.
+//
+// When the tree is changed by others between `Begin()` and `Commit()`, the mutations of the
+// transaction are reapplied to the latest tree. The outcome of the commit may therefore differ
+// from what the transaction saw.
+//
+// When the tree has a `Pool`, a transaction is a reader of the tree (see `COWTree.Read()`) from
+// `Begin()` until `Commit()` or `Rollback()`, so that the nodes it shares with the tree are not
+// recycled meanwhile. Until it ends, the nodes that later mutations replace are not recycled
+// either, so a transaction should always be ended.
+//
+// A `Txn` is not safe for concurrent use, and must not be used after `Commit()` or `Rollback()`.
+type Txn struct {
+	c          *COWTree
+	base, root *Node
+	ops        []txnOp
+	done       bool
+	// phase is the epoch in which the transaction reads the tree.
+	phase uint32
+	// retired collects the nodes of `base` that the mutations of the transaction replaced.
+	retired []*Node
+}
+
+type txnOp struct {
+	n    *Node
+	kind txnOpKind
+}
+
+type txnOpKind int
+
+const (
+	txnUpsert txnOpKind = iota
+	txnReplace
+	txnDelete
+)
+
+// Begin starts a transaction on the current state of the tree.
+func (c *COWTree) Begin() *Txn {
+	tx := &Txn{c: c}
+	tx.phase = c.epochs.enter()
+	tx.base = c.root.Load()
+	tx.root = tx.base
+	return tx
+}
+
+// end ends the transaction as a reader of the tree.
+func (tx *Txn) end() {
+	tx.done = true
+	tx.ops = nil
+	tx.retired = nil
+	tx.c.epochs.leave(tx.phase)
+}
+
+// Find looks up a node in the state of the transaction, see `BTree.Find()`.
+func (tx *Txn) Find(n *Node) (intree *Node, found bool) {
+	return tx.Tree().Find(n)
+}
+
+// Tree returns the state of the transaction as a `BTree`, which must not be modified.
+func (tx *Txn) Tree() *BTree {
+	return &BTree{Root: tx.root, Less: tx.c.less}
+}
+
+// Upsert is `COWTree.Upsert()` within the transaction.
+func (tx *Txn) Upsert(n *Node) (intree *Node, inserted bool) {
+	tx.ops = append(tx.ops, txnOp{n: n, kind: txnUpsert})
+	n.Left, n.Right = nil, nil
+	top, intree, inserted := tx.c.copier(&tx.retired).upsert(tx.root, n, false)
+	tx.root = top
+	return intree, inserted
+}
+
+// Replace is `COWTree.Replace()` within the transaction.
+func (tx *Txn) Replace(n *Node) (old *Node, replaced bool) {
+	tx.ops = append(tx.ops, txnOp{n: n, kind: txnReplace})
+	n.Left, n.Right = nil, nil
+	top, old, inserted := tx.c.copier(&tx.retired).upsert(tx.root, n, true)
+	tx.root = top
+	if inserted {
+		return old, false
+	}
+	return tx.c.detached(old), true
+}
+
+// Delete is `COWTree.Delete()` within the transaction.
+func (tx *Txn) Delete(n *Node) (removed *Node, deleted bool) {
+	tx.ops = append(tx.ops, txnOp{n: n, kind: txnDelete})
+	top, removed := tx.c.copier(&tx.retired).delete(tx.root, n)
+	tx.root = top
+	if removed == nil {
+		return nil, false
+	}
+	return tx.c.detached(removed), true
+}
+
+// Commit publishes the mutations of the transaction atomically: readers of the tree see all of
+// them, or none.
+func (tx *Txn) Commit() error {
+	if tx.done {
+		return ErrTxnDone
+	}
+	c := tx.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer tx.end()
+	root, retired := tx.root, tx.retired
+	if cur := c.root.Load(); cur != tx.base {
+		// The nodes that the transaction replaced may be in use by `cur`, or be retired already;
+		// only the nodes that the replay replaces are retired.
+		root, retired = cur, nil
+		for _, op := range tx.ops {
+			switch op.kind {
+			case txnUpsert, txnReplace:
+				op.n.Left, op.n.Right = nil, nil
+				root, _, _ = c.copier(&retired).upsert(root, op.n, op.kind == txnReplace)
+			case txnDelete:
+				if top, removed := c.copier(&retired).delete(root, op.n); removed != nil {
+					root = top
+				}
+			}
+		}
+	}
+	c.publish(root, retired)
+	return nil
+}
+
+// Rollback discards the mutations of the transaction. Calling it after `Commit()` has no effect.
+func (tx *Txn) Rollback() {
+	if tx.done {
+		return
+	}
+	tx.root = tx.base
+	tx.end()
+}
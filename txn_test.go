@@ -0,0 +1,122 @@
+package btree
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestTxn(t *testing.T) {
+	c := NewCOW(intLess)
+	for _, v := range []int{5, 3, 8} {
+		c.Upsert(&Node{Payload: v})
+	}
+
+	tx := c.Begin()
+	tx.Upsert(&Node{Payload: 1})
+	tx.Delete(&Node{Payload: 8})
+	if _, found := tx.Find(&Node{Payload: 1}); !found {
+		t.Errorf("transaction doesn't see its own Upsert()")
+	}
+	if got, want := inOrderInts(c.Snapshot()), []int{3, 5, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("before Commit() = %v, want %v", got, want)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() = %v", err)
+	}
+	if got, want := inOrderInts(c.Snapshot()), []int{1, 3, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after Commit() = %v, want %v", got, want)
+	}
+	if err := tx.Commit(); err != ErrTxnDone {
+		t.Errorf("second Commit() = %v, want %v", err, ErrTxnDone)
+	}
+
+	tx = c.Begin()
+	tx.Delete(&Node{Payload: 1})
+	tx.Rollback()
+	if got, want := inOrderInts(c.Snapshot()), []int{1, 3, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after Rollback() = %v, want %v", got, want)
+	}
+	if err := tx.Commit(); err != ErrTxnDone {
+		t.Errorf("Commit() after Rollback() = %v, want %v", err, ErrTxnDone)
+	}
+}
+
+func TestTxnConcurrentChange(t *testing.T) {
+	c := NewCOW(kvLess)
+	c.Upsert(&Node{Payload: kv{key: "a", val: "1"}})
+
+	tx := c.Begin()
+	tx.Replace(&Node{Payload: kv{key: "a", val: "2"}})
+	tx.Upsert(&Node{Payload: kv{key: "b", val: "1"}})
+	tx.Delete(&Node{Payload: kv{key: "c"}})
+
+	c.Upsert(&Node{Payload: kv{key: "c", val: "1"}})
+	c.Upsert(&Node{Payload: kv{key: "d", val: "1"}})
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() = %v", err)
+	}
+	want := []string{"a", "2", "b", "1", "d", "1"}
+	if got := kvPairs(c.Snapshot()); !reflect.DeepEqual(got, want) {
+		t.Errorf("after Commit() = %v, want %v", got, want)
+	}
+}
+
+func TestTxnPool(t *testing.T) {
+	c := NewCOW(intLess)
+	c.Pool = NewNodePool()
+	for _, v := range []int{50, 30, 70} {
+		c.Upsert(&Node{Payload: v})
+	}
+	tx := c.Begin()
+
+	// Concurrent writers churn the tree; the nodes that the transaction sees must not be recycled.
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				v := 1000 + w*200 + i
+				c.Upsert(&Node{Payload: v})
+				c.Delete(&Node{Payload: v})
+			}
+		}()
+	}
+	wg.Wait()
+	if got, want := inOrderInts(tx.Tree()), []int{30, 50, 70}; !reflect.DeepEqual(got, want) {
+		t.Errorf("transaction after concurrent writes = %v, want %v", got, want)
+	}
+	tx.Upsert(&Node{Payload: 60})
+	tx.Delete(&Node{Payload: 30})
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() = %v", err)
+	}
+	var got []int
+	c.Read(func(b *BTree) { got = inOrderInts(b) })
+	if want := []int{50, 60, 70}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after Commit() = %v, want %v", got, want)
+	}
+
+	// A transaction on an unchanged tree retires the nodes that it replaced.
+	tx = c.Begin()
+	tx.Upsert(&Node{Payload: 80})
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() = %v", err)
+	}
+	if n := len(c.pending) + len(c.waiting); n == 0 {
+		t.Errorf("no nodes await recycling after Commit(), want the replaced path")
+	}
+	c.Read(func(b *BTree) { got = inOrderInts(b) })
+	if want := []int{50, 60, 70, 80}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after second Commit() = %v, want %v", got, want)
+	}
+
+	// Rollback ends the transaction as a reader, also when called twice.
+	tx = c.Begin()
+	tx.Rollback()
+	tx.Rollback()
+	if r := c.readers[0].Load() + c.readers[1].Load(); r != 0 {
+		t.Errorf("%v readers after Rollback(), want 0", r)
+	}
+}
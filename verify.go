@@ -0,0 +1,69 @@
+package btree
+
+import "fmt"
+
+// Verify checks the invariants of the tree, and returns an error that names the first offending
+// node, or `nil` when the tree is sound. It is meant for tests and debugging, e.g. after
+// manipulating `Root`, `Left` or `Right` directly. Verify checks that:
+//
+//   - Every node is larger than the nodes of its left subtree and smaller than those of its right
+//     subtree, according to `Less`; so there are no equal nodes either.
+//   - Every node is reached only once: there are no cycles, and no subtrees are shared.
+//   - The cached positions of `UseFinger` and `Append()`, and the nodes that `LazyDelete` marked
+//     deleted, are nodes of the tree.
+func (b *BTree) Verify() error {
+	defer b.beginRead("Verify")()
+	if b.Root == nil {
+		return nil
+	}
+	if b.Less == nil {
+		return fmt.Errorf("btree: the tree has no LessFunc")
+	}
+	// bounded is a node plus the bounds `lo < node < hi` that its ancestors impose.
+	type bounded struct {
+		n, lo, hi *Node
+	}
+	seen := map[*Node]struct{}{}
+	stack := []bounded{{n: b.Root}}
+	for len(stack) > 0 {
+		at := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := seen[at.n]; ok {
+			return fmt.Errorf("btree: node %v is reached twice, the tree has a cycle or a shared "+
+				"subtree", at.n.Payload)
+		}
+		seen[at.n] = struct{}{}
+		if at.lo != nil && !b.Less(at.lo, at.n) {
+			return fmt.Errorf("btree: node %v is not larger than %v, which it is right of",
+				at.n.Payload, at.lo.Payload)
+		}
+		if at.hi != nil && !b.Less(at.n, at.hi) {
+			return fmt.Errorf("btree: node %v is not smaller than %v, which it is left of",
+				at.n.Payload, at.hi.Payload)
+		}
+		if at.n.Right != nil {
+			stack = append(stack, bounded{n: at.n.Right, lo: at.n, hi: at.hi})
+		}
+		if at.n.Left != nil {
+			stack = append(stack, bounded{n: at.n.Left, lo: at.lo, hi: at.n})
+		}
+	}
+	for _, f := range []struct {
+		desc string
+		f    finger
+	}{
+		{desc: "last upserted node", f: b.finger},
+		{desc: "largest node", f: b.tail},
+	} {
+		if _, ok := seen[f.f.node]; f.f.node != nil && f.f.root == b.Root && !ok {
+			return fmt.Errorf("btree: the cached position of the %v is node %v, which is not in the "+
+				"tree; call ResetFinger() after changing the tree directly", f.desc, f.f.node.Payload)
+		}
+	}
+	for n := range b.tombstones {
+		if _, ok := seen[n]; !ok {
+			return fmt.Errorf("btree: node %v is marked deleted, but is not in the tree", n.Payload)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package btree
+
+import "testing"
+
+func TestVerify(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		tree func() *BTree
+		want string
+	}{
+		{
+			desc: "empty",
+			tree: func() *BTree { return New(intLess) },
+		},
+		{
+			desc: "sound",
+			tree: func() *BTree { return newIntTree(50, 20, 80, 10, 30, 70, 90) },
+		},
+		{
+			desc: "out of order",
+			tree: func() *BTree {
+				b := newIntTree(50, 20, 80, 10, 30)
+				b.Root.Left.Right.Payload = 60
+				return b
+			},
+			want: "btree: node 60 is not smaller than 50, which it is left of",
+		},
+		{
+			desc: "out of order below a right turn",
+			tree: func() *BTree {
+				b := newIntTree(50, 20, 80, 70)
+				b.Root.Right.Left.Payload = 40
+				return b
+			},
+			want: "btree: node 40 is not larger than 50, which it is right of",
+		},
+		{
+			desc: "duplicate",
+			tree: func() *BTree {
+				b := newIntTree(50, 20)
+				b.Root.Right = &Node{Payload: 50}
+				return b
+			},
+			want: "btree: node 50 is not larger than 50, which it is right of",
+		},
+		{
+			desc: "cycle",
+			tree: func() *BTree {
+				b := newIntTree(50, 20)
+				b.Root.Left.Left = b.Root.Left
+				return b
+			},
+			want: "btree: node 20 is reached twice, the tree has a cycle or a shared subtree",
+		},
+		{
+			desc: "stale finger",
+			tree: func() *BTree {
+				b := New(intLess)
+				b.UseFinger = true
+				for _, v := range []int{50, 20, 80} {
+					b.Upsert(&Node{Payload: v})
+				}
+				b.Root.Right = nil
+				return b
+			},
+			want: "btree: the cached position of the last upserted node is node 80, which is not in the tree; call ResetFinger() after changing the tree directly",
+		},
+		{
+			desc: "stale tombstone",
+			tree: func() *BTree {
+				b := newIntTree(50, 20, 80)
+				b.LazyDelete = true
+				b.Delete(&Node{Payload: 20})
+				b.Root.Left = nil
+				return b
+			},
+			want: "btree: node 20 is marked deleted, but is not in the tree",
+		},
+	} {
+		got := ""
+		if err := test.tree().Verify(); err != nil {
+			got = err.Error()
+		}
+		if got != test.want {
+			t.Errorf("%v: Verify() = %q, want %q", test.desc, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package btree
+
+// Version returns the version of the tree, a counter that is incremented by each mutation:
+// `Upsert()` that inserts, `Update()`, `BulkUpsert()`, `Delete()` that deletes, and `Write()`.
+// Layers on top of the tree can use it for optimistic concurrency: remember the version when
+// reading, and use e.g. `CompareAndUpsert()` to only write when nothing changed in between.
+func (s *SyncTree) Version() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version
+}
+
+// CompareAndUpsert is `Upsert()`, but only when the tree is still at `version`. The return value
+// `ok` is `false` when the version differs; then nothing is done.
+func (s *SyncTree) CompareAndUpsert(n *Node, version uint64) (intree *Node, inserted, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.version != version {
+		return nil, false, false
+	}
+	intree, inserted = s.upsert(n)
+	return intree, inserted, true
+}
+
+// CompareAndUpdate is `Update()`, but only when the tree is still at `version`. It returns
+// `false` when the version differs; then nothing is done and `fn` is not called.
+func (s *SyncTree) CompareAndUpdate(n *Node, version uint64, fn func(intree *Node, inserted bool)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.version != version {
+		return false
+	}
+	s.update(n, fn)
+	return true
+}
+
+// CompareAndDelete is `Delete()`, but only when the tree is still at `version`. The return value
+// `ok` is `false` when the version differs; then nothing is done.
+func (s *SyncTree) CompareAndDelete(n *Node, version uint64) (removed *Node, deleted, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.version != version {
+		return nil, false, false
+	}
+	removed, deleted = s.delete(n)
+	return removed, deleted, true
+}
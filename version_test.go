@@ -0,0 +1,71 @@
+package btree
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	s := Synchronized(New(intLess))
+	v0 := s.Version()
+	s.Upsert(&Node{Payload: 1})
+	s.Upsert(&Node{Payload: 1}) // not inserted, no change
+	s.Delete(&Node{Payload: 2}) // not deleted, no change
+	v1 := s.Version()
+	if v1 != v0+1 {
+		t.Errorf("Version() after one change = %v, want %v", v1, v0+1)
+	}
+
+	if _, inserted, ok := s.CompareAndUpsert(&Node{Payload: 2}, v0); ok || inserted {
+		t.Errorf("CompareAndUpsert() with old version = %v, %v; want not ok", inserted, ok)
+	}
+	if _, inserted, ok := s.CompareAndUpsert(&Node{Payload: 2}, v1); !ok || !inserted {
+		t.Errorf("CompareAndUpsert() with current version = %v, %v; want inserted, ok", inserted, ok)
+	}
+	v2 := s.Version()
+	if s.CompareAndUpdate(&Node{Payload: 2}, v1, func(*Node, bool) { t.Errorf("fn called") }) {
+		t.Errorf("CompareAndUpdate() with old version = ok")
+	}
+	if _, deleted, ok := s.CompareAndDelete(&Node{Payload: 1}, v2); !ok || !deleted {
+		t.Errorf("CompareAndDelete() with current version = %v, %v; want deleted, ok", deleted, ok)
+	}
+	if _, found := s.Find(&Node{Payload: 1}); found {
+		t.Errorf("CompareAndDelete() left the node")
+	}
+	s.Write(func(*BTree) {})
+	if s.Version() != v2+2 {
+		t.Errorf("Version() = %v, want %v", s.Version(), v2+2)
+	}
+}
+
+func TestCompareAndUpdateConcurrent(t *testing.T) {
+	s := Synchronized(New(func(a, b *Node) bool { return false })) // holds a single node
+	s.Upsert(&Node{Payload: 0})
+	get := func() int {
+		var count int
+		s.Read(func(b *BTree) { count = b.Root.Payload.(int) })
+		return count
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				// Read, compute, and write back unless someone else got there first.
+				for {
+					version := s.Version()
+					next := get() + 1
+					if s.CompareAndUpdate(&Node{}, version, func(intree *Node, _ bool) { intree.Payload = next }) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := get(); got != 800 {
+		t.Errorf("count = %v, want 800", got)
+	}
+}
@@ -0,0 +1,98 @@
+package btree
+
+// Walker walks trees using a stack that is kept between walks, so that services that walk trees
+// at a high rate don't allocate for each walk, and deep trees don't cost call stack. A `Walker`
+// may walk any number of trees, one at a time; it is not safe for concurrent use.
+//
+// Nodes can be pulled one by one:
+//
+//	w.Start(b)
+//	for n := w.Next(); n != nil; n = w.Next() {
+//		...
+//	}
+//
+// or pushed to a `WalkFunc` using `DepthFirstInOrder()` or `DepthFirstReverse()`.
+type Walker struct {
+	stack   []*Node
+	reverse bool
+	tree    *BTree
+}
+
+// NewWalker returns a `Walker` whose stack is preallocated for the height of `b`. The stack grows
+// when a deeper tree is walked.
+func NewWalker(b *BTree) *Walker {
+	return &Walker{stack: make([]*Node, 0, height(b.Root))}
+}
+
+// Start starts an in-order walk of `b`. It abandons any walk that was in progress.
+func (w *Walker) Start(b *BTree) {
+	w.stack, w.reverse, w.tree = w.stack[:0], false, b
+	w.push(b.Root)
+}
+
+// StartReverse starts a walk of `b` in reverse order. It abandons any walk that was in progress.
+func (w *Walker) StartReverse(b *BTree) {
+	w.stack, w.reverse, w.tree = w.stack[:0], true, b
+	w.push(b.Root)
+}
+
+// push stacks `n` and its left (or when walking in reverse, right) descendants.
+func (w *Walker) push(n *Node) {
+	for n != nil {
+		w.stack = append(w.stack, n)
+		if w.reverse {
+			n = n.Right
+		} else {
+			n = n.Left
+		}
+	}
+}
+
+// Next returns the next node of the walk, or `nil` when the walk is done. The tree must not be
+// changed during the walk.
+func (w *Walker) Next() *Node {
+	for len(w.stack) > 0 {
+		n := w.stack[len(w.stack)-1]
+		w.stack[len(w.stack)-1] = nil
+		w.stack = w.stack[:len(w.stack)-1]
+		if w.reverse {
+			w.push(n.Left)
+		} else {
+			w.push(n.Right)
+		}
+		if !w.tree.hidden(n) {
+			return n
+		}
+	}
+	return nil
+}
+
+// DepthFirstInOrder calls `walk` for each node of `b`, like `BTree.DepthFirstInOrder()`.
+func (w *Walker) DepthFirstInOrder(b *BTree, walk WalkFunc) {
+	for w.Start(b); ; {
+		n := w.Next()
+		if n == nil {
+			return
+		}
+		walk(n)
+	}
+}
+
+// DepthFirstReverse calls `walk` for each node of `b`, like `BTree.DepthFirstReverse()`.
+func (w *Walker) DepthFirstReverse(b *BTree, walk WalkFunc) {
+	for w.StartReverse(b); ; {
+		n := w.Next()
+		if n == nil {
+			return
+		}
+		walk(n)
+	}
+}
+
+// height returns the height of the subtree under `n`.
+func height(n *Node) int {
+	if n == nil {
+		return 0
+	}
+	return 1 + max(height(n.Left), height(n.Right))
+}
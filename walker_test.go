@@ -0,0 +1,57 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestWalker(t *testing.T) {
+	w := NewWalker(New(intLess))
+	for _, vals := range [][]int{{}, {1}, {5, 3, 8, 1, 4, 7, 9}, {1, 2, 3, 4, 5}} {
+		b := newIntTree(vals...)
+		want := inOrderInts(b)
+		got := []int{}
+		w.DepthFirstInOrder(b, func(n *Node) { got = append(got, n.Payload.(int)) })
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("DepthFirstInOrder(%v) = %v, want %v", vals, got, want)
+		}
+		var reverse []int
+		w.DepthFirstReverse(b, func(n *Node) { reverse = append(reverse, n.Payload.(int)) })
+		for i, j := 0, len(reverse)-1; i < j; i, j = i+1, j-1 {
+			reverse[i], reverse[j] = reverse[j], reverse[i]
+		}
+		if len(reverse) == 0 {
+			reverse = []int{}
+		}
+		if !reflect.DeepEqual(reverse, want) {
+			t.Errorf("DepthFirstReverse(%v) = %v reversed, want %v", vals, reverse, want)
+		}
+	}
+}
+
+func TestWalkerReusesStack(t *testing.T) {
+	b := BuildParallel(intLess, intNodes(rand.New(rand.NewSource(1)).Perm(1000)...))
+	w := NewWalker(b)
+	walk := func(*Node) {}
+	if allocs := testing.AllocsPerRun(10, func() { w.DepthFirstInOrder(b, walk) }); allocs != 0 {
+		t.Errorf("DepthFirstInOrder() allocates %v times, want 0", allocs)
+	}
+	if allocs := testing.AllocsPerRun(10, func() {
+		for w.StartReverse(b); w.Next() != nil; {
+		}
+	}); allocs != 0 {
+		t.Errorf("Next() allocates %v times, want 0", allocs)
+	}
+}
+
+func BenchmarkWalker(b *testing.B) {
+	t := BuildParallel(intLess, intNodes(rand.New(rand.NewSource(1)).Perm(100000)...))
+	w := NewWalker(t)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for w.Start(t); w.Next() != nil; {
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package btree
+
+import (
+	"fmt"
+	"sync"
+)
+
+// EventKind tells what happened to a node, see `Event`.
+type EventKind int
+
+const (
+	// Inserted means that the node was added to the tree.
+	Inserted EventKind = iota
+	// Updated means that the payload of the node was (possibly) changed by `SyncTree.Update()`.
+	Updated
+	// Deleted means that the node was removed from the tree.
+	Deleted
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case Inserted:
+		return "inserted"
+	case Updated:
+		return "updated"
+	case Deleted:
+		return "deleted"
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// Event describes a change to a node of a `SyncTree`, see `SyncTree.Watch()`.
+type Event struct {
+	Kind EventKind
+	// Payload is the payload of the node. It is shared with the tree.
+	Payload interface{}
+}
+
+// watcher delivers the events for the range `from <= n < to` to `out`. Events are queued, so that
+// writers never wait for slow receivers.
+type watcher struct {
+	from, to *Node
+	mu       sync.Mutex
+	queue    []Event
+	wake     chan struct{}
+	done     chan struct{}
+	out      chan Event
+}
+
+// Watch returns a channel that receives an `Event` for each change of a node `n` with
+// `from <= n < to`, in the order of the changes. A `nil` bound leaves the range open at that end.
+// The channel is closed after `cancel` is called; `cancel` may be called more than once.
+//
+// Events are reported for `Upsert()`, `Update()`, `BulkUpsert()` and `Delete()`; changes made
+// within `Write()` are not. Since events are queued without limit, receivers should keep up or
+// cancel.
+func (s *SyncTree) Watch(from, to *Node) (events <-chan Event, cancel func()) {
+	w := &watcher{
+		from: from,
+		to:   to,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+		out:  make(chan Event),
+	}
+	s.mu.Lock()
+	if s.watchers == nil {
+		s.watchers = map[*watcher]struct{}{}
+	}
+	s.watchers[w] = struct{}{}
+	s.mu.Unlock()
+	go w.run()
+
+	var once sync.Once
+	return w.out, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.watchers, w)
+			s.mu.Unlock()
+			close(w.done)
+		})
+	}
+}
+
+// notify reports a change of `n` to the interested watchers. The write lock must be held.
+func (s *SyncTree) notify(kind EventKind, n *Node) {
+	for w := range s.watchers {
+		if (w.from == nil || !s.t.Less(n, w.from)) && (w.to == nil || s.t.Less(n, w.to)) {
+			w.push(Event{Kind: kind, Payload: n.Payload})
+		}
+	}
+}
+
+func (w *watcher) push(ev Event) {
+	w.mu.Lock()
+	w.queue = append(w.queue, ev)
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default: // already awake
+	}
+}
+
+// run forwards queued events to the receiver, until the watcher is cancelled.
+func (w *watcher) run() {
+	defer close(w.out)
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.wake:
+		}
+		for {
+			w.mu.Lock()
+			if len(w.queue) == 0 {
+				w.mu.Unlock()
+				break
+			}
+			ev := w.queue[0]
+			w.queue[0] = Event{}
+			w.queue = w.queue[1:]
+			w.mu.Unlock()
+			select {
+			case w.out <- ev:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package btree
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	type event struct {
+		kind EventKind
+		val  int
+	}
+	s := Synchronized(New(intLess))
+	events, cancel := s.Watch(&Node{Payload: 10}, &Node{Payload: 20})
+
+	s.Upsert(&Node{Payload: 5})
+	s.Upsert(&Node{Payload: 10})
+	s.Upsert(&Node{Payload: 10})
+	s.Update(&Node{Payload: 15}, func(*Node, bool) {})
+	s.Update(&Node{Payload: 15}, func(*Node, bool) {})
+	s.BulkUpsert(intNodes(12, 15, 19, 20))
+	s.Delete(&Node{Payload: 10})
+	s.Delete(&Node{Payload: 42})
+
+	want := []event{
+		{Inserted, 10},
+		{Inserted, 15},
+		{Updated, 15},
+		{Inserted, 12},
+		{Inserted, 19},
+		{Deleted, 10},
+	}
+	var got []event
+	for len(got) < len(want) {
+		select {
+		case ev := <-events:
+			got = append(got, event{ev.Kind, ev.Payload.(int)})
+		case <-time.After(10 * time.Second):
+			t.Fatalf("events = %v, want %v", got, want)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	cancel()
+	cancel()
+	for ev := range events {
+		t.Errorf("event after cancel: %v", ev)
+	}
+	s.Upsert(&Node{Payload: 11})
+}
+
+func TestWatchSlowReceiver(t *testing.T) {
+	s := Synchronized(New(intLess))
+	events, cancel := s.Watch(nil, nil)
+	defer cancel()
+	for v := 0; v < 1000; v++ {
+		s.Upsert(&Node{Payload: v}) // must not block
+	}
+	for v := 0; v < 1000; v++ {
+		if ev := <-events; ev.Payload != v || ev.Kind != Inserted {
+			t.Fatalf("event %v = %v %v, want inserted %v", v, ev.Kind, ev.Payload, v)
+		}
+	}
+}